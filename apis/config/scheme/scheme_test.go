@@ -37,6 +37,7 @@ import (
 	"sigs.k8s.io/scheduler-plugins/pkg/networkaware/topologicalsort"
 	"sigs.k8s.io/scheduler-plugins/pkg/noderesources"
 	"sigs.k8s.io/scheduler-plugins/pkg/preemptiontoleration"
+	"sigs.k8s.io/scheduler-plugins/pkg/sharedev"
 	"sigs.k8s.io/scheduler-plugins/pkg/trimaran/loadvariationriskbalancing"
 	"sigs.k8s.io/scheduler-plugins/pkg/trimaran/lowriskovercommitment"
 	"sigs.k8s.io/scheduler-plugins/pkg/trimaran/targetloadpacking"
@@ -483,6 +484,35 @@ profiles:
       - "networkAware"
       weightsName: "netCosts"
       networkTopologyName: "net-topology-v1"
+  - name: ShareDevPlugin
+    args:
+      deviceManagerPort: 6000
+      allocatorNamespace: "sharedev"
+      allocatorImage: "example.com/allocator:v1"
+      getAvailableDevicesTimeoutSeconds: 2
+      reservePodQuotaTimeoutSeconds: 5
+      allocationTimeoutSeconds: 120
+      deviceInventoryResyncPeriodSeconds: 10
+      reservationReconcilePeriodSeconds: 60
+      reservationLeaseSeconds: 30
+      deviceManagerUnreachableSeconds: 120
+      noFitPolicy: Preempt
+      deviceModelCosts:
+      - vendor: example.com
+        model: big
+        cost: 10
+      scoringStrategy:
+        type: MostAllocated
+        resources:
+        - name: compute
+          weight: 2
+      deviceManagerEndpoint:
+        type: Template
+        template: "{{.NodeName}}.device-manager.kube-system.svc:50051"
+      deviceManagerTLS:
+        caFile: /etc/sharedev/ca.crt
+        certFile: /etc/sharedev/tls.crt
+        keyFile: /etc/sharedev/tls.key
 `),
 			wantProfiles: []schedconfig.KubeSchedulerProfile{
 				{
@@ -503,6 +533,38 @@ profiles:
 								NetworkTopologyName: "net-topology-v1",
 							},
 						},
+						{
+							Name: sharedev.Name,
+							Args: &config.ShareDevPluginArgs{
+								DeviceManagerPort:                  6000,
+								AllocatorNamespace:                 "sharedev",
+								AllocatorImage:                     "example.com/allocator:v1",
+								GetAvailableDevicesTimeoutSeconds:  2,
+								ReservePodQuotaTimeoutSeconds:      5,
+								AllocationTimeoutSeconds:           120,
+								DeviceInventoryResyncPeriodSeconds: 10,
+								ReservationReconcilePeriodSeconds:  60,
+								ReservationLeaseSeconds:            30,
+								DeviceManagerUnreachableSeconds:    120,
+								ScoringStrategy: config.ScoringStrategy{
+									Type:      config.MostAllocated,
+									Resources: []schedconfig.ResourceSpec{{Name: "compute", Weight: 2}},
+								},
+								DeviceManagerEndpoint: config.DeviceManagerEndpoint{
+									Type:     config.TemplateEndpoint,
+									Template: "{{.NodeName}}.device-manager.kube-system.svc:50051",
+								},
+								DeviceManagerTLS: config.DeviceManagerTLS{
+									CAFile:   "/etc/sharedev/ca.crt",
+									CertFile: "/etc/sharedev/tls.crt",
+									KeyFile:  "/etc/sharedev/tls.key",
+								},
+								NoFitPolicy: config.PreemptNoFit,
+								DeviceModelCosts: []config.DeviceModelCost{
+									{Vendor: "example.com", Model: "big", Cost: 10},
+								},
+							},
+						},
 						{
 							Name: "DefaultPreemption",
 							Args: &schedconfig.DefaultPreemptionArgs{MinCandidateNodesPercentage: 10, MinCandidateNodesAbsolute: 100},
@@ -552,6 +614,8 @@ profiles:
     args:
   - name: NetworkOverhead
     args:
+  - name: ShareDevPlugin
+    args:
 `),
 			wantProfiles: []schedconfig.KubeSchedulerProfile{
 				{
@@ -572,6 +636,27 @@ profiles:
 								NetworkTopologyName: "nt-default",
 							},
 						},
+						{
+							Name: sharedev.Name,
+							Args: &config.ShareDevPluginArgs{
+								DeviceManagerPort:                  50051,
+								AllocatorNamespace:                 "default",
+								AllocatorImage:                     "docker.io/zbsss/device-allocator:latest",
+								GetAvailableDevicesTimeoutSeconds:  1,
+								ReservePodQuotaTimeoutSeconds:      10,
+								AllocationTimeoutSeconds:           60,
+								DeviceInventoryResyncPeriodSeconds: 5,
+								ReservationReconcilePeriodSeconds:  300,
+								ReservationLeaseSeconds:            60,
+								DeviceManagerUnreachableSeconds:    60,
+								ScoringStrategy: config.ScoringStrategy{
+									Type:      config.LeastAllocated,
+									Resources: []schedconfig.ResourceSpec{{Name: "compute", Weight: 1}, {Name: "memory", Weight: 1}},
+								},
+								DeviceManagerEndpoint: config.DeviceManagerEndpoint{Type: config.NodeAddressEndpoint},
+								NoFitPolicy:           config.ProvisionNoFit,
+							},
+						},
 						{
 							Name: "DefaultPreemption",
 							Args: &schedconfig.DefaultPreemptionArgs{MinCandidateNodesPercentage: 10, MinCandidateNodesAbsolute: 100},
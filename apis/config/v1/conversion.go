@@ -42,3 +42,25 @@ func Convert_config_NodeResourceTopologyMatchArgs_To_v1_NodeResourceTopologyMatc
 	out.ScoringStrategy = (*ScoringStrategy)(unsafe.Pointer(&in.ScoringStrategy))
 	return nil
 }
+
+func Convert_v1_ShareDevPluginArgs_To_config_ShareDevPluginArgs(in *ShareDevPluginArgs, out *config.ShareDevPluginArgs, s conversion.Scope) error {
+	if err := autoConvert_v1_ShareDevPluginArgs_To_config_ShareDevPluginArgs(in, out, s); err != nil {
+		return err
+	}
+	// Manual conversions.
+	out.ScoringStrategy = *(*config.ScoringStrategy)(unsafe.Pointer(in.ScoringStrategy))
+	out.DeviceManagerEndpoint = *(*config.DeviceManagerEndpoint)(unsafe.Pointer(in.DeviceManagerEndpoint))
+	out.DeviceManagerTLS = *(*config.DeviceManagerTLS)(unsafe.Pointer(in.DeviceManagerTLS))
+	return nil
+}
+
+func Convert_config_ShareDevPluginArgs_To_v1_ShareDevPluginArgs(in *config.ShareDevPluginArgs, out *ShareDevPluginArgs, s conversion.Scope) error {
+	if err := autoConvert_config_ShareDevPluginArgs_To_v1_ShareDevPluginArgs(in, out, s); err != nil {
+		return err
+	}
+	// Manual conversions.
+	out.ScoringStrategy = (*ScoringStrategy)(unsafe.Pointer(&in.ScoringStrategy))
+	out.DeviceManagerEndpoint = (*DeviceManagerEndpoint)(unsafe.Pointer(&in.DeviceManagerEndpoint))
+	out.DeviceManagerTLS = (*DeviceManagerTLS)(unsafe.Pointer(&in.DeviceManagerTLS))
+	return nil
+}
@@ -251,6 +251,82 @@ func TestSchedulingDefaults(t *testing.T) {
 				NetworkTopologyName: pointer.StringPtr("nt-latency-costs"),
 			},
 		},
+		{
+			name:   "empty config ShareDevPluginArgs",
+			config: &ShareDevPluginArgs{},
+			expect: &ShareDevPluginArgs{
+				DeviceManagerPort:                  pointer.Int32Ptr(50051),
+				AllocatorNamespace:                 pointer.StringPtr("default"),
+				AllocatorImage:                     pointer.StringPtr("docker.io/zbsss/device-allocator:latest"),
+				GetAvailableDevicesTimeoutSeconds:  pointer.Int64Ptr(1),
+				ReservePodQuotaTimeoutSeconds:      pointer.Int64Ptr(10),
+				AllocationTimeoutSeconds:           pointer.Int64Ptr(60),
+				DeviceInventoryResyncPeriodSeconds: pointer.Int64Ptr(5),
+				ReservationReconcilePeriodSeconds:  pointer.Int64Ptr(300),
+				ReservationLeaseSeconds:            pointer.Int64Ptr(60),
+				DeviceManagerUnreachableSeconds:    pointer.Int64Ptr(60),
+				ScoringStrategy: &ScoringStrategy{
+					Type: LeastAllocated,
+					Resources: []schedulerconfigv1.ResourceSpec{
+						{Name: "compute", Weight: 1},
+						{Name: "memory", Weight: 1},
+					},
+				},
+				DeviceManagerEndpoint: &DeviceManagerEndpoint{Type: NodeAddressEndpoint},
+				DeviceManagerTLS:      &DeviceManagerTLS{},
+				NoFitPolicy:           ProvisionNoFit,
+			},
+		},
+		{
+			name: "set non default ShareDevPluginArgs",
+			config: &ShareDevPluginArgs{
+				DeviceManagerPort:                  pointer.Int32Ptr(6000),
+				AllocatorNamespace:                 pointer.StringPtr("sharedev"),
+				AllocatorImage:                     pointer.StringPtr("example.com/allocator:v1"),
+				GetAvailableDevicesTimeoutSeconds:  pointer.Int64Ptr(2),
+				ReservePodQuotaTimeoutSeconds:      pointer.Int64Ptr(5),
+				AllocationTimeoutSeconds:           pointer.Int64Ptr(120),
+				DeviceInventoryResyncPeriodSeconds: pointer.Int64Ptr(10),
+				ReservationReconcilePeriodSeconds:  pointer.Int64Ptr(60),
+				ReservationLeaseSeconds:            pointer.Int64Ptr(30),
+				DeviceManagerUnreachableSeconds:    pointer.Int64Ptr(120),
+				ScoringStrategy: &ScoringStrategy{
+					Type: MostAllocated,
+					Resources: []schedulerconfigv1.ResourceSpec{
+						{Name: "compute"},
+						{Name: "memory", Weight: 3},
+					},
+				},
+				DeviceManagerEndpoint: &DeviceManagerEndpoint{Type: NodeAnnotationEndpoint},
+				DeviceManagerTLS:      &DeviceManagerTLS{Insecure: true},
+				NoFitPolicy:           PreemptOrProvisionNoFit,
+			},
+			expect: &ShareDevPluginArgs{
+				DeviceManagerPort:                  pointer.Int32Ptr(6000),
+				AllocatorNamespace:                 pointer.StringPtr("sharedev"),
+				AllocatorImage:                     pointer.StringPtr("example.com/allocator:v1"),
+				GetAvailableDevicesTimeoutSeconds:  pointer.Int64Ptr(2),
+				ReservePodQuotaTimeoutSeconds:      pointer.Int64Ptr(5),
+				AllocationTimeoutSeconds:           pointer.Int64Ptr(120),
+				DeviceInventoryResyncPeriodSeconds: pointer.Int64Ptr(10),
+				ReservationReconcilePeriodSeconds:  pointer.Int64Ptr(60),
+				ReservationLeaseSeconds:            pointer.Int64Ptr(30),
+				DeviceManagerUnreachableSeconds:    pointer.Int64Ptr(120),
+				ScoringStrategy: &ScoringStrategy{
+					Type: MostAllocated,
+					Resources: []schedulerconfigv1.ResourceSpec{
+						{Name: "compute", Weight: 1},
+						{Name: "memory", Weight: 3},
+					},
+				},
+				DeviceManagerEndpoint: &DeviceManagerEndpoint{
+					Type:       NodeAnnotationEndpoint,
+					Annotation: "scheduling.x-k8s.io/device-manager-endpoint",
+				},
+				DeviceManagerTLS: &DeviceManagerTLS{Insecure: true},
+				NoFitPolicy:      PreemptOrProvisionNoFit,
+			},
+		},
 	}
 
 	for _, tc := range tests {
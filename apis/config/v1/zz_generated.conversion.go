@@ -50,6 +50,36 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*DeviceManagerEndpoint)(nil), (*config.DeviceManagerEndpoint)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1_DeviceManagerEndpoint_To_config_DeviceManagerEndpoint(a.(*DeviceManagerEndpoint), b.(*config.DeviceManagerEndpoint), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.DeviceManagerEndpoint)(nil), (*DeviceManagerEndpoint)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_DeviceManagerEndpoint_To_v1_DeviceManagerEndpoint(a.(*config.DeviceManagerEndpoint), b.(*DeviceManagerEndpoint), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*DeviceManagerTLS)(nil), (*config.DeviceManagerTLS)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1_DeviceManagerTLS_To_config_DeviceManagerTLS(a.(*DeviceManagerTLS), b.(*config.DeviceManagerTLS), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.DeviceManagerTLS)(nil), (*DeviceManagerTLS)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_DeviceManagerTLS_To_v1_DeviceManagerTLS(a.(*config.DeviceManagerTLS), b.(*DeviceManagerTLS), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*DeviceModelCost)(nil), (*config.DeviceModelCost)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1_DeviceModelCost_To_config_DeviceModelCost(a.(*DeviceModelCost), b.(*config.DeviceModelCost), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.DeviceModelCost)(nil), (*DeviceModelCost)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_DeviceModelCost_To_v1_DeviceModelCost(a.(*config.DeviceModelCost), b.(*DeviceModelCost), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*LoadVariationRiskBalancingArgs)(nil), (*config.LoadVariationRiskBalancingArgs)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1_LoadVariationRiskBalancingArgs_To_config_LoadVariationRiskBalancingArgs(a.(*LoadVariationRiskBalancingArgs), b.(*config.LoadVariationRiskBalancingArgs), scope)
 	}); err != nil {
@@ -165,11 +195,21 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*config.ShareDevPluginArgs)(nil), (*ShareDevPluginArgs)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_ShareDevPluginArgs_To_v1_ShareDevPluginArgs(a.(*config.ShareDevPluginArgs), b.(*ShareDevPluginArgs), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddConversionFunc((*NodeResourceTopologyMatchArgs)(nil), (*config.NodeResourceTopologyMatchArgs)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1_NodeResourceTopologyMatchArgs_To_config_NodeResourceTopologyMatchArgs(a.(*NodeResourceTopologyMatchArgs), b.(*config.NodeResourceTopologyMatchArgs), scope)
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*ShareDevPluginArgs)(nil), (*config.ShareDevPluginArgs)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1_ShareDevPluginArgs_To_config_ShareDevPluginArgs(a.(*ShareDevPluginArgs), b.(*config.ShareDevPluginArgs), scope)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -197,6 +237,86 @@ func Convert_config_CoschedulingArgs_To_v1_CoschedulingArgs(in *config.Coschedul
 	return autoConvert_config_CoschedulingArgs_To_v1_CoschedulingArgs(in, out, s)
 }
 
+func autoConvert_v1_DeviceManagerEndpoint_To_config_DeviceManagerEndpoint(in *DeviceManagerEndpoint, out *config.DeviceManagerEndpoint, s conversion.Scope) error {
+	out.Type = config.DeviceManagerEndpointType(in.Type)
+	out.Annotation = in.Annotation
+	out.PodNamespace = in.PodNamespace
+	out.PodSelector = in.PodSelector
+	out.Template = in.Template
+	return nil
+}
+
+// Convert_v1_DeviceManagerEndpoint_To_config_DeviceManagerEndpoint is an autogenerated conversion function.
+func Convert_v1_DeviceManagerEndpoint_To_config_DeviceManagerEndpoint(in *DeviceManagerEndpoint, out *config.DeviceManagerEndpoint, s conversion.Scope) error {
+	return autoConvert_v1_DeviceManagerEndpoint_To_config_DeviceManagerEndpoint(in, out, s)
+}
+
+func autoConvert_config_DeviceManagerEndpoint_To_v1_DeviceManagerEndpoint(in *config.DeviceManagerEndpoint, out *DeviceManagerEndpoint, s conversion.Scope) error {
+	out.Type = DeviceManagerEndpointType(in.Type)
+	out.Annotation = in.Annotation
+	out.PodNamespace = in.PodNamespace
+	out.PodSelector = in.PodSelector
+	out.Template = in.Template
+	return nil
+}
+
+// Convert_config_DeviceManagerEndpoint_To_v1_DeviceManagerEndpoint is an autogenerated conversion function.
+func Convert_config_DeviceManagerEndpoint_To_v1_DeviceManagerEndpoint(in *config.DeviceManagerEndpoint, out *DeviceManagerEndpoint, s conversion.Scope) error {
+	return autoConvert_config_DeviceManagerEndpoint_To_v1_DeviceManagerEndpoint(in, out, s)
+}
+
+func autoConvert_v1_DeviceManagerTLS_To_config_DeviceManagerTLS(in *DeviceManagerTLS, out *config.DeviceManagerTLS, s conversion.Scope) error {
+	out.Insecure = in.Insecure
+	out.CAFile = in.CAFile
+	out.CertFile = in.CertFile
+	out.KeyFile = in.KeyFile
+	out.ServerName = in.ServerName
+	return nil
+}
+
+// Convert_v1_DeviceManagerTLS_To_config_DeviceManagerTLS is an autogenerated conversion function.
+func Convert_v1_DeviceManagerTLS_To_config_DeviceManagerTLS(in *DeviceManagerTLS, out *config.DeviceManagerTLS, s conversion.Scope) error {
+	return autoConvert_v1_DeviceManagerTLS_To_config_DeviceManagerTLS(in, out, s)
+}
+
+func autoConvert_config_DeviceManagerTLS_To_v1_DeviceManagerTLS(in *config.DeviceManagerTLS, out *DeviceManagerTLS, s conversion.Scope) error {
+	out.Insecure = in.Insecure
+	out.CAFile = in.CAFile
+	out.CertFile = in.CertFile
+	out.KeyFile = in.KeyFile
+	out.ServerName = in.ServerName
+	return nil
+}
+
+// Convert_config_DeviceManagerTLS_To_v1_DeviceManagerTLS is an autogenerated conversion function.
+func Convert_config_DeviceManagerTLS_To_v1_DeviceManagerTLS(in *config.DeviceManagerTLS, out *DeviceManagerTLS, s conversion.Scope) error {
+	return autoConvert_config_DeviceManagerTLS_To_v1_DeviceManagerTLS(in, out, s)
+}
+
+func autoConvert_v1_DeviceModelCost_To_config_DeviceModelCost(in *DeviceModelCost, out *config.DeviceModelCost, s conversion.Scope) error {
+	out.Vendor = in.Vendor
+	out.Model = in.Model
+	out.Cost = in.Cost
+	return nil
+}
+
+// Convert_v1_DeviceModelCost_To_config_DeviceModelCost is an autogenerated conversion function.
+func Convert_v1_DeviceModelCost_To_config_DeviceModelCost(in *DeviceModelCost, out *config.DeviceModelCost, s conversion.Scope) error {
+	return autoConvert_v1_DeviceModelCost_To_config_DeviceModelCost(in, out, s)
+}
+
+func autoConvert_config_DeviceModelCost_To_v1_DeviceModelCost(in *config.DeviceModelCost, out *DeviceModelCost, s conversion.Scope) error {
+	out.Vendor = in.Vendor
+	out.Model = in.Model
+	out.Cost = in.Cost
+	return nil
+}
+
+// Convert_config_DeviceModelCost_To_v1_DeviceModelCost is an autogenerated conversion function.
+func Convert_config_DeviceModelCost_To_v1_DeviceModelCost(in *config.DeviceModelCost, out *DeviceModelCost, s conversion.Scope) error {
+	return autoConvert_config_DeviceModelCost_To_v1_DeviceModelCost(in, out, s)
+}
+
 func autoConvert_v1_LoadVariationRiskBalancingArgs_To_config_LoadVariationRiskBalancingArgs(in *LoadVariationRiskBalancingArgs, out *config.LoadVariationRiskBalancingArgs, s conversion.Scope) error {
 	if err := Convert_v1_TrimaranSpec_To_config_TrimaranSpec(&in.TrimaranSpec, &out.TrimaranSpec, s); err != nil {
 		return err
@@ -451,6 +571,84 @@ func Convert_config_ScoringStrategy_To_v1_ScoringStrategy(in *config.ScoringStra
 	return autoConvert_config_ScoringStrategy_To_v1_ScoringStrategy(in, out, s)
 }
 
+func autoConvert_v1_ShareDevPluginArgs_To_config_ShareDevPluginArgs(in *ShareDevPluginArgs, out *config.ShareDevPluginArgs, s conversion.Scope) error {
+	if err := metav1.Convert_Pointer_int32_To_int32(&in.DeviceManagerPort, &out.DeviceManagerPort, s); err != nil {
+		return err
+	}
+	if err := metav1.Convert_Pointer_string_To_string(&in.AllocatorNamespace, &out.AllocatorNamespace, s); err != nil {
+		return err
+	}
+	if err := metav1.Convert_Pointer_string_To_string(&in.AllocatorImage, &out.AllocatorImage, s); err != nil {
+		return err
+	}
+	if err := metav1.Convert_Pointer_int64_To_int64(&in.GetAvailableDevicesTimeoutSeconds, &out.GetAvailableDevicesTimeoutSeconds, s); err != nil {
+		return err
+	}
+	if err := metav1.Convert_Pointer_int64_To_int64(&in.ReservePodQuotaTimeoutSeconds, &out.ReservePodQuotaTimeoutSeconds, s); err != nil {
+		return err
+	}
+	if err := metav1.Convert_Pointer_int64_To_int64(&in.AllocationTimeoutSeconds, &out.AllocationTimeoutSeconds, s); err != nil {
+		return err
+	}
+	if err := metav1.Convert_Pointer_int64_To_int64(&in.DeviceInventoryResyncPeriodSeconds, &out.DeviceInventoryResyncPeriodSeconds, s); err != nil {
+		return err
+	}
+	if err := metav1.Convert_Pointer_int64_To_int64(&in.ReservationReconcilePeriodSeconds, &out.ReservationReconcilePeriodSeconds, s); err != nil {
+		return err
+	}
+	if err := metav1.Convert_Pointer_int64_To_int64(&in.ReservationLeaseSeconds, &out.ReservationLeaseSeconds, s); err != nil {
+		return err
+	}
+	if err := metav1.Convert_Pointer_int64_To_int64(&in.DeviceManagerUnreachableSeconds, &out.DeviceManagerUnreachableSeconds, s); err != nil {
+		return err
+	}
+	// WARNING: in.ScoringStrategy requires manual conversion: inconvertible types (*sigs.k8s.io/scheduler-plugins/apis/config/v1.ScoringStrategy vs sigs.k8s.io/scheduler-plugins/apis/config.ScoringStrategy)
+	// WARNING: in.DeviceManagerEndpoint requires manual conversion: inconvertible types (*sigs.k8s.io/scheduler-plugins/apis/config/v1.DeviceManagerEndpoint vs sigs.k8s.io/scheduler-plugins/apis/config.DeviceManagerEndpoint)
+	// WARNING: in.DeviceManagerTLS requires manual conversion: inconvertible types (*sigs.k8s.io/scheduler-plugins/apis/config/v1.DeviceManagerTLS vs sigs.k8s.io/scheduler-plugins/apis/config.DeviceManagerTLS)
+	out.NoFitPolicy = config.NoFitPolicy(in.NoFitPolicy)
+	out.DeviceModelCosts = *(*[]config.DeviceModelCost)(unsafe.Pointer(&in.DeviceModelCosts))
+	return nil
+}
+
+func autoConvert_config_ShareDevPluginArgs_To_v1_ShareDevPluginArgs(in *config.ShareDevPluginArgs, out *ShareDevPluginArgs, s conversion.Scope) error {
+	if err := metav1.Convert_int32_To_Pointer_int32(&in.DeviceManagerPort, &out.DeviceManagerPort, s); err != nil {
+		return err
+	}
+	if err := metav1.Convert_string_To_Pointer_string(&in.AllocatorNamespace, &out.AllocatorNamespace, s); err != nil {
+		return err
+	}
+	if err := metav1.Convert_string_To_Pointer_string(&in.AllocatorImage, &out.AllocatorImage, s); err != nil {
+		return err
+	}
+	if err := metav1.Convert_int64_To_Pointer_int64(&in.GetAvailableDevicesTimeoutSeconds, &out.GetAvailableDevicesTimeoutSeconds, s); err != nil {
+		return err
+	}
+	if err := metav1.Convert_int64_To_Pointer_int64(&in.ReservePodQuotaTimeoutSeconds, &out.ReservePodQuotaTimeoutSeconds, s); err != nil {
+		return err
+	}
+	if err := metav1.Convert_int64_To_Pointer_int64(&in.AllocationTimeoutSeconds, &out.AllocationTimeoutSeconds, s); err != nil {
+		return err
+	}
+	if err := metav1.Convert_int64_To_Pointer_int64(&in.DeviceInventoryResyncPeriodSeconds, &out.DeviceInventoryResyncPeriodSeconds, s); err != nil {
+		return err
+	}
+	if err := metav1.Convert_int64_To_Pointer_int64(&in.ReservationReconcilePeriodSeconds, &out.ReservationReconcilePeriodSeconds, s); err != nil {
+		return err
+	}
+	if err := metav1.Convert_int64_To_Pointer_int64(&in.ReservationLeaseSeconds, &out.ReservationLeaseSeconds, s); err != nil {
+		return err
+	}
+	if err := metav1.Convert_int64_To_Pointer_int64(&in.DeviceManagerUnreachableSeconds, &out.DeviceManagerUnreachableSeconds, s); err != nil {
+		return err
+	}
+	// WARNING: in.ScoringStrategy requires manual conversion: inconvertible types (sigs.k8s.io/scheduler-plugins/apis/config.ScoringStrategy vs *sigs.k8s.io/scheduler-plugins/apis/config/v1.ScoringStrategy)
+	// WARNING: in.DeviceManagerEndpoint requires manual conversion: inconvertible types (sigs.k8s.io/scheduler-plugins/apis/config.DeviceManagerEndpoint vs *sigs.k8s.io/scheduler-plugins/apis/config/v1.DeviceManagerEndpoint)
+	// WARNING: in.DeviceManagerTLS requires manual conversion: inconvertible types (sigs.k8s.io/scheduler-plugins/apis/config.DeviceManagerTLS vs *sigs.k8s.io/scheduler-plugins/apis/config/v1.DeviceManagerTLS)
+	out.NoFitPolicy = NoFitPolicy(in.NoFitPolicy)
+	out.DeviceModelCosts = *(*[]DeviceModelCost)(unsafe.Pointer(&in.DeviceModelCosts))
+	return nil
+}
+
 func autoConvert_v1_TargetLoadPackingArgs_To_config_TargetLoadPackingArgs(in *TargetLoadPackingArgs, out *config.TargetLoadPackingArgs, s conversion.Scope) error {
 	if err := Convert_v1_TrimaranSpec_To_config_TrimaranSpec(&in.TrimaranSpec, &out.TrimaranSpec, s); err != nil {
 		return err
@@ -62,6 +62,54 @@ func (in *CoschedulingArgs) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeviceManagerEndpoint) DeepCopyInto(out *DeviceManagerEndpoint) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeviceManagerEndpoint.
+func (in *DeviceManagerEndpoint) DeepCopy() *DeviceManagerEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(DeviceManagerEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeviceManagerTLS) DeepCopyInto(out *DeviceManagerTLS) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeviceManagerTLS.
+func (in *DeviceManagerTLS) DeepCopy() *DeviceManagerTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(DeviceManagerTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeviceModelCost) DeepCopyInto(out *DeviceModelCost) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeviceModelCost.
+func (in *DeviceModelCost) DeepCopy() *DeviceModelCost {
+	if in == nil {
+		return nil
+	}
+	out := new(DeviceModelCost)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LoadVariationRiskBalancingArgs) DeepCopyInto(out *LoadVariationRiskBalancingArgs) {
 	*out = *in
@@ -286,6 +334,101 @@ func (in *ScoringStrategy) DeepCopy() *ScoringStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShareDevPluginArgs) DeepCopyInto(out *ShareDevPluginArgs) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	if in.DeviceManagerPort != nil {
+		in, out := &in.DeviceManagerPort, &out.DeviceManagerPort
+		*out = new(int32)
+		**out = **in
+	}
+	if in.AllocatorNamespace != nil {
+		in, out := &in.AllocatorNamespace, &out.AllocatorNamespace
+		*out = new(string)
+		**out = **in
+	}
+	if in.AllocatorImage != nil {
+		in, out := &in.AllocatorImage, &out.AllocatorImage
+		*out = new(string)
+		**out = **in
+	}
+	if in.GetAvailableDevicesTimeoutSeconds != nil {
+		in, out := &in.GetAvailableDevicesTimeoutSeconds, &out.GetAvailableDevicesTimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ReservePodQuotaTimeoutSeconds != nil {
+		in, out := &in.ReservePodQuotaTimeoutSeconds, &out.ReservePodQuotaTimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.AllocationTimeoutSeconds != nil {
+		in, out := &in.AllocationTimeoutSeconds, &out.AllocationTimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.DeviceInventoryResyncPeriodSeconds != nil {
+		in, out := &in.DeviceInventoryResyncPeriodSeconds, &out.DeviceInventoryResyncPeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ReservationReconcilePeriodSeconds != nil {
+		in, out := &in.ReservationReconcilePeriodSeconds, &out.ReservationReconcilePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ReservationLeaseSeconds != nil {
+		in, out := &in.ReservationLeaseSeconds, &out.ReservationLeaseSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.DeviceManagerUnreachableSeconds != nil {
+		in, out := &in.DeviceManagerUnreachableSeconds, &out.DeviceManagerUnreachableSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ScoringStrategy != nil {
+		in, out := &in.ScoringStrategy, &out.ScoringStrategy
+		*out = new(ScoringStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DeviceManagerEndpoint != nil {
+		in, out := &in.DeviceManagerEndpoint, &out.DeviceManagerEndpoint
+		*out = new(DeviceManagerEndpoint)
+		**out = **in
+	}
+	if in.DeviceManagerTLS != nil {
+		in, out := &in.DeviceManagerTLS, &out.DeviceManagerTLS
+		*out = new(DeviceManagerTLS)
+		**out = **in
+	}
+	if in.DeviceModelCosts != nil {
+		in, out := &in.DeviceModelCosts, &out.DeviceModelCosts
+		*out = make([]DeviceModelCost, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShareDevPluginArgs.
+func (in *ShareDevPluginArgs) DeepCopy() *ShareDevPluginArgs {
+	if in == nil {
+		return nil
+	}
+	out := new(ShareDevPluginArgs)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ShareDevPluginArgs) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TargetLoadPackingArgs) DeepCopyInto(out *TargetLoadPackingArgs) {
 	*out = *in
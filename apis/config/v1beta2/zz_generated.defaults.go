@@ -40,6 +40,7 @@ func RegisterDefaults(scheme *runtime.Scheme) error {
 		SetObjectDefaults_NodeResourcesAllocatableArgs(obj.(*NodeResourcesAllocatableArgs))
 	})
 	scheme.AddTypeDefaultingFunc(&PreemptionTolerationArgs{}, func(obj interface{}) { SetObjectDefaults_PreemptionTolerationArgs(obj.(*PreemptionTolerationArgs)) })
+	scheme.AddTypeDefaultingFunc(&ShareDevPluginArgs{}, func(obj interface{}) { SetObjectDefaults_ShareDevPluginArgs(obj.(*ShareDevPluginArgs)) })
 	scheme.AddTypeDefaultingFunc(&TargetLoadPackingArgs{}, func(obj interface{}) { SetObjectDefaults_TargetLoadPackingArgs(obj.(*TargetLoadPackingArgs)) })
 	return nil
 }
@@ -64,6 +65,10 @@ func SetObjectDefaults_PreemptionTolerationArgs(in *PreemptionTolerationArgs) {
 	SetDefaults_PreemptionTolerationArgs(in)
 }
 
+func SetObjectDefaults_ShareDevPluginArgs(in *ShareDevPluginArgs) {
+	SetDefaults_ShareDevPluginArgs(in)
+}
+
 func SetObjectDefaults_TargetLoadPackingArgs(in *TargetLoadPackingArgs) {
 	SetDefaults_TargetLoadPackingArgs(in)
 }
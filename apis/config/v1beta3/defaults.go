@@ -94,6 +94,35 @@ var (
 	DefaultWeightsName = "UserDefined"
 	// DefaultNetworkTopologyName contains the networkTopology CR name to be used by networkAware plugins
 	DefaultNetworkTopologyName = "nt-default"
+
+	// Defaults for ShareDevPlugin
+	// DefaultDeviceManagerPort is the port the device manager listens on on every node
+	DefaultDeviceManagerPort int32 = 50051
+	// DefaultAllocatorNamespace is the namespace allocator Deployments are created in
+	DefaultAllocatorNamespace = metav1.NamespaceDefault
+	// DefaultAllocatorImage is the container image used by allocator pods
+	DefaultAllocatorImage = "docker.io/zbsss/device-allocator:latest"
+	// DefaultGetAvailableDevicesTimeoutSeconds is the timeout of the GetAvailableDevices RPC
+	DefaultGetAvailableDevicesTimeoutSeconds int64 = 1
+	// DefaultReservePodQuotaTimeoutSeconds is the timeout of the ReservePodQuota RPC
+	DefaultReservePodQuotaTimeoutSeconds int64 = 10
+	// DefaultAllocationTimeoutSeconds is how long to wait for a new allocator pod to be running
+	DefaultAllocationTimeoutSeconds int64 = 60
+	// DefaultDeviceInventoryResyncPeriodSeconds is how often the free device resources of every node are refreshed
+	DefaultDeviceInventoryResyncPeriodSeconds int64 = 5
+	// DefaultReservationReconcilePeriodSeconds is how often the reservations on the device managers are reconciled
+	DefaultReservationReconcilePeriodSeconds int64 = 300
+	// DefaultReservationLeaseSeconds is how long the quota reserved for a pod is held without being renewed
+	DefaultReservationLeaseSeconds int64 = 60
+	// DefaultDeviceManagerUnreachableSeconds is how long the device manager of a node may fail before the node is left out
+	DefaultDeviceManagerUnreachableSeconds int64 = 60
+	// DefaultDeviceManagerEndpointAnnotation is the node annotation holding the device manager endpoint
+	DefaultDeviceManagerEndpointAnnotation = "scheduling.x-k8s.io/device-manager-endpoint"
+
+	defaultShareDevResourceSpec = []schedulerconfigv1beta3.ResourceSpec{
+		{Name: "compute", Weight: 1},
+		{Name: "memory", Weight: 1},
+	}
 )
 
 // SetDefaults_CoschedulingArgs sets the default parameters for Coscheduling plugin.
@@ -225,3 +254,84 @@ func SetDefaults_NetworkOverheadArgs(obj *NetworkOverheadArgs) {
 		obj.NetworkTopologyName = &DefaultNetworkTopologyName
 	}
 }
+
+// SetDefaults_ShareDevPluginArgs sets the default parameters for ShareDevPlugin plugin.
+func SetDefaults_ShareDevPluginArgs(obj *ShareDevPluginArgs) {
+	if obj.DeviceManagerPort == nil {
+		obj.DeviceManagerPort = &DefaultDeviceManagerPort
+	}
+
+	if obj.AllocatorNamespace == nil {
+		obj.AllocatorNamespace = &DefaultAllocatorNamespace
+	}
+
+	if obj.AllocatorImage == nil {
+		obj.AllocatorImage = &DefaultAllocatorImage
+	}
+
+	if obj.GetAvailableDevicesTimeoutSeconds == nil {
+		obj.GetAvailableDevicesTimeoutSeconds = &DefaultGetAvailableDevicesTimeoutSeconds
+	}
+
+	if obj.ReservePodQuotaTimeoutSeconds == nil {
+		obj.ReservePodQuotaTimeoutSeconds = &DefaultReservePodQuotaTimeoutSeconds
+	}
+
+	if obj.AllocationTimeoutSeconds == nil {
+		obj.AllocationTimeoutSeconds = &DefaultAllocationTimeoutSeconds
+	}
+
+	if obj.DeviceInventoryResyncPeriodSeconds == nil {
+		obj.DeviceInventoryResyncPeriodSeconds = &DefaultDeviceInventoryResyncPeriodSeconds
+	}
+
+	if obj.ReservationReconcilePeriodSeconds == nil {
+		obj.ReservationReconcilePeriodSeconds = &DefaultReservationReconcilePeriodSeconds
+	}
+
+	if obj.ReservationLeaseSeconds == nil {
+		obj.ReservationLeaseSeconds = &DefaultReservationLeaseSeconds
+	}
+
+	if obj.DeviceManagerUnreachableSeconds == nil {
+		obj.DeviceManagerUnreachableSeconds = &DefaultDeviceManagerUnreachableSeconds
+	}
+
+	if obj.ScoringStrategy == nil {
+		obj.ScoringStrategy = &ScoringStrategy{
+			Type:      LeastAllocated,
+			Resources: defaultShareDevResourceSpec,
+		}
+	}
+
+	if len(obj.ScoringStrategy.Resources) == 0 {
+		// If no resources specified, use the default set.
+		obj.ScoringStrategy.Resources = append(obj.ScoringStrategy.Resources, defaultShareDevResourceSpec...)
+	}
+
+	for i := range obj.ScoringStrategy.Resources {
+		if obj.ScoringStrategy.Resources[i].Weight == 0 {
+			obj.ScoringStrategy.Resources[i].Weight = 1
+		}
+	}
+
+	if obj.DeviceManagerEndpoint == nil {
+		obj.DeviceManagerEndpoint = &DeviceManagerEndpoint{}
+	}
+
+	if obj.DeviceManagerEndpoint.Type == "" {
+		obj.DeviceManagerEndpoint.Type = NodeAddressEndpoint
+	}
+
+	if obj.DeviceManagerEndpoint.Type == NodeAnnotationEndpoint && obj.DeviceManagerEndpoint.Annotation == "" {
+		obj.DeviceManagerEndpoint.Annotation = DefaultDeviceManagerEndpointAnnotation
+	}
+
+	if obj.DeviceManagerTLS == nil {
+		obj.DeviceManagerTLS = &DeviceManagerTLS{}
+	}
+
+	if obj.NoFitPolicy == "" {
+		obj.NoFitPolicy = ProvisionNoFit
+	}
+}
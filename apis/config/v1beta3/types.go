@@ -239,3 +239,120 @@ type NetworkOverheadArgs struct {
 	// The NetworkTopology CRD name
 	NetworkTopologyName *string `json:"networkTopologyName,omitempty"`
 }
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ShareDevPluginArgs holds arguments used to configure the ShareDevPlugin plugin.
+type ShareDevPluginArgs struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// DeviceManagerPort is the port the device manager listens on on every node.
+	DeviceManagerPort *int32 `json:"deviceManagerPort,omitempty"`
+	// AllocatorNamespace is the namespace allocator Deployments are created in.
+	AllocatorNamespace *string `json:"allocatorNamespace,omitempty"`
+	// AllocatorImage is the container image used by allocator pods.
+	AllocatorImage *string `json:"allocatorImage,omitempty"`
+	// GetAvailableDevicesTimeoutSeconds is the timeout of the GetAvailableDevices RPC.
+	GetAvailableDevicesTimeoutSeconds *int64 `json:"getAvailableDevicesTimeoutSeconds,omitempty"`
+	// ReservePodQuotaTimeoutSeconds is the timeout of the ReservePodQuota RPC.
+	ReservePodQuotaTimeoutSeconds *int64 `json:"reservePodQuotaTimeoutSeconds,omitempty"`
+	// AllocationTimeoutSeconds is how long to wait for a new allocator pod to be running.
+	AllocationTimeoutSeconds *int64 `json:"allocationTimeoutSeconds,omitempty"`
+	// DeviceInventoryResyncPeriodSeconds is how often the free device resources of every node are refreshed.
+	DeviceInventoryResyncPeriodSeconds *int64 `json:"deviceInventoryResyncPeriodSeconds,omitempty"`
+	// ReservationReconcilePeriodSeconds is how often the reservations on the device managers are
+	// compared with the pods using them, and the ones left by pods that are gone released.
+	ReservationReconcilePeriodSeconds *int64 `json:"reservationReconcilePeriodSeconds,omitempty"`
+	// ReservationLeaseSeconds is how long the quota reserved for a pod is held without being renewed.
+	// It is renewed while the pod is bound, so it is only released when the scheduler crashes before
+	// the pod is bound or the pod is gone. 0 reserves the quota until it is released.
+	ReservationLeaseSeconds *int64 `json:"reservationLeaseSeconds,omitempty"`
+	// DeviceManagerUnreachableSeconds is how long the device manager of a node may fail before
+	// the node is left out of scheduling instead of failing the scheduling cycle. The device
+	// manager is asked again once per device inventory resync period until it answers.
+	DeviceManagerUnreachableSeconds *int64 `json:"deviceManagerUnreachableSeconds,omitempty"`
+	// ScoringStrategy selects how nodes are scored and which device a pod is put on.
+	// Resources may be "compute" and "memory".
+	ScoringStrategy *ScoringStrategy `json:"scoringStrategy,omitempty"`
+	// DeviceManagerEndpoint selects how the address of the device manager on a node is found.
+	DeviceManagerEndpoint *DeviceManagerEndpoint `json:"deviceManagerEndpoint,omitempty"`
+	// DeviceManagerTLS configures TLS, or plaintext, for the device manager connections.
+	DeviceManagerTLS *DeviceManagerTLS `json:"deviceManagerTLS,omitempty"`
+	// NoFitPolicy is what PostFilter does when no node has devices with room for the pod:
+	// Provision creates new devices, Preempt evicts lower-priority clients of the devices
+	// and PreemptOrProvision creates new devices only when preempting can't make room.
+	NoFitPolicy NoFitPolicy `json:"noFitPolicy,omitempty"`
+	// DeviceModelCosts are the costs of a whole device of some models. PostFilter provisions
+	// devices of the cheapest model a pod accepts, for the share of a device the pod takes on it.
+	// Models without a cost come last, in the order the pod prefers them.
+	DeviceModelCosts []DeviceModelCost `json:"deviceModelCosts,omitempty"`
+}
+
+// NoFitPolicy is what ShareDevPlugin does for a pod no device has room for.
+type NoFitPolicy string
+
+const (
+	// ProvisionNoFit creates new devices for the pod.
+	ProvisionNoFit NoFitPolicy = "Provision"
+	// PreemptNoFit evicts lower-priority clients of the devices of a node.
+	PreemptNoFit NoFitPolicy = "Preempt"
+	// PreemptOrProvisionNoFit preempts, and creates new devices when
+	// preempting can't make room.
+	PreemptOrProvisionNoFit NoFitPolicy = "PreemptOrProvision"
+)
+
+// DeviceModelCost is the cost of a whole device of a model.
+type DeviceModelCost struct {
+	Vendor string `json:"vendor"`
+	Model  string `json:"model"`
+	Cost   int64  `json:"cost"`
+}
+
+// DeviceManagerEndpointType is how the device manager of a node is found.
+type DeviceManagerEndpointType string
+
+const (
+	// NodeAddressEndpoint is the node's InternalIP, or its ExternalIP if it has
+	// none, and DeviceManagerPort.
+	NodeAddressEndpoint DeviceManagerEndpointType = "NodeAddress"
+	// NodeAnnotationEndpoint is the "host" or "host:port" in a node annotation.
+	NodeAnnotationEndpoint DeviceManagerEndpointType = "NodeAnnotation"
+	// PodEndpoint is the IP of the device manager pod running on the node,
+	// e.g. of a DaemonSet without host network, and DeviceManagerPort.
+	PodEndpoint DeviceManagerEndpointType = "Pod"
+	// TemplateEndpoint is built from the node, e.g. a DNS name or a Service.
+	TemplateEndpoint DeviceManagerEndpointType = "Template"
+)
+
+// DeviceManagerEndpoint selects how the device manager of a node is found.
+type DeviceManagerEndpoint struct {
+	// Type is NodeAddress, NodeAnnotation, Pod or Template.
+	Type DeviceManagerEndpointType `json:"type,omitempty"`
+	// Annotation is the node annotation holding the endpoint, for NodeAnnotation.
+	Annotation string `json:"annotation,omitempty"`
+	// PodNamespace is the namespace of the device manager pods, for Pod.
+	PodNamespace string `json:"podNamespace,omitempty"`
+	// PodSelector is the label selector of the device manager pods, for Pod.
+	PodSelector string `json:"podSelector,omitempty"`
+	// Template is a Go template of the endpoint, for Template, e.g.
+	// "{{.NodeName}}.device-manager.kube-system.svc". It is given the node's
+	// NodeName and InternalIP. DeviceManagerPort is used if it has no port.
+	Template string `json:"template,omitempty"`
+}
+
+// DeviceManagerTLS configures the transport security of the device manager
+// connections. The files are read again when they change, e.g. on rotation.
+type DeviceManagerTLS struct {
+	// Insecure disables TLS. It must be set explicitly to use plaintext connections.
+	Insecure bool `json:"insecure,omitempty"`
+	// CAFile is the PEM bundle of the CAs the device manager certificates are
+	// verified against. The system roots are used if it is empty.
+	CAFile string `json:"caFile,omitempty"`
+	// CertFile and KeyFile are the PEM client certificate and key presented to
+	// device managers that require mutual TLS.
+	CertFile string `json:"certFile,omitempty"`
+	KeyFile  string `json:"keyFile,omitempty"`
+	// ServerName is the name the device manager certificates are verified
+	// against. The host of the endpoint is used if it is empty.
+	ServerName string `json:"serverName,omitempty"`
+}
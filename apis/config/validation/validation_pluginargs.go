@@ -17,7 +17,11 @@ limitations under the License.
 package validation
 
 import (
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	"sigs.k8s.io/scheduler-plugins/apis/config"
@@ -30,6 +34,27 @@ var validScoringStrategy = sets.NewString(
 	string(config.LeastNUMANodes),
 )
 
+var validShareDevScoringStrategy = sets.NewString(
+	string(config.MostAllocated),
+	string(config.BalancedAllocation),
+	string(config.LeastAllocated),
+)
+
+var validShareDevScoringResources = sets.NewString("compute", "memory")
+
+var validNoFitPolicies = sets.NewString(
+	string(config.ProvisionNoFit),
+	string(config.PreemptNoFit),
+	string(config.PreemptOrProvisionNoFit),
+)
+
+var validDeviceManagerEndpointTypes = sets.NewString(
+	string(config.NodeAddressEndpoint),
+	string(config.NodeAnnotationEndpoint),
+	string(config.PodEndpoint),
+	string(config.TemplateEndpoint),
+)
+
 func ValidateNodeResourceTopologyMatchArgs(path *field.Path, args *config.NodeResourceTopologyMatchArgs) error {
 	var allErrs field.ErrorList
 	scoringStrategyTypePath := path.Child("scoringStrategy.type")
@@ -46,3 +71,135 @@ func validateScoringStrategyType(scoringStrategy config.ScoringStrategyType, pat
 	}
 	return nil
 }
+
+// ValidateShareDevPluginArgs validates that ShareDevPluginArgs are correct.
+func ValidateShareDevPluginArgs(path *field.Path, args *config.ShareDevPluginArgs) error {
+	var allErrs field.ErrorList
+	if args.DeviceManagerPort < 1 || args.DeviceManagerPort > 65535 {
+		allErrs = append(allErrs, field.Invalid(path.Child("deviceManagerPort"), args.DeviceManagerPort, "must be a valid port number"))
+	}
+	if args.AllocatorNamespace == "" {
+		allErrs = append(allErrs, field.Required(path.Child("allocatorNamespace"), "allocator namespace must not be empty"))
+	}
+	if args.AllocatorImage == "" {
+		allErrs = append(allErrs, field.Required(path.Child("allocatorImage"), "allocator image must not be empty"))
+	}
+	if args.GetAvailableDevicesTimeoutSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("getAvailableDevicesTimeoutSeconds"), args.GetAvailableDevicesTimeoutSeconds, "must be greater than 0"))
+	}
+	if args.ReservePodQuotaTimeoutSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("reservePodQuotaTimeoutSeconds"), args.ReservePodQuotaTimeoutSeconds, "must be greater than 0"))
+	}
+	if args.AllocationTimeoutSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("allocationTimeoutSeconds"), args.AllocationTimeoutSeconds, "must be greater than 0"))
+	}
+	if args.DeviceInventoryResyncPeriodSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("deviceInventoryResyncPeriodSeconds"), args.DeviceInventoryResyncPeriodSeconds, "must be greater than 0"))
+	}
+	if args.ReservationReconcilePeriodSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("reservationReconcilePeriodSeconds"), args.ReservationReconcilePeriodSeconds, "must be greater than 0"))
+	}
+	if args.ReservationLeaseSeconds < 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("reservationLeaseSeconds"), args.ReservationLeaseSeconds, "must be greater than or equal to 0"))
+	}
+	if args.DeviceManagerUnreachableSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("deviceManagerUnreachableSeconds"), args.DeviceManagerUnreachableSeconds, "must be greater than 0"))
+	}
+	if !validShareDevScoringStrategy.Has(string(args.ScoringStrategy.Type)) {
+		allErrs = append(allErrs, field.Invalid(path.Child("scoringStrategy.type"), args.ScoringStrategy.Type, "invalid ScoringStrategyType"))
+	}
+	for i, resource := range args.ScoringStrategy.Resources {
+		resourcePath := path.Child("scoringStrategy.resources").Index(i)
+		if !validShareDevScoringResources.Has(resource.Name) {
+			allErrs = append(allErrs, field.NotSupported(resourcePath.Child("name"), resource.Name, validShareDevScoringResources.List()))
+		}
+		if resource.Weight <= 0 {
+			allErrs = append(allErrs, field.Invalid(resourcePath.Child("weight"), resource.Weight, "must be greater than 0"))
+		}
+	}
+	allErrs = append(allErrs, validateDeviceManagerEndpoint(path.Child("deviceManagerEndpoint"), args.DeviceManagerEndpoint)...)
+	allErrs = append(allErrs, validateDeviceManagerTLS(path.Child("deviceManagerTLS"), args.DeviceManagerTLS)...)
+	if !validNoFitPolicies.Has(string(args.NoFitPolicy)) {
+		allErrs = append(allErrs, field.NotSupported(path.Child("noFitPolicy"), args.NoFitPolicy, validNoFitPolicies.List()))
+	}
+	allErrs = append(allErrs, validateDeviceModelCosts(path.Child("deviceModelCosts"), args.DeviceModelCosts)...)
+
+	return allErrs.ToAggregate()
+}
+
+// validateDeviceModelCosts checks every model has a single, non-negative cost.
+func validateDeviceModelCosts(path *field.Path, costs []config.DeviceModelCost) field.ErrorList {
+	var allErrs field.ErrorList
+	seen := sets.NewString()
+	for i, c := range costs {
+		costPath := path.Index(i)
+		if c.Vendor == "" {
+			allErrs = append(allErrs, field.Required(costPath.Child("vendor"), "vendor must not be empty"))
+		}
+		if c.Model == "" {
+			allErrs = append(allErrs, field.Required(costPath.Child("model"), "model must not be empty"))
+		}
+		if c.Cost < 0 {
+			allErrs = append(allErrs, field.Invalid(costPath.Child("cost"), c.Cost, "must be greater than or equal to 0"))
+		}
+		if key := c.Vendor + "/" + c.Model; seen.Has(key) {
+			allErrs = append(allErrs, field.Duplicate(costPath, key))
+		} else {
+			seen.Insert(key)
+		}
+	}
+	return allErrs
+}
+
+// validateDeviceManagerEndpoint checks the endpoint has what its type needs.
+func validateDeviceManagerEndpoint(path *field.Path, endpoint config.DeviceManagerEndpoint) field.ErrorList {
+	var allErrs field.ErrorList
+	switch endpoint.Type {
+	case config.NodeAddressEndpoint:
+	case config.NodeAnnotationEndpoint:
+		for _, msg := range validation.IsQualifiedName(endpoint.Annotation) {
+			allErrs = append(allErrs, field.Invalid(path.Child("annotation"), endpoint.Annotation, msg))
+		}
+	case config.PodEndpoint:
+		if endpoint.PodNamespace == "" {
+			allErrs = append(allErrs, field.Required(path.Child("podNamespace"), "device manager pod namespace must not be empty"))
+		}
+		if endpoint.PodSelector == "" {
+			allErrs = append(allErrs, field.Required(path.Child("podSelector"), "device manager pod selector must not be empty"))
+		} else if _, err := labels.Parse(endpoint.PodSelector); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("podSelector"), endpoint.PodSelector, err.Error()))
+		}
+	case config.TemplateEndpoint:
+		if endpoint.Template == "" {
+			allErrs = append(allErrs, field.Required(path.Child("template"), "endpoint template must not be empty"))
+		} else if _, err := template.New("endpoint").Parse(endpoint.Template); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("template"), endpoint.Template, err.Error()))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(path.Child("type"), endpoint.Type, validDeviceManagerEndpointTypes.List()))
+	}
+	return allErrs
+}
+
+// validateDeviceManagerTLS checks TLS settings aren't given in insecure mode
+// and that the client certificate comes with its key.
+func validateDeviceManagerTLS(path *field.Path, tls config.DeviceManagerTLS) field.ErrorList {
+	var allErrs field.ErrorList
+	if tls.Insecure {
+		for _, f := range []struct{ name, value string }{
+			{"caFile", tls.CAFile}, {"certFile", tls.CertFile}, {"keyFile", tls.KeyFile}, {"serverName", tls.ServerName},
+		} {
+			if f.value != "" {
+				allErrs = append(allErrs, field.Invalid(path.Child(f.name), f.value, "must be empty when insecure is set"))
+			}
+		}
+		return allErrs
+	}
+	if tls.CertFile != "" && tls.KeyFile == "" {
+		allErrs = append(allErrs, field.Required(path.Child("keyFile"), "client key must be set with the client certificate"))
+	}
+	if tls.KeyFile != "" && tls.CertFile == "" {
+		allErrs = append(allErrs, field.Required(path.Child("certFile"), "client certificate must be set with the client key"))
+	}
+	return allErrs
+}
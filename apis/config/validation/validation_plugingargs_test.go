@@ -21,6 +21,8 @@ import (
 	"strings"
 	"testing"
 
+	schedconfig "k8s.io/kubernetes/pkg/scheduler/apis/config"
+
 	"sigs.k8s.io/scheduler-plugins/apis/config"
 )
 
@@ -67,3 +69,310 @@ func TestValidateNodeResourceTopologyMatchArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateShareDevPluginArgs(t *testing.T) {
+	validArgs := func() *config.ShareDevPluginArgs {
+		return &config.ShareDevPluginArgs{
+			DeviceManagerPort:                  50051,
+			AllocatorNamespace:                 "default",
+			AllocatorImage:                     "docker.io/zbsss/device-allocator:latest",
+			GetAvailableDevicesTimeoutSeconds:  1,
+			ReservePodQuotaTimeoutSeconds:      10,
+			AllocationTimeoutSeconds:           60,
+			DeviceInventoryResyncPeriodSeconds: 5,
+			ReservationReconcilePeriodSeconds:  300,
+			ReservationLeaseSeconds:            60,
+			DeviceManagerUnreachableSeconds:    60,
+			ScoringStrategy: config.ScoringStrategy{
+				Type: config.MostAllocated,
+				Resources: []schedconfig.ResourceSpec{
+					{Name: "compute", Weight: 2},
+					{Name: "memory", Weight: 1},
+				},
+			},
+			DeviceManagerEndpoint: config.DeviceManagerEndpoint{Type: config.NodeAddressEndpoint},
+			NoFitPolicy:           config.ProvisionNoFit,
+		}
+	}
+
+	testCases := []struct {
+		args        *config.ShareDevPluginArgs
+		expectedErr error
+		description string
+	}{
+		{
+			description: "correct config",
+			args:        validArgs(),
+		},
+		{
+			description: "incorrect config, invalid port",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.DeviceManagerPort = 70000
+				return args
+			}(),
+			expectedErr: fmt.Errorf("deviceManagerPort: Invalid value:"),
+		},
+		{
+			description: "incorrect config, empty allocator namespace",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.AllocatorNamespace = ""
+				return args
+			}(),
+			expectedErr: fmt.Errorf("allocatorNamespace: Required value"),
+		},
+		{
+			description: "incorrect config, empty allocator image",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.AllocatorImage = ""
+				return args
+			}(),
+			expectedErr: fmt.Errorf("allocatorImage: Required value"),
+		},
+		{
+			description: "incorrect config, non-positive rpc timeout",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.GetAvailableDevicesTimeoutSeconds = 0
+				return args
+			}(),
+			expectedErr: fmt.Errorf("getAvailableDevicesTimeoutSeconds: Invalid value:"),
+		},
+		{
+			description: "incorrect config, non-positive allocation timeout",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.AllocationTimeoutSeconds = -1
+				return args
+			}(),
+			expectedErr: fmt.Errorf("allocationTimeoutSeconds: Invalid value:"),
+		},
+		{
+			description: "incorrect config, non-positive device inventory resync period",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.DeviceInventoryResyncPeriodSeconds = 0
+				return args
+			}(),
+			expectedErr: fmt.Errorf("deviceInventoryResyncPeriodSeconds: Invalid value:"),
+		},
+		{
+			description: "incorrect config, non-positive reservation reconcile period",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.ReservationReconcilePeriodSeconds = 0
+				return args
+			}(),
+			expectedErr: fmt.Errorf("reservationReconcilePeriodSeconds: Invalid value:"),
+		},
+		{
+			description: "correct config, reservations without lease",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.ReservationLeaseSeconds = 0
+				return args
+			}(),
+		},
+		{
+			description: "incorrect config, negative reservation lease",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.ReservationLeaseSeconds = -1
+				return args
+			}(),
+			expectedErr: fmt.Errorf("reservationLeaseSeconds: Invalid value:"),
+		},
+		{
+			description: "incorrect config, zero deviceManagerUnreachableSeconds",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.DeviceManagerUnreachableSeconds = 0
+				return args
+			}(),
+			expectedErr: fmt.Errorf("deviceManagerUnreachableSeconds: Invalid value:"),
+		},
+		{
+			description: "correct config, preempt or provision",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.NoFitPolicy = config.PreemptOrProvisionNoFit
+				return args
+			}(),
+		},
+		{
+			description: "incorrect config, unsupported no fit policy",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.NoFitPolicy = "Wait"
+				return args
+			}(),
+			expectedErr: fmt.Errorf("noFitPolicy: Unsupported value:"),
+		},
+		{
+			description: "correct config, device model costs",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.DeviceModelCosts = []config.DeviceModelCost{
+					{Vendor: "example.com", Model: "big", Cost: 10},
+					{Vendor: "example.com", Model: "small", Cost: 0},
+				}
+				return args
+			}(),
+		},
+		{
+			description: "incorrect config, negative device model cost",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.DeviceModelCosts = []config.DeviceModelCost{{Vendor: "example.com", Model: "big", Cost: -1}}
+				return args
+			}(),
+			expectedErr: fmt.Errorf("deviceModelCosts[0].cost: Invalid value:"),
+		},
+		{
+			description: "incorrect config, device model cost without model",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.DeviceModelCosts = []config.DeviceModelCost{{Vendor: "example.com", Cost: 1}}
+				return args
+			}(),
+			expectedErr: fmt.Errorf("deviceModelCosts[0].model: Required value:"),
+		},
+		{
+			description: "incorrect config, duplicate device model cost",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.DeviceModelCosts = []config.DeviceModelCost{
+					{Vendor: "example.com", Model: "big", Cost: 10},
+					{Vendor: "example.com", Model: "big", Cost: 5},
+				}
+				return args
+			}(),
+			expectedErr: fmt.Errorf("deviceModelCosts[1]: Duplicate value:"),
+		},
+		{
+			description: "incorrect config, unsupported scoring strategy",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.ScoringStrategy.Type = config.LeastNUMANodes
+				return args
+			}(),
+			expectedErr: fmt.Errorf("scoringStrategy.type: Invalid value:"),
+		},
+		{
+			description: "incorrect config, unsupported scoring resource",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.ScoringStrategy.Resources[0].Name = "cpu"
+				return args
+			}(),
+			expectedErr: fmt.Errorf("scoringStrategy.resources[0].name: Unsupported value:"),
+		},
+		{
+			description: "incorrect config, non-positive scoring weight",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.ScoringStrategy.Resources[1].Weight = 0
+				return args
+			}(),
+			expectedErr: fmt.Errorf("scoringStrategy.resources[1].weight: Invalid value:"),
+		},
+		{
+			description: "correct config, device manager pods",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.DeviceManagerEndpoint = config.DeviceManagerEndpoint{Type: config.PodEndpoint, PodNamespace: "kube-system", PodSelector: "app=device-manager"}
+				return args
+			}(),
+		},
+		{
+			description: "incorrect config, unsupported endpoint type",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.DeviceManagerEndpoint.Type = "Consul"
+				return args
+			}(),
+			expectedErr: fmt.Errorf("deviceManagerEndpoint.type: Unsupported value:"),
+		},
+		{
+			description: "incorrect config, invalid endpoint annotation",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.DeviceManagerEndpoint = config.DeviceManagerEndpoint{Type: config.NodeAnnotationEndpoint, Annotation: "not a key"}
+				return args
+			}(),
+			expectedErr: fmt.Errorf("deviceManagerEndpoint.annotation: Invalid value:"),
+		},
+		{
+			description: "incorrect config, invalid device manager pod selector",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.DeviceManagerEndpoint = config.DeviceManagerEndpoint{Type: config.PodEndpoint, PodNamespace: "kube-system", PodSelector: "app in ("}
+				return args
+			}(),
+			expectedErr: fmt.Errorf("deviceManagerEndpoint.podSelector: Invalid value:"),
+		},
+		{
+			description: "incorrect config, invalid endpoint template",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.DeviceManagerEndpoint = config.DeviceManagerEndpoint{Type: config.TemplateEndpoint, Template: "{{.NodeName"}
+				return args
+			}(),
+			expectedErr: fmt.Errorf("deviceManagerEndpoint.template: Invalid value:"),
+		},
+		{
+			description: "correct config, mutual TLS",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.DeviceManagerTLS = config.DeviceManagerTLS{CAFile: "/etc/sharedev/ca.crt", CertFile: "/etc/sharedev/tls.crt", KeyFile: "/etc/sharedev/tls.key"}
+				return args
+			}(),
+		},
+		{
+			description: "correct config, insecure",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.DeviceManagerTLS = config.DeviceManagerTLS{Insecure: true}
+				return args
+			}(),
+		},
+		{
+			description: "incorrect config, client certificate without key",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.DeviceManagerTLS = config.DeviceManagerTLS{CertFile: "/etc/sharedev/tls.crt"}
+				return args
+			}(),
+			expectedErr: fmt.Errorf("deviceManagerTLS.keyFile: Required value:"),
+		},
+		{
+			description: "incorrect config, CA bundle in insecure mode",
+			args: func() *config.ShareDevPluginArgs {
+				args := validArgs()
+				args.DeviceManagerTLS = config.DeviceManagerTLS{Insecure: true, CAFile: "/etc/sharedev/ca.crt"}
+				return args
+			}(),
+			expectedErr: fmt.Errorf("deviceManagerTLS.caFile: Invalid value:"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			err := ValidateShareDevPluginArgs(nil, testCase.args)
+			if testCase.expectedErr != nil {
+				if err == nil {
+					t.Fatalf("expected err to equal %v not nil", testCase.expectedErr)
+				}
+
+				if !strings.Contains(err.Error(), testCase.expectedErr.Error()) {
+					t.Errorf("expected err to contain %s in error message: %s", testCase.expectedErr.Error(), err.Error())
+				}
+			}
+			if testCase.expectedErr == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -52,6 +52,54 @@ func (in *CoschedulingArgs) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeviceManagerEndpoint) DeepCopyInto(out *DeviceManagerEndpoint) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeviceManagerEndpoint.
+func (in *DeviceManagerEndpoint) DeepCopy() *DeviceManagerEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(DeviceManagerEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeviceManagerTLS) DeepCopyInto(out *DeviceManagerTLS) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeviceManagerTLS.
+func (in *DeviceManagerTLS) DeepCopy() *DeviceManagerTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(DeviceManagerTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeviceModelCost) DeepCopyInto(out *DeviceModelCost) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeviceModelCost.
+func (in *DeviceModelCost) DeepCopy() *DeviceModelCost {
+	if in == nil {
+		return nil
+	}
+	out := new(DeviceModelCost)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LoadVariationRiskBalancingArgs) DeepCopyInto(out *LoadVariationRiskBalancingArgs) {
 	*out = *in
@@ -290,6 +338,39 @@ func (in *ScoringStrategy) DeepCopy() *ScoringStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShareDevPluginArgs) DeepCopyInto(out *ShareDevPluginArgs) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ScoringStrategy.DeepCopyInto(&out.ScoringStrategy)
+	out.DeviceManagerEndpoint = in.DeviceManagerEndpoint
+	out.DeviceManagerTLS = in.DeviceManagerTLS
+	if in.DeviceModelCosts != nil {
+		in, out := &in.DeviceModelCosts, &out.DeviceModelCosts
+		*out = make([]DeviceModelCost, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShareDevPluginArgs.
+func (in *ShareDevPluginArgs) DeepCopy() *ShareDevPluginArgs {
+	if in == nil {
+		return nil
+	}
+	out := new(ShareDevPluginArgs)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ShareDevPluginArgs) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TargetLoadPackingArgs) DeepCopyInto(out *TargetLoadPackingArgs) {
 	*out = *in
@@ -53,6 +53,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&ElasticQuotaList{},
 		&PodGroup{},
 		&PodGroupList{},
+		&SharedDeviceClaim{},
+		&SharedDeviceClaimList{},
 	)
 	// AddToGroupVersion allows the serialization of client types like ListOptions.
 	v1.AddToGroupVersion(scheme, SchemeGroupVersion)
@@ -18,7 +18,9 @@ package v1alpha1
 
 import (
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/scheduler-plugins/apis/scheduling"
 )
 
@@ -185,3 +187,215 @@ type PodGroupList struct {
 	// Items is the list of PodGroup
 	Items []PodGroup `json:"items"`
 }
+
+// SharedDeviceClaimPhase is the phase of a shared device claim at the current time.
+type SharedDeviceClaimPhase string
+
+// These are the valid phase of sharedDeviceClaims.
+const (
+	// SharedDeviceClaimPending means the claim has not been bound to a device yet.
+	SharedDeviceClaimPending SharedDeviceClaimPhase = "Pending"
+
+	// SharedDeviceClaimBound means quota for the claim has been reserved on a device.
+	SharedDeviceClaimBound SharedDeviceClaimPhase = "Bound"
+
+	// SharedDeviceClaimLabel is the label a pod uses to reference a SharedDeviceClaim by name
+	// in its own namespace.
+	SharedDeviceClaimLabel = scheduling.GroupName + "/shared-device-claim"
+
+	// SharedDeviceIDAnnotation is set by the scheduler on a bound pod to the device its claim was reserved on,
+	// or a comma-separated list of devices if the claim spans several.
+	SharedDeviceIDAnnotation = scheduling.GroupName + "/shared-device-id"
+
+	// SharedDeviceContainerIDAnnotationPrefix followed by a container name is set by the scheduler on a
+	// bound pod to the device of the container's own share. The share is reserved under the client ID
+	// "<pod namespace>/<pod name>/<pod UID>.<container name>", the other shares of the pod under
+	// "<pod namespace>/<pod name>/<pod UID>".
+	SharedDeviceContainerIDAnnotationPrefix = scheduling.GroupName + "/shared-device-id."
+
+	// SharedDeviceHostIPAnnotation is set by the scheduler on a bound pod to the IP of the device manager
+	// serving its device.
+	SharedDeviceHostIPAnnotation = scheduling.GroupName + "/shared-device-host-ip"
+
+	// SharedDeviceModelAnnotation is set by the scheduler on a bound pod to the "<vendor>/<model>" of its
+	// devices, one of the models its claim accepts.
+	SharedDeviceModelAnnotation = scheduling.GroupName + "/shared-device-model"
+
+	// SharedDeviceRoleLabel tells the allocator pods claiming shared devices from the
+	// client pods using them.
+	SharedDeviceRoleLabel = "sharedev"
+
+	// SharedDeviceAllocatorRole is the SharedDeviceRoleLabel value of allocator pods. An allocator
+	// registers the device it claims under its own pod name.
+	SharedDeviceAllocatorRole = "allocator"
+
+	// SharedDeviceClientRole is the SharedDeviceRoleLabel value of pods bound to a shared device.
+	SharedDeviceClientRole = "client"
+
+	// SharedDeviceAllocatorNameLabel is the name of the Deployment an allocator pod belongs to.
+	SharedDeviceAllocatorNameLabel = "app"
+)
+
+// SharedDeviceClaim is a request for a fraction of a shared device; pods reference it by name.
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName={sdc,sdcs}
+// +kubebuilder:subresource:status
+type SharedDeviceClaim struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard object's metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Specification of the requested device share.
+	// +optional
+	Spec SharedDeviceClaimSpec `json:"spec,omitempty"`
+
+	// Status represents the device the claim is bound to.
+	// This data may not be up to date.
+	// +optional
+	Status SharedDeviceClaimStatus `json:"status,omitempty"`
+}
+
+// SharedDeviceClaimSpec represents the requested share of a device.
+// Shares are expressed as quantities of a whole device, e.g. "250m" is a quarter of it.
+type SharedDeviceClaimSpec struct {
+	// Vendor is the vendor of the acceptable device.
+	Vendor string `json:"vendor"`
+
+	// Model is the model of the acceptable device.
+	Model string `json:"model"`
+
+	// Compute is the guaranteed share of the device compute.
+	Compute resource.Quantity `json:"compute"`
+
+	// Memory is the share of the device memory.
+	Memory resource.Quantity `json:"memory"`
+
+	// Limit is the maximal share of the device compute the pod may burst to.
+	// Defaults to Compute if unset.
+	// +optional
+	Limit *resource.Quantity `json:"limit,omitempty"`
+
+	// Count is how many devices the pod needs the share of, each on a different device.
+	// Defaults to 1.
+	// +optional
+	Count *int32 `json:"count,omitempty"`
+
+	// Containers are shares used by a single container on top of the pod's shares,
+	// e.g. a small slice for a sidecar. They may land on any device, including the pod's.
+	// +optional
+	Containers []SharedDeviceContainerShare `json:"containers,omitempty"`
+
+	// Fallbacks are other models the pod runs on, in preference order after Vendor and Model.
+	// ElasticQuotas still account the claim against Vendor and Model.
+	// +optional
+	Fallbacks []SharedDeviceModel `json:"fallbacks,omitempty"`
+}
+
+// SharedDeviceModel is a device model a claim accepts besides its own.
+type SharedDeviceModel struct {
+	// Vendor is the vendor of the device.
+	Vendor string `json:"vendor"`
+
+	// Model is the model of the device.
+	Model string `json:"model"`
+
+	// ComputeScale multiplies the compute shares and limits of the claim on devices of the
+	// model, e.g. "2" for a model half as fast. Models the shares don't fit once scaled are
+	// skipped. Defaults to 1.
+	// +optional
+	ComputeScale *resource.Quantity `json:"computeScale,omitempty"`
+}
+
+// SharedDeviceContainerShare is the share of a device used by a single container.
+type SharedDeviceContainerShare struct {
+	// Name is the name of the container.
+	Name string `json:"name"`
+
+	// Compute is the guaranteed share of the device compute.
+	Compute resource.Quantity `json:"compute"`
+
+	// Memory is the share of the device memory.
+	Memory resource.Quantity `json:"memory"`
+
+	// Limit is the maximal share of the device compute the container may burst to.
+	// Defaults to Compute if unset.
+	// +optional
+	Limit *resource.Quantity `json:"limit,omitempty"`
+}
+
+// SharedDeviceClaimStatus represents the current state of a shared device claim.
+// A claim may be used by several pods: the devices of each are in Pods, the
+// other fields are those of the first one.
+type SharedDeviceClaimStatus struct {
+	// Current phase of SharedDeviceClaim.
+	Phase SharedDeviceClaimPhase `json:"phase,omitempty"`
+
+	// DeviceID is the device the claim is bound to, the first one if Count is larger than 1.
+	// +optional
+	DeviceID string `json:"deviceID,omitempty"`
+
+	// DeviceIDs are all devices the pod's shares are bound to.
+	// +optional
+	DeviceIDs []string `json:"deviceIDs,omitempty"`
+
+	// ContainerDeviceIDs are the devices the container shares are bound to, by container name.
+	// +optional
+	ContainerDeviceIDs map[string]string `json:"containerDeviceIDs,omitempty"`
+
+	// NodeName is the node hosting the device the claim is bound to.
+	// +optional
+	NodeName string `json:"nodeName,omitempty"`
+
+	// Vendor and Model are the model of the devices the claim is bound to, one of the
+	// models of the spec.
+	// +optional
+	Vendor string `json:"vendor,omitempty"`
+	// +optional
+	Model string `json:"model,omitempty"`
+
+	// Pods are the devices every pod using the claim is bound to, in the order
+	// they were bound.
+	// +optional
+	Pods []SharedDeviceClaimPodStatus `json:"pods,omitempty"`
+}
+
+// SharedDeviceClaimPodStatus represents the devices one pod using a shared
+// device claim is bound to.
+type SharedDeviceClaimPodStatus struct {
+	// Name and UID identify the pod.
+	Name string    `json:"name"`
+	UID  types.UID `json:"uid"`
+
+	// NodeName is the node hosting the devices of the pod.
+	NodeName string `json:"nodeName"`
+
+	// DeviceIDs are the devices the pod's shares are bound to.
+	// +optional
+	DeviceIDs []string `json:"deviceIDs,omitempty"`
+
+	// ContainerDeviceIDs are the devices the container shares are bound to, by container name.
+	// +optional
+	ContainerDeviceIDs map[string]string `json:"containerDeviceIDs,omitempty"`
+
+	// Vendor and Model are the model of the devices of the pod.
+	// +optional
+	Vendor string `json:"vendor,omitempty"`
+	// +optional
+	Model string `json:"model,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SharedDeviceClaimList is a collection of shared device claims.
+type SharedDeviceClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard list metadata
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	// Items is the list of SharedDeviceClaim
+	Items []SharedDeviceClaim `json:"items"`
+}
@@ -237,3 +237,206 @@ func (in *PodGroupStatus) DeepCopy() *PodGroupStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedDeviceClaim) DeepCopyInto(out *SharedDeviceClaim) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedDeviceClaim.
+func (in *SharedDeviceClaim) DeepCopy() *SharedDeviceClaim {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedDeviceClaim)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SharedDeviceClaim) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedDeviceClaimList) DeepCopyInto(out *SharedDeviceClaimList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SharedDeviceClaim, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedDeviceClaimList.
+func (in *SharedDeviceClaimList) DeepCopy() *SharedDeviceClaimList {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedDeviceClaimList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SharedDeviceClaimList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedDeviceClaimSpec) DeepCopyInto(out *SharedDeviceClaimSpec) {
+	*out = *in
+	out.Compute = in.Compute.DeepCopy()
+	out.Memory = in.Memory.DeepCopy()
+	if in.Limit != nil {
+		in, out := &in.Limit, &out.Limit
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Count != nil {
+		in, out := &in.Count, &out.Count
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]SharedDeviceContainerShare, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Fallbacks != nil {
+		in, out := &in.Fallbacks, &out.Fallbacks
+		*out = make([]SharedDeviceModel, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedDeviceClaimSpec.
+func (in *SharedDeviceClaimSpec) DeepCopy() *SharedDeviceClaimSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedDeviceClaimSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedDeviceClaimPodStatus) DeepCopyInto(out *SharedDeviceClaimPodStatus) {
+	*out = *in
+	if in.DeviceIDs != nil {
+		in, out := &in.DeviceIDs, &out.DeviceIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ContainerDeviceIDs != nil {
+		in, out := &in.ContainerDeviceIDs, &out.ContainerDeviceIDs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedDeviceClaimPodStatus.
+func (in *SharedDeviceClaimPodStatus) DeepCopy() *SharedDeviceClaimPodStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedDeviceClaimPodStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedDeviceClaimStatus) DeepCopyInto(out *SharedDeviceClaimStatus) {
+	*out = *in
+	if in.DeviceIDs != nil {
+		in, out := &in.DeviceIDs, &out.DeviceIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ContainerDeviceIDs != nil {
+		in, out := &in.ContainerDeviceIDs, &out.ContainerDeviceIDs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make([]SharedDeviceClaimPodStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedDeviceClaimStatus.
+func (in *SharedDeviceClaimStatus) DeepCopy() *SharedDeviceClaimStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedDeviceClaimStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedDeviceContainerShare) DeepCopyInto(out *SharedDeviceContainerShare) {
+	*out = *in
+	out.Compute = in.Compute.DeepCopy()
+	out.Memory = in.Memory.DeepCopy()
+	if in.Limit != nil {
+		in, out := &in.Limit, &out.Limit
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedDeviceContainerShare.
+func (in *SharedDeviceContainerShare) DeepCopy() *SharedDeviceContainerShare {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedDeviceContainerShare)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedDeviceModel) DeepCopyInto(out *SharedDeviceModel) {
+	*out = *in
+	if in.ComputeScale != nil {
+		in, out := &in.ComputeScale, &out.ComputeScale
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedDeviceModel.
+func (in *SharedDeviceModel) DeepCopy() *SharedDeviceModel {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedDeviceModel)
+	in.DeepCopyInto(out)
+	return out
+}
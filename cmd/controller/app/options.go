@@ -17,7 +17,10 @@ limitations under the License.
 package app
 
 import (
+	"time"
+
 	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type ServerRunOptions struct {
@@ -27,6 +30,28 @@ type ServerRunOptions struct {
 	ApiServerBurst       int
 	Workers              int
 	EnableLeaderElection bool
+	// EnableSharedDeviceWebhook serves the webhook injecting shared device env vars into pods.
+	EnableSharedDeviceWebhook bool
+	// EnableSharedDeviceAllocatorController deletes the shared device allocators that aren't needed anymore.
+	EnableSharedDeviceAllocatorController bool
+	// AllocatorNamespace is the namespace the scheduler creates shared device allocators in.
+	AllocatorNamespace string
+	// AllocatorIdleGracePeriod is how long a shared device may have no client pods before its allocator is deleted.
+	AllocatorIdleGracePeriod time.Duration
+	// AllocatorPendingTimeout is how long an allocator pod may be pending before the allocator is deleted.
+	AllocatorPendingTimeout time.Duration
+	// EnableSharedDeviceDefragmentation periodically evicts the clients of barely used shared devices to consolidate them.
+	EnableSharedDeviceDefragmentation bool
+	// DefragmentationInterval is the time between two defragmentation runs.
+	DefragmentationInterval time.Duration
+	// DefragmentationUtilizationThreshold is the fraction of a shared device below which it is drained.
+	DefragmentationUtilizationThreshold float64
+	// DefragmentationMaxEvictions is how many pods a defragmentation run evicts at most.
+	DefragmentationMaxEvictions int
+	// DefragmentationDryRun only logs the defragmentation plan.
+	DefragmentationDryRun bool
+	// SchedulerConfigFile is the configuration of the scheduler running the ShareDevPlugin.
+	SchedulerConfigFile string
 }
 
 func NewServerRunOptions() *ServerRunOptions {
@@ -42,4 +67,15 @@ func (s *ServerRunOptions) addAllFlags() {
 	pflag.IntVar(&s.ApiServerBurst, "burst", 10, "burst of query apiserver.")
 	pflag.IntVar(&s.Workers, "workers", 1, "workers of scheduler-plugin-controllers.")
 	pflag.BoolVar(&s.EnableLeaderElection, "enableLeaderElection", s.EnableLeaderElection, "If EnableLeaderElection for controller.")
+	pflag.BoolVar(&s.EnableSharedDeviceWebhook, "enableSharedDeviceWebhook", s.EnableSharedDeviceWebhook, "If serve the webhook injecting shared device env vars into pods.")
+	pflag.BoolVar(&s.EnableSharedDeviceAllocatorController, "enableSharedDeviceAllocatorController", s.EnableSharedDeviceAllocatorController, "If delete the shared device allocators without client pods or that can't start.")
+	pflag.StringVar(&s.AllocatorNamespace, "allocatorNamespace", metav1.NamespaceDefault, "Namespace the scheduler creates shared device allocators in.")
+	pflag.DurationVar(&s.AllocatorIdleGracePeriod, "allocatorIdleGracePeriod", 10*time.Minute, "How long a shared device may have no client pods before its allocator is deleted.")
+	pflag.DurationVar(&s.AllocatorPendingTimeout, "allocatorPendingTimeout", 5*time.Minute, "How long an allocator pod may be pending before the allocator is deleted.")
+	pflag.BoolVar(&s.EnableSharedDeviceDefragmentation, "enableSharedDeviceDefragmentation", s.EnableSharedDeviceDefragmentation, "If periodically evict the clients of barely used shared devices to consolidate them.")
+	pflag.DurationVar(&s.DefragmentationInterval, "defragmentationInterval", 10*time.Minute, "Time between two shared device defragmentation runs.")
+	pflag.Float64Var(&s.DefragmentationUtilizationThreshold, "defragmentationUtilizationThreshold", 0.5, "Fraction of a shared device below which its clients are moved to fuller devices.")
+	pflag.IntVar(&s.DefragmentationMaxEvictions, "defragmentationMaxEvictions", 5, "How many pods a shared device defragmentation run evicts at most.")
+	pflag.BoolVar(&s.DefragmentationDryRun, "defragmentationDryRun", s.DefragmentationDryRun, "If only log the shared device defragmentation plan, without evicting pods.")
+	pflag.StringVar(&s.SchedulerConfigFile, "schedulerConfigFile", "", "Configuration of the scheduler running the ShareDevPlugin. Defragmentation reaches the device managers the way the plugin does, and requires its MostAllocated scoring strategy.")
 }
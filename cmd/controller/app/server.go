@@ -17,16 +17,29 @@ limitations under the License.
 package app
 
 import (
+	"context"
+	"fmt"
+	"os"
+
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	toolscache "k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2/klogr"
+	schedconfig "k8s.io/kubernetes/pkg/scheduler/apis/config"
 
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 
+	pluginconfig "sigs.k8s.io/scheduler-plugins/apis/config"
+	pluginscheme "sigs.k8s.io/scheduler-plugins/apis/config/scheme"
 	schedulingv1a1 "sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
 	"sigs.k8s.io/scheduler-plugins/pkg/controllers"
+	"sigs.k8s.io/scheduler-plugins/pkg/sharedev"
 )
 
 var (
@@ -47,7 +60,12 @@ func Run(s *ServerRunOptions) error {
 
 	// Controller Runtime Controllers
 	ctrl.SetLogger(klogr.New())
+	var cacheOptions cache.Options
+	if s.EnableSharedDeviceAllocatorController {
+		cacheOptions.SelectorsByObject = controllers.AllocatorCacheSelectors(s.AllocatorNamespace)
+	}
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+		NewCache:                cache.BuilderWithOptions(cacheOptions),
 		Scheme:                  scheme,
 		MetricsBindAddress:      s.MetricsAddr,
 		Port:                    9443,
@@ -79,6 +97,49 @@ func Run(s *ServerRunOptions) error {
 		return err
 	}
 
+	if s.EnableSharedDeviceAllocatorController {
+		if err = (&controllers.AllocatorReconciler{
+			Client:          mgr.GetClient(),
+			Scheme:          mgr.GetScheme(),
+			Workers:         s.Workers,
+			Namespace:       s.AllocatorNamespace,
+			IdleGracePeriod: s.AllocatorIdleGracePeriod,
+			PendingTimeout:  s.AllocatorPendingTimeout,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Allocator")
+			return err
+		}
+	}
+
+	ctx := ctrl.SetupSignalHandler()
+	if s.EnableSharedDeviceDefragmentation {
+		kubeClient := kubernetes.NewForConfigOrDie(config)
+		deviceManagers, err := shareDevDeviceManagers(ctx, s.SchedulerConfigFile, kubeClient)
+		if err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "SharedDeviceDefragmenter")
+			return err
+		}
+		if err = (&controllers.SharedDeviceDefragmenter{
+			Client:               mgr.GetClient(),
+			KubeClient:           kubeClient,
+			Inventory:            &controllers.DeviceManagerInventory{DeviceManagers: deviceManagers},
+			Interval:             s.DefragmentationInterval,
+			UtilizationThreshold: s.DefragmentationUtilizationThreshold,
+			MaxEvictions:         s.DefragmentationMaxEvictions,
+			DryRun:               s.DefragmentationDryRun,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "SharedDeviceDefragmenter")
+			return err
+		}
+	}
+
+	if s.EnableSharedDeviceWebhook {
+		if err = (&controllers.SharedDevicePodDefaulter{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "SharedDevicePod")
+			return err
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		return err
@@ -88,9 +149,65 @@ func Run(s *ServerRunOptions) error {
 		return err
 	}
 
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "unable to start manager")
 		return err
 	}
 	return nil
 }
+
+// shareDevDeviceManagers returns the device managers of the ShareDevPlugin
+// configured in the scheduler configuration file. Defragmenting only
+// consolidates the devices if the scheduler packs the evicted clients onto the
+// fullest devices, so the plugin must use the MostAllocated scoring strategy.
+func shareDevDeviceManagers(ctx context.Context, schedulerConfigFile string, kubeClient kubernetes.Interface) (*sharedev.DeviceManagers, error) {
+	args, err := shareDevPluginArgs(schedulerConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	if args.ScoringStrategy.Type != pluginconfig.MostAllocated {
+		return nil, fmt.Errorf("shared device defragmentation requires the %s scoring strategy of %s, got %s",
+			pluginconfig.MostAllocated, sharedev.Name, args.ScoringStrategy.Type)
+	}
+
+	var podLister corelisters.PodLister
+	if args.DeviceManagerEndpoint.Type == pluginconfig.PodEndpoint {
+		informerFactory := informers.NewSharedInformerFactoryWithOptions(kubeClient, 0,
+			informers.WithNamespace(args.DeviceManagerEndpoint.PodNamespace))
+		podInformer := informerFactory.Core().V1().Pods()
+		podLister = podInformer.Lister()
+		informerFactory.Start(ctx.Done())
+		if !toolscache.WaitForCacheSync(ctx.Done(), podInformer.Informer().HasSynced) {
+			return nil, fmt.Errorf("timed out waiting for the device manager pods to sync")
+		}
+	}
+	return sharedev.NewDeviceManagers(args, podLister)
+}
+
+// shareDevPluginArgs returns the args of the ShareDevPlugin in the scheduler
+// configuration file, defaulted like the scheduler does.
+func shareDevPluginArgs(schedulerConfigFile string) (*pluginconfig.ShareDevPluginArgs, error) {
+	if schedulerConfigFile == "" {
+		return nil, fmt.Errorf("--schedulerConfigFile is required for shared device defragmentation")
+	}
+	data, err := os.ReadFile(schedulerConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	obj, gvk, err := pluginscheme.Codecs.UniversalDecoder().Decode(data, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	cfg, ok := obj.(*schedconfig.KubeSchedulerConfiguration)
+	if !ok {
+		return nil, fmt.Errorf("couldn't decode %s as KubeSchedulerConfiguration, got %s", schedulerConfigFile, gvk)
+	}
+	for _, profile := range cfg.Profiles {
+		for _, pc := range profile.PluginConfig {
+			if args, ok := pc.Args.(*pluginconfig.ShareDevPluginArgs); ok && pc.Name == sharedev.Name {
+				return args, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s has no %s args", schedulerConfigFile, sharedev.Name)
+}
@@ -24,7 +24,6 @@ import (
 
 	v1 "k8s.io/api/core/v1"
 	policy "k8s.io/api/policy/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -55,6 +54,7 @@ type CapacityScheduling struct {
 	podLister          corelisters.PodLister
 	pdbLister          policylisters.PodDisruptionBudgetLister
 	elasticQuotaLister externalv1alpha1.ElasticQuotaLister
+	claimLister        externalv1alpha1.SharedDeviceClaimLister
 	elasticQuotaInfos  ElasticQuotaInfos
 }
 
@@ -151,8 +151,11 @@ func New(obj runtime.Object, handle framework.Handle) (framework.Plugin, error)
 			},
 		})
 
+	claimInformer := schedSharedInformerFactory.Scheduling().V1alpha1().SharedDeviceClaims()
+	c.claimLister = claimInformer.Lister()
+
 	schedSharedInformerFactory.Start(nil)
-	if !cache.WaitForCacheSync(nil, elasticQuotaInformer.HasSynced) {
+	if !cache.WaitForCacheSync(nil, elasticQuotaInformer.HasSynced, claimInformer.Informer().HasSynced) {
 		return nil, fmt.Errorf("timed out waiting for caches to sync %v", Name)
 	}
 
@@ -200,7 +203,7 @@ func (c *CapacityScheduling) PreFilter(ctx context.Context, state *framework.Cyc
 	// TODO improve the efficiency of taking snapshot
 	// e.g. use a two-pointer data structure to only copy the updated EQs when necessary.
 	snapshotElasticQuota := c.snapshotElasticQuota()
-	podReq := computePodResourceRequest(pod)
+	podReq := c.computePodResourceRequest(pod)
 
 	state.Write(ElasticQuotaSnapshotKey, snapshotElasticQuota)
 
@@ -237,7 +240,7 @@ func (c *CapacityScheduling) PreFilter(ctx context.Context, state *framework.Cyc
 			ns := p.Pod.Namespace
 			info := c.elasticQuotaInfos[ns]
 			if info != nil {
-				pResourceRequest := util.ResourceList(computePodResourceRequest(p.Pod))
+				pResourceRequest := util.ResourceList(c.computePodResourceRequest(p.Pod))
 				// If they are subject to the same quota(namespace) and p is more important than pod,
 				// p will be added to the nominatedResource and totalNominatedResource.
 				// If they aren't subject to the same quota(namespace) and the usage of quota(p's namespace) does not exceed min,
@@ -287,7 +290,7 @@ func (c *CapacityScheduling) AddPod(ctx context.Context, cycleState *framework.C
 
 	elasticQuotaInfo := elasticQuotaSnapshotState.elasticQuotaInfos[podToAdd.Pod.Namespace]
 	if elasticQuotaInfo != nil {
-		err := elasticQuotaInfo.addPodIfNotPresent(podToAdd.Pod)
+		err := elasticQuotaInfo.addPodIfNotPresent(podToAdd.Pod, c.getSharedDeviceRequest(podToAdd.Pod))
 		if err != nil {
 			klog.ErrorS(err, "Failed to add Pod to its associated elasticQuota", "pod", klog.KObj(podToAdd.Pod))
 		}
@@ -341,7 +344,7 @@ func (c *CapacityScheduling) Reserve(ctx context.Context, state *framework.Cycle
 
 	elasticQuotaInfo := c.elasticQuotaInfos[pod.Namespace]
 	if elasticQuotaInfo != nil {
-		err := elasticQuotaInfo.addPodIfNotPresent(pod)
+		err := elasticQuotaInfo.addPodIfNotPresent(pod, c.getSharedDeviceRequest(pod))
 		if err != nil {
 			klog.ErrorS(err, "Failed to add Pod to its associated elasticQuota", "pod", klog.KObj(pod))
 			return framework.NewStatus(framework.Error, err.Error())
@@ -599,7 +602,7 @@ func (p *preemptor) SelectVictimsOnNode(
 	// Try to reprieve as many pods as possible. We first try to reprieve the PDB
 	// violating victims and then other non-violating ones. In both cases, we start
 	// from the highest priority victims.
-	violatingVictims, nonViolatingVictims := filterPodsWithPDBViolation(potentialVictims, pdbs)
+	violatingVictims, nonViolatingVictims := util.FilterPodsWithPDBViolation(potentialVictims, pdbs)
 	reprievePod := func(pi *framework.PodInfo) (bool, error) {
 		if err := addPod(pi); err != nil {
 			return false, err
@@ -667,6 +670,7 @@ func (c *CapacityScheduling) updateElasticQuota(oldObj, newObj interface{}) {
 	oldEQInfo := c.elasticQuotaInfos[oldEQ.Namespace]
 	if oldEQInfo != nil {
 		newEQInfo.pods = oldEQInfo.pods
+		newEQInfo.sharedDevice = oldEQInfo.sharedDevice
 		newEQInfo.Used = oldEQInfo.Used
 	}
 	c.elasticQuotaInfos[newEQ.Namespace] = newEQInfo
@@ -707,7 +711,7 @@ func (c *CapacityScheduling) addPod(obj interface{}) {
 		}
 	}
 
-	err := elasticQuotaInfo.addPodIfNotPresent(pod)
+	err := elasticQuotaInfo.addPodIfNotPresent(pod, c.getSharedDeviceRequest(pod))
 	if err != nil {
 		klog.ErrorS(err, "Failed to add Pod to its associated elasticQuota", "pod", klog.KObj(pod))
 	}
@@ -839,56 +843,27 @@ func computePodResourceRequest(pod *v1.Pod) *framework.Resource {
 	return result
 }
 
-// filterPodsWithPDBViolation groups the given "pods" into two groups of "violatingPods"
-// and "nonViolatingPods" based on whether their PDBs will be violated if they are
-// preempted.
-// This function is stable and does not change the order of received pods. So, if it
-// receives a sorted list, grouping will preserve the order of the input list.
-func filterPodsWithPDBViolation(podInfos []*framework.PodInfo, pdbs []*policy.PodDisruptionBudget) (violatingPods, nonViolatingPods []*framework.PodInfo) {
-	pdbsAllowed := make([]int32, len(pdbs))
-	for i, pdb := range pdbs {
-		pdbsAllowed[i] = pdb.Status.DisruptionsAllowed
-	}
-
-	for _, podInfo := range podInfos {
-		pod := podInfo.Pod
-		pdbForPodIsViolated := false
-		// A pod with no labels will not match any PDB. So, no need to check.
-		if len(pod.Labels) != 0 {
-			for i, pdb := range pdbs {
-				if pdb.Namespace != pod.Namespace {
-					continue
-				}
-				selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
-				if err != nil {
-					continue
-				}
-				// A PDB with a nil or empty selector matches nothing.
-				if selector.Empty() || !selector.Matches(labels.Set(pod.Labels)) {
-					continue
-				}
+// computePodResourceRequest returns the resource request of pod including its
+// shared device usage.
+func (c *CapacityScheduling) computePodResourceRequest(pod *v1.Pod) *framework.Resource {
+	result := computePodResourceRequest(pod)
+	addSharedDeviceRequest(result, c.getSharedDeviceRequest(pod))
+	return result
+}
 
-				// Existing in DisruptedPods means it has been processed in API server,
-				// we don't treat it as a violating case.
-				if _, exist := pdb.Status.DisruptedPods[pod.Name]; exist {
-					continue
-				}
-				// Only decrement the matched pdb when it's not in its <DisruptedPods>;
-				// otherwise we may over-decrement the budget number.
-				pdbsAllowed[i]--
-				// We have found a matching PDB.
-				if pdbsAllowed[i] < 0 {
-					pdbForPodIsViolated = true
-				}
-			}
-		}
-		if pdbForPodIsViolated {
-			violatingPods = append(violatingPods, podInfo)
-		} else {
-			nonViolatingPods = append(nonViolatingPods, podInfo)
-		}
+// getSharedDeviceRequest returns the shares of devices the SharedDeviceClaim
+// of pod requests, as synthetic resources, or nil if the pod has no claim.
+func (c *CapacityScheduling) getSharedDeviceRequest(pod *v1.Pod) v1.ResourceList {
+	claimName := pod.Labels[v1alpha1.SharedDeviceClaimLabel]
+	if claimName == "" || c.claimLister == nil {
+		return nil
+	}
+	claim, err := c.claimLister.SharedDeviceClaims(pod.Namespace).Get(claimName)
+	if err != nil {
+		klog.V(4).InfoS("Not accounting the shared device usage of pod", "pod", klog.KObj(pod), "sharedDeviceClaim", claimName, "err", err)
+		return nil
 	}
-	return violatingPods, nonViolatingPods
+	return util.GetSharedDeviceRequest(claim)
 }
 
 // assignedPod selects pods that are assigned (scheduled and running).
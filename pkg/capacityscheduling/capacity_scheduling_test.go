@@ -31,6 +31,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/informers"
 	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/events"
 	extenderv1 "k8s.io/kube-scheduler/extender/v1"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
@@ -46,6 +47,8 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	externalv1alpha1 "sigs.k8s.io/scheduler-plugins/pkg/generated/listers/scheduling/v1alpha1"
+	"sigs.k8s.io/scheduler-plugins/pkg/util"
 	testutil "sigs.k8s.io/scheduler-plugins/test/util"
 )
 
@@ -1570,6 +1573,71 @@ func TestDeletePod(t *testing.T) {
 	}
 }
 
+func TestSharedDeviceQuota(t *testing.T) {
+	fwk, err := st.NewFramework(
+		[]st.RegisterPluginFunc{
+			st.RegisterQueueSortPlugin(queuesort.Name, queuesort.New),
+			st.RegisterBindPlugin(defaultbinder.Name, defaultbinder.New),
+		}, "", wait.NeverStop,
+		frameworkruntime.WithPodNominator(testutil.NewPodNominator(nil)),
+		frameworkruntime.WithSnapshotSharedLister(testutil.NewFakeSharedLister(make([]*v1.Pod, 0), make([]*v1.Node, 0))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compute := util.SharedDeviceComputeResource("example.com", "mydev")
+	memory := util.SharedDeviceMemoryResource("example.com", "mydev")
+	claims := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	half := makeSharedDeviceClaim("ns1", "half", "500m")
+	claims.Add(half)
+	claims.Add(makeSharedDeviceClaim("ns1", "three-quarters", "750m"))
+
+	oneDevice := v1.ResourceList{compute: resource.MustParse("1"), memory: resource.MustParse("1")}
+	cs := &CapacityScheduling{
+		elasticQuotaInfos: ElasticQuotaInfos{"ns1": newElasticQuotaInfo("ns1", oneDevice, oneDevice, nil)},
+		claimLister:       externalv1alpha1.NewSharedDeviceClaimLister(claims),
+		fh:                fwk,
+	}
+	makeClientPod := func(name, claim string) *v1.Pod {
+		pod := makePod(name, "ns1", 0, 0, 0, 0, name, "")
+		pod.Labels = map[string]string{v1alpha1.SharedDeviceClaimLabel: claim}
+		return pod
+	}
+
+	p1 := makeClientPod("p1", "half")
+	if got := cs.Reserve(context.TODO(), framework.NewCycleState(), p1, "node-a"); !got.IsSuccess() {
+		t.Fatalf("expected p1 to be reserved, got %v", got.Message())
+	}
+	if got := cs.elasticQuotaInfos["ns1"].Used.ScalarResources[compute]; got != 500 {
+		t.Errorf("expected half a device used, got %d thousandths", got)
+	}
+	if got := cs.elasticQuotaInfos["ns1"].Used.ScalarResources[memory]; got != 250 {
+		t.Errorf("expected a quarter of the device memory used, got %d thousandths", got)
+	}
+
+	for _, tt := range []struct {
+		pod      *v1.Pod
+		expected framework.Code
+	}{
+		{pod: makeClientPod("p2", "three-quarters"), expected: framework.Unschedulable},
+		{pod: makeClientPod("p3", "half"), expected: framework.Success},
+		// Not a shared device client.
+		{pod: makePod("p4", "ns1", 0, 0, 0, 0, "p4", ""), expected: framework.Success},
+	} {
+		if _, got := cs.PreFilter(context.TODO(), framework.NewCycleState(), tt.pod); got.Code() != tt.expected {
+			t.Errorf("expected %v for %s, got %v : %v", tt.expected, tt.pod.Name, got.Code(), got.Message())
+		}
+	}
+
+	// The usage of p1 is released even though its claim is gone.
+	claims.Delete(half)
+	cs.Unreserve(context.TODO(), framework.NewCycleState(), p1, "node-a")
+	if got := cs.elasticQuotaInfos["ns1"]; got.Used.ScalarResources[compute] != 0 || got.Used.ScalarResources[memory] != 0 || got.sharedDevice != nil {
+		t.Errorf("expected no shared device usage left, got %v and %v", got.Used.ScalarResources, got.sharedDevice)
+	}
+}
+
 func makePod(podName string, namespace string, memReq int64, cpuReq int64, gpuReq int64, priority int32, uid string, nodeName string) *v1.Pod {
 	pause := imageutils.GetPauseImageName()
 	pod := st.MakePod().Namespace(namespace).Name(podName).Container(pause).
@@ -1602,6 +1670,18 @@ func makeEQ(namespace, name string, max, min v1.ResourceList) *v1alpha1.ElasticQ
 	return eq
 }
 
+func makeSharedDeviceClaim(namespace, name, compute string) *v1alpha1.SharedDeviceClaim {
+	return &v1alpha1.SharedDeviceClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: v1alpha1.SharedDeviceClaimSpec{
+			Vendor:  "example.com",
+			Model:   "mydev",
+			Compute: resource.MustParse(compute),
+			Memory:  resource.MustParse("250m"),
+		},
+	}
+}
+
 func makeResourceList(cpu, mem int64) v1.ResourceList {
 	return v1.ResourceList{
 		v1.ResourceCPU:    *resource.NewMilliQuantity(cpu, resource.DecimalSI),
@@ -63,9 +63,13 @@ func (e ElasticQuotaInfos) aggregatedUsedOverMinWith(podRequest framework.Resour
 type ElasticQuotaInfo struct {
 	Namespace string
 	pods      sets.String
-	Min       *framework.Resource
-	Max       *framework.Resource
-	Used      *framework.Resource
+	// sharedDevice is the shared device usage of the pods that have some, by
+	// pod key. It is recorded when the pod is added, its SharedDeviceClaim
+	// may be gone by the time the pod is deleted.
+	sharedDevice map[string]v1.ResourceList
+	Min          *framework.Resource
+	Max          *framework.Resource
+	Used         *framework.Resource
 }
 
 func newElasticQuotaInfo(namespace string, min, max, used v1.ResourceList) *ElasticQuotaInfo {
@@ -79,9 +83,9 @@ func newElasticQuotaInfo(namespace string, min, max, used v1.ResourceList) *Elas
 	elasticQuotaInfo := &ElasticQuotaInfo{
 		Namespace: namespace,
 		pods:      sets.NewString(),
-		Min:       framework.NewResource(min),
-		Max:       framework.NewResource(max),
-		Used:      framework.NewResource(used),
+		Min:       newResource(min),
+		Max:       newResource(max),
+		Used:      newResource(used),
 	}
 	return elasticQuotaInfo
 }
@@ -145,6 +149,12 @@ func (e *ElasticQuotaInfo) clone() *ElasticQuotaInfo {
 	if e.Used != nil {
 		newEQInfo.Used = e.Used.Clone()
 	}
+	if len(e.sharedDevice) > 0 {
+		newEQInfo.sharedDevice = make(map[string]v1.ResourceList, len(e.sharedDevice))
+		for key, request := range e.sharedDevice {
+			newEQInfo.sharedDevice[key] = request
+		}
+	}
 	if len(e.pods) > 0 {
 		pods := e.pods.List()
 		for _, pod := range pods {
@@ -155,7 +165,9 @@ func (e *ElasticQuotaInfo) clone() *ElasticQuotaInfo {
 	return newEQInfo
 }
 
-func (e *ElasticQuotaInfo) addPodIfNotPresent(pod *v1.Pod) error {
+// addPodIfNotPresent accounts the requests of pod and its shared device usage,
+// see getSharedDeviceRequest.
+func (e *ElasticQuotaInfo) addPodIfNotPresent(pod *v1.Pod, sharedDevice v1.ResourceList) error {
 	key, err := framework.GetPodKey(pod)
 	if err != nil {
 		return err
@@ -167,6 +179,13 @@ func (e *ElasticQuotaInfo) addPodIfNotPresent(pod *v1.Pod) error {
 
 	e.pods.Insert(key)
 	podRequest := computePodResourceRequest(pod)
+	if len(sharedDevice) > 0 {
+		if e.sharedDevice == nil {
+			e.sharedDevice = map[string]v1.ResourceList{}
+		}
+		e.sharedDevice[key] = sharedDevice
+		addSharedDeviceRequest(podRequest, sharedDevice)
+	}
 	e.reserveResource(*podRequest)
 
 	return nil
@@ -184,11 +203,39 @@ func (e *ElasticQuotaInfo) deletePodIfPresent(pod *v1.Pod) error {
 
 	e.pods.Delete(key)
 	podRequest := computePodResourceRequest(pod)
+	if sharedDevice, ok := e.sharedDevice[key]; ok {
+		addSharedDeviceRequest(podRequest, sharedDevice)
+		delete(e.sharedDevice, key)
+		if len(e.sharedDevice) == 0 {
+			e.sharedDevice = nil
+		}
+	}
 	e.unreserveResource(*podRequest)
 
 	return nil
 }
 
+// newResource is framework.NewResource accounting the shared device synthetic
+// resources in thousandths of a device, as framework.Resource rounds the
+// scalar resources up to integers.
+func newResource(rl v1.ResourceList) *framework.Resource {
+	r := framework.NewResource(rl)
+	for name, quant := range rl {
+		if util.IsSharedDeviceResource(name) {
+			r.SetScalar(name, quant.MilliValue())
+		}
+	}
+	return r
+}
+
+// addSharedDeviceRequest adds the shared device usage to r, in thousandths of
+// a device like newResource.
+func addSharedDeviceRequest(r *framework.Resource, sharedDevice v1.ResourceList) {
+	for name, quant := range sharedDevice {
+		r.SetScalar(name, r.ScalarResources[name]+quant.MilliValue())
+	}
+}
+
 func cmp(x, y *framework.Resource, bound int64) bool {
 	return cmp2(x, &framework.Resource{}, y, bound)
 }
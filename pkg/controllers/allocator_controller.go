@@ -0,0 +1,287 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling"
+	schedv1alpha1 "sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+)
+
+const (
+	// AllocatorIdleSinceAnnotation is set on an allocator Deployment to when its
+	// device was last seen without client pods.
+	AllocatorIdleSinceAnnotation = scheduling.GroupName + "/allocator-idle-since"
+
+	// sharedDeviceIDField indexes client pods by the device they are bound to.
+	sharedDeviceIDField = "metadata.annotations.sharedDeviceID"
+)
+
+// AllocatorReconciler deletes the allocator Deployments the scheduler creates
+// for shared devices once they aren't needed anymore: when no client pod has
+// been bound to the allocator's device for IdleGracePeriod, or when the
+// allocator pod couldn't start within PendingTimeout. Deleting the allocator
+// releases its device for other workloads.
+type AllocatorReconciler struct {
+	recorder record.EventRecorder
+	clock    clock.PassiveClock
+
+	client.Client
+	Scheme  *runtime.Scheme
+	Workers int
+	// Namespace is the namespace the scheduler creates allocators in.
+	Namespace string
+	// IdleGracePeriod is how long a device may have no client pods before its allocator is deleted.
+	IdleGracePeriod time.Duration
+	// PendingTimeout is how long an allocator pod may be pending before the allocator is deleted.
+	PendingTimeout time.Duration
+}
+
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+func (r *AllocatorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+	log.Info("reconciling")
+	deploy := &appsv1.Deployment{}
+	if err := r.Get(ctx, req.NamespacedName, deploy); err != nil {
+		if apierrs.IsNotFound(err) {
+			log.V(5).Info("allocator not found")
+			return ctrl.Result{}, nil
+		}
+		log.V(3).Error(err, "Unable to retrieve allocator")
+		return ctrl.Result{}, err
+	}
+	if !r.isOwnAllocator(deploy) || deploy.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	podList := &v1.PodList{}
+	if err := r.List(ctx, podList, client.InNamespace(deploy.Namespace), client.MatchingLabels{
+		schedv1alpha1.SharedDeviceRoleLabel:          schedv1alpha1.SharedDeviceAllocatorRole,
+		schedv1alpha1.SharedDeviceAllocatorNameLabel: deploy.Name,
+	}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var running []string
+	// pendingSince is when the oldest pending pod was created, or the
+	// Deployment if it has no pod yet.
+	pendingSince := deploy.CreationTimestamp.Time
+	pending := false
+	for _, pod := range podList.Items {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		switch pod.Status.Phase {
+		case v1.PodRunning:
+			running = append(running, pod.Name)
+		case v1.PodPending:
+			if !pending || pod.CreationTimestamp.Time.Before(pendingSince) {
+				pendingSince = pod.CreationTimestamp.Time
+				pending = true
+			}
+		}
+	}
+
+	now := r.now()
+	if len(running) == 0 {
+		if elapsed := now.Sub(pendingSince); elapsed < r.PendingTimeout {
+			return ctrl.Result{RequeueAfter: r.PendingTimeout - elapsed}, nil
+		}
+		return ctrl.Result{}, r.deleteAllocator(ctx, deploy, "AllocatorPending", "Allocator not running after %v", r.PendingTimeout)
+	}
+
+	busy, err := r.hasClients(ctx, running)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	idleSince, idle := deploy.Annotations[AllocatorIdleSinceAnnotation]
+	if busy {
+		if idle {
+			return ctrl.Result{}, r.setIdleSince(ctx, deploy, nil)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	since, err := time.Parse(time.RFC3339, idleSince)
+	if !idle || err != nil {
+		return ctrl.Result{RequeueAfter: r.IdleGracePeriod}, r.setIdleSince(ctx, deploy, &now)
+	}
+	if elapsed := now.Sub(since); elapsed < r.IdleGracePeriod {
+		return ctrl.Result{RequeueAfter: r.IdleGracePeriod - elapsed}, nil
+	}
+	return ctrl.Result{}, r.deleteAllocator(ctx, deploy, "AllocatorIdle", "Device without client pods for %v", r.IdleGracePeriod)
+}
+
+// hasClients returns whether any live client pod is bound to the devices of the allocator pods.
+func (r *AllocatorReconciler) hasClients(ctx context.Context, allocatorPods []string) (bool, error) {
+	for _, deviceID := range allocatorPods {
+		podList := &v1.PodList{}
+		if err := r.List(ctx, podList, client.MatchingFields{sharedDeviceIDField: deviceID}); err != nil {
+			return false, err
+		}
+		for _, pod := range podList.Items {
+			if pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// setIdleSince records when the allocator became idle, or clears it if since is nil.
+func (r *AllocatorReconciler) setIdleSince(ctx context.Context, deploy *appsv1.Deployment, since *time.Time) error {
+	patch := client.MergeFrom(deploy.DeepCopy())
+	if since == nil {
+		delete(deploy.Annotations, AllocatorIdleSinceAnnotation)
+	} else {
+		if deploy.Annotations == nil {
+			deploy.Annotations = map[string]string{}
+		}
+		deploy.Annotations[AllocatorIdleSinceAnnotation] = since.UTC().Format(time.RFC3339)
+	}
+	return r.Patch(ctx, deploy, patch)
+}
+
+func (r *AllocatorReconciler) deleteAllocator(ctx context.Context, deploy *appsv1.Deployment, reason, messageFmt string, args ...interface{}) error {
+	log.FromContext(ctx).Info("deleting allocator", "reason", reason)
+	if err := r.Delete(ctx, deploy, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrs.IsNotFound(err) {
+		return err
+	}
+	r.recorder.Eventf(deploy, v1.EventTypeNormal, reason, messageFmt, args...)
+	return nil
+}
+
+func (r *AllocatorReconciler) now() time.Time {
+	if r.clock == nil {
+		return time.Now()
+	}
+	return r.clock.Now()
+}
+
+// podToAllocator maps allocator pods to their Deployment, and client pods to
+// the Deployment of the allocator their device belongs to.
+func (r *AllocatorReconciler) podToAllocator(obj client.Object) []reconcile.Request {
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		return nil
+	}
+	switch pod.Labels[schedv1alpha1.SharedDeviceRoleLabel] {
+	case schedv1alpha1.SharedDeviceAllocatorRole:
+		if name := pod.Labels[schedv1alpha1.SharedDeviceAllocatorNameLabel]; name != "" && pod.Namespace == r.Namespace {
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: pod.Namespace, Name: name}}}
+		}
+	case schedv1alpha1.SharedDeviceClientRole:
+		var requests []reconcile.Request
+		for _, deviceID := range sharedDeviceIDs(pod) {
+			allocatorPod := &v1.Pod{}
+			if err := r.Get(context.Background(), types.NamespacedName{Namespace: r.Namespace, Name: deviceID}, allocatorPod); err != nil {
+				continue
+			}
+			if name := allocatorPod.Labels[schedv1alpha1.SharedDeviceAllocatorNameLabel]; name != "" {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: r.Namespace, Name: name}})
+			}
+		}
+		return requests
+	}
+	return nil
+}
+
+// isOwnAllocator returns whether obj is an allocator Deployment of the
+// scheduler, in the allocator namespace.
+func (r *AllocatorReconciler) isOwnAllocator(obj client.Object) bool {
+	return obj.GetNamespace() == r.Namespace && isAllocator(obj)
+}
+
+// isAllocator returns whether obj is an allocator Deployment.
+func isAllocator(obj client.Object) bool {
+	deploy, ok := obj.(*appsv1.Deployment)
+	return ok && deploy.Spec.Template.Labels[schedv1alpha1.SharedDeviceRoleLabel] == schedv1alpha1.SharedDeviceAllocatorRole
+}
+
+// indexSharedDeviceID indexes client pods by their devices.
+func indexSharedDeviceID(obj client.Object) []string {
+	pod, ok := obj.(*v1.Pod)
+	if !ok || pod.Labels[schedv1alpha1.SharedDeviceRoleLabel] != schedv1alpha1.SharedDeviceClientRole {
+		return nil
+	}
+	return sharedDeviceIDs(pod)
+}
+
+// sharedDeviceIDs returns the devices the scheduler bound the pod's shares to.
+func sharedDeviceIDs(pod *v1.Pod) []string {
+	deviceIDs := sets.NewString()
+	for key, value := range pod.Annotations {
+		switch {
+		case key == schedv1alpha1.SharedDeviceIDAnnotation:
+			for _, deviceID := range strings.Split(value, ",") {
+				if deviceID != "" {
+					deviceIDs.Insert(deviceID)
+				}
+			}
+		case strings.HasPrefix(key, schedv1alpha1.SharedDeviceContainerIDAnnotationPrefix) && value != "":
+			deviceIDs.Insert(value)
+		}
+	}
+	return deviceIDs.List()
+}
+
+// AllocatorCacheSelectors restricts the Deployments of the manager cache to
+// the allocator namespace. The manager must not watch Deployments for anything else.
+func AllocatorCacheSelectors(namespace string) cache.SelectorsByObject {
+	return cache.SelectorsByObject{
+		&appsv1.Deployment{}: {Field: fields.OneTermEqualSelector("metadata.namespace", namespace)},
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *AllocatorReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor("AllocatorController")
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &v1.Pod{}, sharedDeviceIDField, indexSharedDeviceID); err != nil {
+		return err
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appsv1.Deployment{}, builder.WithPredicates(predicate.NewPredicateFuncs(r.isOwnAllocator))).
+		Watches(&source.Kind{Type: &v1.Pod{}}, handler.EnqueueRequestsFromMapFunc(r.podToAllocator)).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.Workers}).
+		Complete(r)
+}
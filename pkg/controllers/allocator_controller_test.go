@@ -0,0 +1,253 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	testutil "sigs.k8s.io/scheduler-plugins/test/integration"
+)
+
+func makeAllocator(name string, created time.Time, annotations map[string]string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "default",
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(created),
+			Annotations:       annotations,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					v1alpha1.SharedDeviceRoleLabel:          v1alpha1.SharedDeviceAllocatorRole,
+					v1alpha1.SharedDeviceAllocatorNameLabel: name,
+				}},
+			},
+		},
+	}
+}
+
+func makeAllocatorPod(deployName string, phase v1.PodPhase, created time.Time) *v1.Pod {
+	return testutil.MakePod("default", deployName+"-abcde").Phase(phase).Created(created).
+		Label(v1alpha1.SharedDeviceRoleLabel, v1alpha1.SharedDeviceAllocatorRole).
+		Label(v1alpha1.SharedDeviceAllocatorNameLabel, deployName).Obj()
+}
+
+func makeClientPod(namespace, name, deviceID string, phase v1.PodPhase) *v1.Pod {
+	return testutil.MakePod(namespace, name).Phase(phase).
+		Label(v1alpha1.SharedDeviceRoleLabel, v1alpha1.SharedDeviceClientRole).
+		Annotation(v1alpha1.SharedDeviceIDAnnotation, deviceID).Obj()
+}
+
+func TestAllocatorController_Run(t *testing.T) {
+	ctx := context.TODO()
+	now := time.Now().Truncate(time.Second)
+	idleSince := func(t time.Time) map[string]string {
+		return map[string]string{AllocatorIdleSinceAnnotation: t.UTC().Format(time.RFC3339)}
+	}
+
+	cases := []struct {
+		name            string
+		allocator       *appsv1.Deployment
+		pods            []*v1.Pod
+		wantDeleted     bool
+		wantRequeue     time.Duration
+		wantAnnotations map[string]string
+	}{
+		{
+			name:        "pending allocator within the timeout",
+			allocator:   makeAllocator("alloc", now.Add(-3*time.Minute), nil),
+			pods:        []*v1.Pod{makeAllocatorPod("alloc", v1.PodPending, now.Add(-2*time.Minute))},
+			wantRequeue: 3 * time.Minute,
+		},
+		{
+			name:        "pending allocator past the timeout",
+			allocator:   makeAllocator("alloc", now.Add(-10*time.Minute), nil),
+			pods:        []*v1.Pod{makeAllocatorPod("alloc", v1.PodPending, now.Add(-10*time.Minute))},
+			wantDeleted: true,
+		},
+		{
+			name:        "allocator without pods past the timeout",
+			allocator:   makeAllocator("alloc", now.Add(-10*time.Minute), nil),
+			wantDeleted: true,
+		},
+		{
+			name:      "device in use",
+			allocator: makeAllocator("alloc", now.Add(-time.Hour), idleSince(now.Add(-time.Hour))),
+			pods: []*v1.Pod{
+				makeAllocatorPod("alloc", v1.PodRunning, now.Add(-time.Hour)),
+				makeClientPod("ns1", "client1", "alloc-abcde", v1.PodRunning),
+			},
+		},
+		{
+			name:      "device in use by one of several shares",
+			allocator: makeAllocator("alloc", now.Add(-time.Hour), nil),
+			pods: []*v1.Pod{
+				makeAllocatorPod("alloc", v1.PodRunning, now.Add(-time.Hour)),
+				testutil.MakePod("ns1", "client1").Phase(v1.PodRunning).
+					Label(v1alpha1.SharedDeviceRoleLabel, v1alpha1.SharedDeviceClientRole).
+					Annotation(v1alpha1.SharedDeviceIDAnnotation, "other-device,another-device").
+					Annotation(v1alpha1.SharedDeviceContainerIDAnnotationPrefix+"sidecar", "alloc-abcde").Obj(),
+			},
+		},
+		{
+			name:      "device becomes idle",
+			allocator: makeAllocator("alloc", now.Add(-time.Hour), nil),
+			pods: []*v1.Pod{
+				makeAllocatorPod("alloc", v1.PodRunning, now.Add(-time.Hour)),
+				makeClientPod("ns1", "client1", "alloc-abcde", v1.PodSucceeded),
+				makeClientPod("ns1", "client2", "other-device", v1.PodRunning),
+			},
+			wantRequeue:     10 * time.Minute,
+			wantAnnotations: idleSince(now),
+		},
+		{
+			name:      "device idle within the grace period",
+			allocator: makeAllocator("alloc", now.Add(-time.Hour), idleSince(now.Add(-4*time.Minute))),
+			pods: []*v1.Pod{
+				makeAllocatorPod("alloc", v1.PodRunning, now.Add(-time.Hour)),
+			},
+			wantRequeue:     6 * time.Minute,
+			wantAnnotations: idleSince(now.Add(-4 * time.Minute)),
+		},
+		{
+			name:      "device idle past the grace period",
+			allocator: makeAllocator("alloc", now.Add(-time.Hour), idleSince(now.Add(-10*time.Minute))),
+			pods: []*v1.Pod{
+				makeAllocatorPod("alloc", v1.PodRunning, now.Add(-time.Hour)),
+			},
+			wantDeleted: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			controller, kClient := setUpAllocatorController(c.allocator, c.pods, now)
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "alloc"}}
+			result, err := controller.Reconcile(ctx, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.RequeueAfter != c.wantRequeue {
+				t.Errorf("expected requeue after %v, got %v", c.wantRequeue, result.RequeueAfter)
+			}
+
+			got := &appsv1.Deployment{}
+			err = kClient.Get(ctx, req.NamespacedName, got)
+			if c.wantDeleted {
+				if !apierrs.IsNotFound(err) {
+					t.Errorf("expected the allocator to be deleted, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.wantAnnotations, got.Annotations); diff != "" {
+				t.Errorf("unexpected annotations (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAllocatorController_OtherNamespace(t *testing.T) {
+	ctx := context.TODO()
+	now := time.Now()
+	// Past the pending timeout, but not created by the scheduler.
+	allocator := makeAllocator("alloc", now.Add(-time.Hour), nil)
+	allocator.Namespace = "other"
+	pod := makeAllocatorPod("alloc", v1.PodPending, now.Add(-time.Hour))
+	pod.Namespace = "other"
+	controller, kClient := setUpAllocatorController(allocator, []*v1.Pod{pod}, now)
+
+	if got := controller.podToAllocator(pod); len(got) != 0 {
+		t.Errorf("expected no requests for pod %s, got %v", pod.Name, got)
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "other", Name: "alloc"}}
+	if _, err := controller.Reconcile(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if err := kClient.Get(ctx, req.NamespacedName, &appsv1.Deployment{}); err != nil {
+		t.Errorf("expected the Deployment to be kept, got %v", err)
+	}
+}
+
+func TestAllocatorController_PodToAllocator(t *testing.T) {
+	now := time.Now()
+	controller, _ := setUpAllocatorController(makeAllocator("alloc", now, nil), []*v1.Pod{
+		makeAllocatorPod("alloc", v1.PodRunning, now),
+	}, now)
+	want := []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "alloc"}}}
+
+	for _, pod := range []*v1.Pod{
+		makeAllocatorPod("alloc", v1.PodRunning, now),
+		makeClientPod("ns1", "client1", "alloc-abcde", v1.PodRunning),
+	} {
+		if diff := cmp.Diff(want, controller.podToAllocator(pod)); diff != "" {
+			t.Errorf("unexpected requests for pod %s (-want,+got):\n%s", pod.Name, diff)
+		}
+	}
+	for _, pod := range []*v1.Pod{
+		testutil.MakePod("ns1", "other").Obj(),
+		makeClientPod("ns1", "client2", "", v1.PodPending),
+		makeClientPod("ns1", "client3", "unknown-device", v1.PodRunning),
+	} {
+		if got := controller.podToAllocator(pod); len(got) != 0 {
+			t.Errorf("expected no requests for pod %s, got %v", pod.Name, got)
+		}
+	}
+}
+
+func setUpAllocatorController(allocator *appsv1.Deployment, pods []*v1.Pod, now time.Time) (*AllocatorReconciler, client.Client) {
+	objs := []client.Object{allocator}
+	for _, pod := range pods {
+		objs = append(objs, pod)
+	}
+	kClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(objs...).
+		WithIndex(&v1.Pod{}, sharedDeviceIDField, indexSharedDeviceID).
+		Build()
+
+	controller := &AllocatorReconciler{
+		Client:          kClient,
+		Scheme:          scheme.Scheme,
+		Namespace:       "default",
+		IdleGracePeriod: 10 * time.Minute,
+		PendingTimeout:  5 * time.Minute,
+		recorder:        record.NewFakeRecorder(3),
+		clock:           clocktesting.NewFakeClock(now),
+	}
+	return controller, kClient
+}
@@ -0,0 +1,553 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling"
+	schedv1alpha1 "sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	"sigs.k8s.io/scheduler-plugins/pkg/sharedev"
+	"sigs.k8s.io/scheduler-plugins/pkg/util"
+)
+
+const (
+	// SharedDeviceMovableAnnotation set to "false" keeps a client pod from
+	// being evicted to defragment shared devices.
+	SharedDeviceMovableAnnotation = scheduling.GroupName + "/shared-device-movable"
+
+	// quotaEpsilon absorbs the rounding of the float quotas summed up per device.
+	quotaEpsilon = 1e-9
+)
+
+// FreeSharedDevice is the compute and memory left on a shared device, as
+// fractions of the whole device.
+type FreeSharedDevice struct {
+	DeviceID string
+	Compute  float64
+	Memory   float64
+}
+
+// SharedDeviceInventory reads the shared devices of a node.
+type SharedDeviceInventory interface {
+	// FreeDevices returns the devices of vendor and model on node.
+	FreeDevices(ctx context.Context, node *v1.Node, vendor, model string) ([]FreeSharedDevice, error)
+}
+
+// DeviceManagerInventory reads the shared devices from the device managers,
+// reached the way the ShareDevPlugin of the scheduler reaches them. Unhealthy
+// devices are left out: they take no new clients, and draining them is up to
+// their health, not to defragmentation.
+type DeviceManagerInventory struct {
+	DeviceManagers *sharedev.DeviceManagers
+}
+
+var _ SharedDeviceInventory = &DeviceManagerInventory{}
+
+func (i *DeviceManagerInventory) FreeDevices(ctx context.Context, node *v1.Node, vendor, model string) ([]FreeSharedDevice, error) {
+	free, err := i.DeviceManagers.FreeDevices(ctx, node, vendor, model)
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]FreeSharedDevice, 0, len(free))
+	for _, f := range free {
+		if f.Unhealthy {
+			continue
+		}
+		devices = append(devices, FreeSharedDevice{DeviceID: f.DeviceId, Compute: f.Requests, Memory: f.Memory})
+	}
+	return devices, nil
+}
+
+// SharedDeviceDefragmenter consolidates the clients of shared devices. Every
+// Interval it reads the devices in use from the device managers, plans to drain
+// the devices used below UtilizationThreshold whose clients all fit in the room
+// left on fuller devices, and evicts those clients so that the scheduler packs
+// them again. Drained devices are then idle, and their allocators are deleted
+// by the AllocatorReconciler.
+//
+// Evictions go through the Eviction API, so they respect PodDisruptionBudgets,
+// and a run evicts at most MaxEvictions pods. Only running client pods owned by
+// a controller other than a DaemonSet, not in a PodGroup and not annotated with
+// SharedDeviceMovableAnnotation "false" are moved.
+type SharedDeviceDefragmenter struct {
+	recorder record.EventRecorder
+
+	client.Client
+	// KubeClient evicts the pods.
+	KubeClient kubernetes.Interface
+	Inventory  SharedDeviceInventory
+	// Interval is the time between two runs.
+	Interval time.Duration
+	// UtilizationThreshold is the fraction of a device below which it is drained.
+	UtilizationThreshold float64
+	// MaxEvictions is how many pods a run evicts at most.
+	MaxEvictions int
+	// DryRun only logs the plan.
+	DryRun bool
+}
+
+// defragClient is a client pod and its shares.
+type defragClient struct {
+	pod *v1.Pod
+	// shares are by device, the devices being on the node of the pod.
+	shares []defragShare
+	// movable tells whether the pod may be evicted.
+	movable bool
+}
+
+type defragShare struct {
+	clientID string
+	deviceID string
+	compute  float64
+	memory   float64
+}
+
+type defragDevice struct {
+	node    string
+	id      string
+	compute float64
+	memory  float64
+	clients []*defragClient
+	// pinned devices hold quota of clients that can't be moved or are unknown.
+	pinned bool
+}
+
+func (d *defragDevice) utilization() float64 {
+	used := 1 - d.compute
+	if mem := 1 - d.memory; mem > used {
+		used = mem
+	}
+	return used
+}
+
+// defragMove moves pod off a drained device, to room found on node.
+type defragMove struct {
+	pod  *v1.Pod
+	node string
+}
+
+// defragStep drains a device.
+type defragStep struct {
+	device *defragDevice
+	moves  []defragMove
+}
+
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=pods/eviction,verbs=create
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=scheduling.x-k8s.io,resources=shareddeviceclaims,verbs=get;list;watch
+func (r *SharedDeviceDefragmenter) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, r.run, r.Interval)
+	return nil
+}
+
+func (r *SharedDeviceDefragmenter) run(ctx context.Context) {
+	log := log.FromContext(ctx)
+	steps, err := r.plan(ctx)
+	if err != nil {
+		log.Error(err, "Unable to plan the defragmentation of shared devices")
+		return
+	}
+	for _, step := range steps {
+		for _, move := range step.moves {
+			log.Info("Planned to move a shared device client", "pod", klog.KObj(move.pod), "node", step.device.node,
+				"device", step.device.id, "utilization", step.device.utilization(), "targetNode", move.node, "dryRun", r.DryRun)
+		}
+	}
+	if r.DryRun {
+		return
+	}
+	for _, step := range steps {
+		for _, move := range step.moves {
+			if err := r.evict(ctx, move.pod); err != nil {
+				// The device stays in use, don't disrupt its other clients.
+				log.Info("Unable to evict shared device client, skipping the device", "pod", klog.KObj(move.pod),
+					"device", step.device.id, "err", err)
+				break
+			}
+			r.recorder.Eventf(move.pod, v1.EventTypeNormal, "SharedDeviceDefragmentation",
+				"Evicted to consolidate shared device %s on node %s", step.device.id, step.device.node)
+		}
+	}
+}
+
+func (r *SharedDeviceDefragmenter) evict(ctx context.Context, pod *v1.Pod) error {
+	err := r.KubeClient.PolicyV1().Evictions(pod.Namespace).Evict(ctx, &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{Namespace: pod.Namespace, Name: pod.Name},
+	})
+	if apierrs.IsNotFound(err) {
+		return nil
+	}
+	if apierrs.IsTooManyRequests(err) {
+		return fmt.Errorf("blocked by a PodDisruptionBudget: %w", err)
+	}
+	return err
+}
+
+// plan returns the devices to drain and where their clients fit. Devices are
+// drained least used first, as long as the run's eviction budget allows
+// moving all of their clients.
+func (r *SharedDeviceDefragmenter) plan(ctx context.Context) ([]defragStep, error) {
+	devices, nodes, err := r.devices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []*defragDevice
+	for _, d := range devices {
+		if u := d.utilization(); !d.pinned && u > quotaEpsilon && u < r.UtilizationThreshold {
+			sources = append(sources, d)
+		}
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		if ui, uj := sources[i].utilization(), sources[j].utilization(); ui != uj {
+			return ui < uj
+		}
+		return deviceKey(sources[i].node, sources[i].id) < deviceKey(sources[j].node, sources[j].id)
+	})
+
+	// free is the room left on the devices clients may move to, by node. Only
+	// devices in use are filled, moving clients to idle devices wouldn't free any.
+	free := map[string]map[string]*FreeSharedDevice{}
+	for _, d := range devices {
+		if node := nodes[d.node]; node == nil || node.Spec.Unschedulable || d.utilization() <= quotaEpsilon {
+			continue
+		}
+		if free[d.node] == nil {
+			free[d.node] = map[string]*FreeSharedDevice{}
+		}
+		free[d.node][d.id] = &FreeSharedDevice{DeviceID: d.id, Compute: d.compute, Memory: d.memory}
+	}
+
+	var steps []defragStep
+	evictions := 0
+	moved := map[*defragClient]bool{}
+	// filled are the devices receiving clients, they aren't drained.
+	filled := map[string]bool{}
+	for _, d := range sources {
+		key := deviceKey(d.node, d.id)
+		if filled[key] {
+			continue
+		}
+		var clients []*defragClient
+		for _, c := range d.clients {
+			if !moved[c] {
+				clients = append(clients, c)
+			}
+		}
+		if evictions+len(clients) > r.MaxEvictions {
+			continue
+		}
+
+		// Plan on a copy, kept only if every client fits.
+		left := copyFree(free)
+		delete(left[d.node], d.id)
+		step := defragStep{device: d}
+		var targets []string
+		for _, c := range clients {
+			node, deviceIDs := placeClient(c, left)
+			if node == "" {
+				step.moves = nil
+				break
+			}
+			step.moves = append(step.moves, defragMove{pod: c.pod, node: node})
+			for _, id := range deviceIDs {
+				targets = append(targets, deviceKey(node, id))
+			}
+		}
+		if len(step.moves) != len(clients) {
+			continue
+		}
+
+		free = left
+		for _, c := range clients {
+			moved[c] = true
+		}
+		for _, t := range targets {
+			filled[t] = true
+		}
+		evictions += len(clients)
+		if len(step.moves) > 0 {
+			steps = append(steps, step)
+		}
+	}
+	return steps, nil
+}
+
+// devices returns the devices the client pods are bound to, and their nodes.
+func (r *SharedDeviceDefragmenter) devices(ctx context.Context) ([]*defragDevice, map[string]*v1.Node, error) {
+	podList := &v1.PodList{}
+	if err := r.List(ctx, podList, client.MatchingLabels{schedv1alpha1.SharedDeviceRoleLabel: schedv1alpha1.SharedDeviceClientRole}); err != nil {
+		return nil, nil, err
+	}
+
+	type nodeModel struct {
+		node, vendor, model string
+	}
+	clients := map[nodeModel][]*defragClient{}
+	// pinned are the devices of clients whose shares can't be read.
+	pinned := map[string]bool{}
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Spec.NodeName == "" || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		claim := &schedv1alpha1.SharedDeviceClaim{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: pod.Labels[schedv1alpha1.SharedDeviceClaimLabel]}, claim)
+		var c *defragClient
+		var vendor, model string
+		if err == nil {
+			c, vendor, model = clientShares(pod, claim)
+		}
+		if c == nil {
+			for _, id := range sharedDeviceIDs(pod) {
+				pinned[deviceKey(pod.Spec.NodeName, id)] = true
+			}
+			continue
+		}
+		key := nodeModel{node: pod.Spec.NodeName, vendor: vendor, model: model}
+		clients[key] = append(clients[key], c)
+	}
+
+	keys := make([]nodeModel, 0, len(clients))
+	for key := range clients {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].node+"/"+keys[i].vendor+"/"+keys[i].model < keys[j].node+"/"+keys[j].vendor+"/"+keys[j].model
+	})
+
+	var devices []*defragDevice
+	nodes := map[string]*v1.Node{}
+	for _, key := range keys {
+		node, ok := nodes[key.node]
+		if !ok {
+			node = &v1.Node{}
+			if err := r.Get(ctx, types.NamespacedName{Name: key.node}, node); err != nil {
+				node = nil
+			}
+			nodes[key.node] = node
+		}
+		if node == nil {
+			continue
+		}
+		free, err := r.Inventory.FreeDevices(ctx, node, key.vendor, key.model)
+		if err != nil {
+			log.FromContext(ctx).Info("Unable to read the shared devices of a node, leaving it out", "node", key.node, "err", err)
+			continue
+		}
+
+		byID := map[string]*defragDevice{}
+		for _, f := range free {
+			d := &defragDevice{node: key.node, id: f.DeviceID, compute: f.Compute, memory: f.Memory}
+			d.pinned = pinned[deviceKey(key.node, f.DeviceID)]
+			byID[f.DeviceID] = d
+			devices = append(devices, d)
+		}
+		// known is the quota of the clients on every device.
+		known := map[string][2]float64{}
+		for _, c := range clients[key] {
+			attached := map[string]bool{}
+			for _, s := range c.shares {
+				d, ok := byID[s.deviceID]
+				if !ok {
+					continue
+				}
+				q := known[s.deviceID]
+				known[s.deviceID] = [2]float64{q[0] + s.compute, q[1] + s.memory}
+				if !attached[s.deviceID] {
+					d.clients = append(d.clients, c)
+					attached[s.deviceID] = true
+				}
+				if !c.movable {
+					d.pinned = true
+				}
+			}
+		}
+		// Quota reserved by no known client, e.g. by a pod being scheduled,
+		// would stay on the device.
+		for id, d := range byID {
+			if 1-d.compute > known[id][0]+quotaEpsilon || 1-d.memory > known[id][1]+quotaEpsilon {
+				d.pinned = true
+			}
+		}
+	}
+	return devices, nodes, nil
+}
+
+// clientShares returns the shares of pod on the devices it is bound to and
+// their vendor and model, or nil if they don't match its claim.
+func clientShares(pod *v1.Pod, claim *schedv1alpha1.SharedDeviceClaim) (*defragClient, string, string) {
+	podQ, deviceIDs, err := sharedev.BoundShares(pod, claim)
+	if err != nil {
+		return nil, "", ""
+	}
+	c := &defragClient{pod: pod, movable: isMovable(pod)}
+	for i, share := range podQ.Shares {
+		if deviceIDs[i] == "" {
+			return nil, "", ""
+		}
+		c.shares = append(c.shares, defragShare{
+			clientID: share.ClientId,
+			deviceID: deviceIDs[i],
+			compute:  share.Requests,
+			memory:   share.Memory,
+		})
+	}
+	return c, podQ.Vendor, podQ.Model
+}
+
+// isMovable tells whether pod may be evicted to be scheduled again.
+func isMovable(pod *v1.Pod) bool {
+	if pod.DeletionTimestamp != nil || pod.Status.Phase != v1.PodRunning ||
+		pod.Annotations[SharedDeviceMovableAnnotation] == "false" || util.GetPodGroupLabel(pod) != "" {
+		return false
+	}
+	owner := metav1.GetControllerOf(pod)
+	// Nothing recreates bare pods, and DaemonSet pods come back on the same node.
+	return owner != nil && owner.Kind != "DaemonSet"
+}
+
+// placeClient finds the node whose free devices host all shares of c the
+// tightest, and takes the shares from free. It returns the node and devices
+// used, or "" if no node fits.
+func placeClient(c *defragClient, free map[string]map[string]*FreeSharedDevice) (string, []string) {
+	own := map[string]bool{}
+	for _, s := range c.shares {
+		own[deviceKey(c.pod.Spec.NodeName, s.deviceID)] = true
+	}
+	shares := make([]defragShare, len(c.shares))
+	copy(shares, c.shares)
+	sort.SliceStable(shares, func(i, j int) bool {
+		return shares[i].compute+shares[i].memory > shares[j].compute+shares[j].memory
+	})
+
+	nodeNames := make([]string, 0, len(free))
+	for name := range free {
+		nodeNames = append(nodeNames, name)
+	}
+	sort.Strings(nodeNames)
+
+	bestNode, bestLeft := "", 0.0
+	var bestIDs []string
+	for _, name := range nodeNames {
+		left := map[string]FreeSharedDevice{}
+		for id, f := range free[name] {
+			if !own[deviceKey(name, id)] {
+				left[id] = *f
+			}
+		}
+		ids, ok := fitShares(shares, left)
+		if !ok {
+			continue
+		}
+		room := 0.0
+		for _, id := range ids {
+			room += left[id].Compute + left[id].Memory
+		}
+		if bestNode == "" || room < bestLeft {
+			bestNode, bestLeft, bestIDs = name, room, ids
+		}
+	}
+	if bestNode == "" {
+		return "", nil
+	}
+	for i, id := range bestIDs {
+		free[bestNode][id].Compute -= shares[i].compute
+		free[bestNode][id].Memory -= shares[i].memory
+	}
+	return bestNode, bestIDs
+}
+
+// fitShares puts every share on the fullest device it fits, never two shares
+// of a client on the same device, and takes them from free. It returns the
+// device of every share.
+func fitShares(shares []defragShare, free map[string]FreeSharedDevice) ([]string, bool) {
+	ids := make([]string, 0, len(free))
+	for id := range free {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	used := map[string]map[string]bool{}
+	deviceIDs := make([]string, len(shares))
+	for i, s := range shares {
+		best := ""
+		for _, id := range ids {
+			f := free[id]
+			if used[s.clientID][id] || f.Compute+quotaEpsilon < s.compute || f.Memory+quotaEpsilon < s.memory {
+				continue
+			}
+			if best == "" || f.Compute+f.Memory < free[best].Compute+free[best].Memory {
+				best = id
+			}
+		}
+		if best == "" {
+			return nil, false
+		}
+		f := free[best]
+		f.Compute -= s.compute
+		f.Memory -= s.memory
+		free[best] = f
+		if used[s.clientID] == nil {
+			used[s.clientID] = map[string]bool{}
+		}
+		used[s.clientID][best] = true
+		deviceIDs[i] = best
+	}
+	return deviceIDs, true
+}
+
+func copyFree(free map[string]map[string]*FreeSharedDevice) map[string]map[string]*FreeSharedDevice {
+	out := make(map[string]map[string]*FreeSharedDevice, len(free))
+	for node, devices := range free {
+		out[node] = make(map[string]*FreeSharedDevice, len(devices))
+		for id, f := range devices {
+			c := *f
+			out[node][id] = &c
+		}
+	}
+	return out
+}
+
+func deviceKey(node, deviceID string) string {
+	return node + "/" + deviceID
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SharedDeviceDefragmenter) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor("SharedDeviceDefragmenter")
+	return mgr.Add(r)
+}
@@ -0,0 +1,290 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"sigs.k8s.io/scheduler-plugins/apis/config"
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	"sigs.k8s.io/scheduler-plugins/pkg/sharedev"
+	testutil "sigs.k8s.io/scheduler-plugins/test/integration"
+	dmtest "sigs.k8s.io/scheduler-plugins/test/util"
+)
+
+func makeDefragNode(name, ip string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     v1.NodeStatus{Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: ip}}},
+	}
+}
+
+func makeShareClaim(name, share string) *v1alpha1.SharedDeviceClaim {
+	return &v1alpha1.SharedDeviceClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+		Spec: v1alpha1.SharedDeviceClaimSpec{
+			Vendor:  "example.com",
+			Model:   "mydev",
+			Compute: resource.MustParse(share),
+			Memory:  resource.MustParse(share),
+		},
+	}
+}
+
+// makeSlowClaim returns a claim of an eighth of an older model, or a quarter of
+// a mydev device.
+func makeSlowClaim() *v1alpha1.SharedDeviceClaim {
+	claim := makeShareClaim("slow", "125m")
+	claim.Spec.Model = "olddev"
+	claim.Spec.Memory = resource.MustParse("250m")
+	scale := resource.MustParse("2")
+	claim.Spec.Fallbacks = []v1alpha1.SharedDeviceModel{{Vendor: "example.com", Model: "mydev", ComputeScale: &scale}}
+	return claim
+}
+
+// makeMovableClient returns a running client pod of a ReplicaSet, bound to deviceID on node.
+func makeMovableClient(name, claim, node, deviceID string) *v1.Pod {
+	pod := testutil.MakePod("ns", name).Phase(v1.PodRunning).Node(node).
+		Label(v1alpha1.SharedDeviceRoleLabel, v1alpha1.SharedDeviceClientRole).
+		Label(v1alpha1.SharedDeviceClaimLabel, claim).
+		Annotation(v1alpha1.SharedDeviceIDAnnotation, deviceID).Obj()
+	pod.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: name + "-rs", Controller: pointer.Bool(true)}}
+	return pod
+}
+
+func TestSharedDeviceDefragmenter_Run(t *testing.T) {
+	cases := []struct {
+		name string
+		// modify changes the pods and device managers of the base layout:
+		// dev1 of node1 used 75% by a and b, dev2 of node1 used 25% by c,
+		// dev3 of node2 used 25% by d and dev4 of node2 unused.
+		modify       func(pods map[string]*v1.Pod, fakes map[string]*dmtest.FakeDeviceManager)
+		cordoned     bool
+		maxEvictions int
+		dryRun       bool
+		blocked      string
+		wantEvicted  []string
+		wantEvents   []string
+	}{
+		{
+			name:         "moves the clients of the least used device to the fullest one",
+			maxEvictions: 5,
+			wantEvicted:  []string{"c"},
+			wantEvents:   []string{"Normal SharedDeviceDefragmentation Evicted to consolidate shared device dev2 on node node1"},
+		},
+		{
+			name:         "dry run",
+			maxEvictions: 5,
+			dryRun:       true,
+		},
+		{
+			name: "clients opting out pin their device",
+			modify: func(pods map[string]*v1.Pod, _ map[string]*dmtest.FakeDeviceManager) {
+				pods["c"].Annotations[SharedDeviceMovableAnnotation] = "false"
+			},
+			maxEvictions: 5,
+			wantEvicted:  []string{"d"},
+			wantEvents:   []string{"Normal SharedDeviceDefragmentation Evicted to consolidate shared device dev3 on node node2"},
+		},
+		{
+			name: "bare pods pin their device",
+			modify: func(pods map[string]*v1.Pod, _ map[string]*dmtest.FakeDeviceManager) {
+				pods["c"].OwnerReferences = nil
+			},
+			maxEvictions: 5,
+			wantEvicted:  []string{"d"},
+			wantEvents:   []string{"Normal SharedDeviceDefragmentation Evicted to consolidate shared device dev3 on node node2"},
+		},
+		{
+			name: "PodGroup members pin their device",
+			modify: func(pods map[string]*v1.Pod, _ map[string]*dmtest.FakeDeviceManager) {
+				pods["c"].Labels[v1alpha1.PodGroupLabel] = "pg1"
+			},
+			maxEvictions: 5,
+			wantEvicted:  []string{"d"},
+			wantEvents:   []string{"Normal SharedDeviceDefragmentation Evicted to consolidate shared device dev3 on node node2"},
+		},
+		{
+			name: "quota of unknown clients pins the device",
+			modify: func(_ map[string]*v1.Pod, fakes map[string]*dmtest.FakeDeviceManager) {
+				fakes["10.0.0.1"].Reserve("dev2", "ns/scheduling/uid", 0.1, 0.1)
+			},
+			maxEvictions: 5,
+			wantEvicted:  []string{"d"},
+			wantEvents:   []string{"Normal SharedDeviceDefragmentation Evicted to consolidate shared device dev3 on node node2"},
+		},
+		{
+			name: "clients on a fallback model count its scaled shares",
+			modify: func(pods map[string]*v1.Pod, _ map[string]*dmtest.FakeDeviceManager) {
+				pods["c"].Labels[v1alpha1.SharedDeviceClaimLabel] = "slow"
+				pods["c"].Annotations[v1alpha1.SharedDeviceModelAnnotation] = "example.com/mydev"
+			},
+			maxEvictions: 5,
+			wantEvicted:  []string{"c"},
+			wantEvents:   []string{"Normal SharedDeviceDefragmentation Evicted to consolidate shared device dev2 on node node1"},
+		},
+		{
+			name: "clients on a model their claim doesn't accept pin their device",
+			modify: func(pods map[string]*v1.Pod, _ map[string]*dmtest.FakeDeviceManager) {
+				pods["c"].Annotations[v1alpha1.SharedDeviceModelAnnotation] = "example.com/gone"
+			},
+			maxEvictions: 5,
+			wantEvicted:  []string{"d"},
+			wantEvents:   []string{"Normal SharedDeviceDefragmentation Evicted to consolidate shared device dev3 on node node2"},
+		},
+		{
+			name: "unhealthy devices don't receive clients",
+			modify: func(_ map[string]*v1.Pod, fakes map[string]*dmtest.FakeDeviceManager) {
+				// c would move to dev1, there is no room left on dev3.
+				fakes["10.0.0.1"].SetUnhealthy("dev1", "ECC errors")
+				fakes["10.0.0.2"].Reserve("dev3", "ns/e/e-uid", 0.6, 0.6)
+			},
+			maxEvictions: 5,
+		},
+		{
+			name: "devices receiving clients aren't drained",
+			modify: func(pods map[string]*v1.Pod, fakes map[string]*dmtest.FakeDeviceManager) {
+				// dev1 is full.
+				fakes["10.0.0.1"].Reserve("dev1", "ns/e/e-uid", 0.25, 0.25)
+				pods["e"] = makeMovableClient("e", "quarter", "node1", "dev1")
+			},
+			maxEvictions: 5,
+			// c moves to dev3, which then keeps d.
+			wantEvicted: []string{"c"},
+			wantEvents:  []string{"Normal SharedDeviceDefragmentation Evicted to consolidate shared device dev2 on node node1"},
+		},
+		{
+			name: "cordoned nodes don't receive clients",
+			modify: func(pods map[string]*v1.Pod, _ map[string]*dmtest.FakeDeviceManager) {
+				pods["c"].Annotations[SharedDeviceMovableAnnotation] = "false"
+			},
+			// d would move to dev1 if node1 weren't cordoned.
+			cordoned:     true,
+			maxEvictions: 5,
+		},
+		{
+			name:         "the eviction budget is respected",
+			maxEvictions: 0,
+		},
+		{
+			name:         "PodDisruptionBudgets block evictions",
+			maxEvictions: 5,
+			blocked:      "c",
+			wantEvicted:  []string{"c"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.TODO()
+			fakes := map[string]*dmtest.FakeDeviceManager{
+				"10.0.0.1": dmtest.NewFakeDeviceManager().AddDevice("example.com", "mydev", "dev1").AddDevice("example.com", "mydev", "dev2").
+					Reserve("dev1", "ns/a/a-uid", 0.5, 0.5).Reserve("dev1", "ns/b/b-uid", 0.25, 0.25).Reserve("dev2", "ns/c/c-uid", 0.25, 0.25),
+				"10.0.0.2": dmtest.NewFakeDeviceManager().AddDevice("example.com", "mydev", "dev3").AddDevice("example.com", "mydev", "dev4").
+					Reserve("dev3", "ns/d/d-uid", 0.25, 0.25),
+			}
+			pods := map[string]*v1.Pod{
+				"a": makeMovableClient("a", "half", "node1", "dev1"),
+				"b": makeMovableClient("b", "quarter", "node1", "dev1"),
+				"c": makeMovableClient("c", "quarter", "node1", "dev2"),
+				"d": makeMovableClient("d", "quarter", "node2", "dev3"),
+			}
+			if tc.modify != nil {
+				tc.modify(pods, fakes)
+			}
+			dialer := dmtest.ServeFakeDeviceManagers(t, fakes)
+
+			s := runtime.NewScheme()
+			utilruntime.Must(clientgoscheme.AddToScheme(s))
+			utilruntime.Must(v1alpha1.AddToScheme(s))
+			objs := []runtime.Object{
+				makeDefragNode("node1", "10.0.0.1"),
+				makeDefragNode("node2", "10.0.0.2"),
+				makeShareClaim("half", "500m"),
+				makeShareClaim("quarter", "250m"),
+				makeSlowClaim(),
+			}
+			objs[0].(*v1.Node).Spec.Unschedulable = tc.cordoned
+			for _, pod := range pods {
+				objs = append(objs, pod)
+			}
+			kClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objs...).Build()
+
+			var evicted []string
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() != "eviction" {
+					return false, nil, nil
+				}
+				name := action.(k8stesting.CreateAction).GetObject().(*policyv1.Eviction).Name
+				evicted = append(evicted, name)
+				if name == tc.blocked {
+					return true, nil, apierrs.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+				}
+				return true, nil, nil
+			})
+
+			deviceManagers, err := sharedev.NewDeviceManagers(&config.ShareDevPluginArgs{
+				DeviceManagerPort:                 50051,
+				GetAvailableDevicesTimeoutSeconds: 10,
+				DeviceManagerTLS:                  config.DeviceManagerTLS{Insecure: true},
+			}, nil, dialer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := record.NewFakeRecorder(10)
+			r := &SharedDeviceDefragmenter{
+				recorder:             recorder,
+				Client:               kClient,
+				KubeClient:           kubeClient,
+				Inventory:            &DeviceManagerInventory{DeviceManagers: deviceManagers},
+				UtilizationThreshold: 0.5,
+				MaxEvictions:         tc.maxEvictions,
+				DryRun:               tc.dryRun,
+			}
+			r.run(ctx)
+
+			if diff := cmp.Diff(tc.wantEvicted, evicted); diff != "" {
+				t.Errorf("unexpected evictions (-want,+got):\n%s", diff)
+			}
+			close(recorder.Events)
+			var events []string
+			for e := range recorder.Events {
+				events = append(events, e)
+			}
+			if diff := cmp.Diff(tc.wantEvents, events); diff != "" {
+				t.Errorf("unexpected events (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -25,6 +25,7 @@ import (
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	quota "k8s.io/apiserver/pkg/quota/v1"
 	"k8s.io/client-go/tools/record"
 
@@ -36,6 +37,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	schedv1alpha1 "sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	"sigs.k8s.io/scheduler-plugins/pkg/util"
 )
 
 type ElasticQuotaReconciler struct {
@@ -105,11 +107,34 @@ func (r *ElasticQuotaReconciler) computeElasticQuotaUsed(ctx context.Context, na
 	for _, p := range podList.Items {
 		if p.Status.Phase == v1.PodRunning {
 			used = quota.Add(used, computePodResourceRequest(&p))
+			sharedDevice, err := r.getSharedDeviceRequest(ctx, &p)
+			if err != nil {
+				return nil, err
+			}
+			used = quota.Add(used, sharedDevice)
 		}
 	}
 	return used, nil
 }
 
+// getSharedDeviceRequest returns the shares of devices the SharedDeviceClaim
+// of pod requests, as synthetic resources. Pods without a claim, or whose
+// claim is gone, use none.
+func (r *ElasticQuotaReconciler) getSharedDeviceRequest(ctx context.Context, pod *v1.Pod) (v1.ResourceList, error) {
+	claimName := pod.Labels[schedv1alpha1.SharedDeviceClaimLabel]
+	if claimName == "" {
+		return nil, nil
+	}
+	claim := &schedv1alpha1.SharedDeviceClaim{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: claimName}, claim); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return util.GetSharedDeviceRequest(claim), nil
+}
+
 // computePodResourceRequest returns a v1.ResourceList that covers the largest
 // width in each resource dimension. Because init-containers run sequentially, we collect
 // the max in each dimension iteratively. In contrast, we sum the resource vectors for
@@ -25,6 +25,7 @@ import (
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -38,6 +39,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	"sigs.k8s.io/scheduler-plugins/pkg/util"
 	testutil "sigs.k8s.io/scheduler-plugins/test/integration"
 )
 
@@ -46,6 +48,7 @@ func TestElasticQuotaController_Run(t *testing.T) {
 	cases := []struct {
 		name          string
 		elasticQuotas []*v1alpha1.ElasticQuota
+		claims        []*v1alpha1.SharedDeviceClaim
 		pods          []*v1.Pod
 		want          []*v1alpha1.ElasticQuota
 	}{
@@ -181,11 +184,38 @@ func TestElasticQuotaController_Run(t *testing.T) {
 					Used(testutil.MakeResourceList().CPU(0).Mem(0).GPU(0).Obj()).Obj(),
 			},
 		},
+		{
+			name: "shared device clients",
+			elasticQuotas: []*v1alpha1.ElasticQuota{
+				testutil.MakeEQ("t7-ns1", "t7-eq1").
+					Min(withSharedDevice(testutil.MakeResourceList().CPU(3).Obj(), "1", "1")).
+					Max(withSharedDevice(testutil.MakeResourceList().CPU(5).Obj(), "2", "2")).Obj(),
+			},
+			claims: []*v1alpha1.SharedDeviceClaim{
+				makeSharedDeviceClaim("t7-ns1", "half", "500m", 1),
+				makeSharedDeviceClaim("t7-ns1", "quarter-twice", "250m", 2),
+			},
+			pods: []*v1.Pod{
+				testutil.MakePod("t7-ns1", "pod1").Phase(v1.PodRunning).Label(v1alpha1.SharedDeviceClaimLabel, "half").
+					Container(testutil.MakeResourceList().CPU(1).Obj()).Obj(),
+				testutil.MakePod("t7-ns1", "pod2").Phase(v1.PodRunning).Label(v1alpha1.SharedDeviceClaimLabel, "quarter-twice").
+					Container(testutil.MakeResourceList().CPU(1).Obj()).Obj(),
+				testutil.MakePod("t7-ns1", "pod3").Phase(v1.PodPending).Label(v1alpha1.SharedDeviceClaimLabel, "half").
+					Container(testutil.MakeResourceList().CPU(1).Obj()).Obj(),
+				// Its claim is gone.
+				testutil.MakePod("t7-ns1", "pod4").Phase(v1.PodRunning).Label(v1alpha1.SharedDeviceClaimLabel, "deleted").
+					Container(testutil.MakeResourceList().CPU(1).Obj()).Obj(),
+			},
+			want: []*v1alpha1.ElasticQuota{
+				testutil.MakeEQ("t7-ns1", "t7-eq1").
+					Used(withSharedDevice(testutil.MakeResourceList().CPU(3).Obj(), "1", "750m")).Obj(),
+			},
+		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			controller, kClient := setUpEQ(ctx, t, c.elasticQuotas, c.pods)
+			controller, kClient := setUpEQ(ctx, t, c.elasticQuotas, c.claims, c.pods)
 			for _, pod := range c.pods {
 				if _, err := controller.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{
 					Namespace: pod.Namespace,
@@ -233,6 +263,7 @@ func TestElasticQuotaController_Run(t *testing.T) {
 func setUpEQ(ctx context.Context,
 	t *testing.T,
 	eqs []*v1alpha1.ElasticQuota,
+	claims []*v1alpha1.SharedDeviceClaim,
 	pods []*v1.Pod) (*ElasticQuotaReconciler, client.WithWatch) {
 	s := scheme.Scheme
 	utilruntime.Must(v1alpha1.AddToScheme(s))
@@ -247,6 +278,11 @@ func setUpEQ(ctx context.Context,
 			t.Fatal("setup controller", err)
 		}
 	}
+	for _, claim := range claims {
+		if err := client.Create(ctx, claim); err != nil {
+			t.Fatal("setup controller", err)
+		}
+	}
 	for _, pod := range pods {
 		err := client.Create(ctx, pod)
 		if errors.IsAlreadyExists(err) {
@@ -264,3 +300,23 @@ func setUpEQ(ctx context.Context,
 
 	return controller, client
 }
+
+// withSharedDevice adds the example.com/mydev shared device resources to rl.
+func withSharedDevice(rl v1.ResourceList, compute, memory string) v1.ResourceList {
+	rl[util.SharedDeviceComputeResource("example.com", "mydev")] = resource.MustParse(compute)
+	rl[util.SharedDeviceMemoryResource("example.com", "mydev")] = resource.MustParse(memory)
+	return rl
+}
+
+func makeSharedDeviceClaim(namespace, name, compute string, count int32) *v1alpha1.SharedDeviceClaim {
+	return &v1alpha1.SharedDeviceClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: v1alpha1.SharedDeviceClaimSpec{
+			Vendor:  "example.com",
+			Model:   "mydev",
+			Compute: resource.MustParse(compute),
+			Memory:  resource.MustParse("250m"),
+			Count:   &count,
+		},
+	}
+}
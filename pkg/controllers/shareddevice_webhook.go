@@ -0,0 +1,180 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	schedv1alpha1 "sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+)
+
+// SharedDevicePodWebhookPath is the path the shared device pod webhook is served on.
+const SharedDevicePodWebhookPath = "/mutate-v1-pod-shared-device"
+
+// SharedDevicePodDefaulter injects the env vars a shared device client needs
+// into pods referencing a SharedDeviceClaim. The device is only known once the
+// scheduler has reserved it, so the env vars are resolved by the kubelet from
+// the annotations the scheduler sets before binding.
+//
+// Containers with a share of their own in the claim get that share's client ID
+// and device instead of the pod's.
+type SharedDevicePodDefaulter struct {
+	// Client reads the claims. Without it, all containers use the pod's shares.
+	Client client.Reader
+}
+
+var _ admission.CustomDefaulter = &SharedDevicePodDefaulter{}
+
+// SetupWebhookWithManager registers the webhook with the manager's webhook server.
+func (d *SharedDevicePodDefaulter) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	if d.Client == nil {
+		d.Client = mgr.GetClient()
+	}
+	mgr.GetWebhookServer().Register(SharedDevicePodWebhookPath, admission.WithCustomDefaulter(&v1.Pod{}, d))
+	return nil
+}
+
+// Default implements admission.CustomDefaulter.
+func (d *SharedDevicePodDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		return fmt.Errorf("expected a Pod but got a %T", obj)
+	}
+	claimName := pod.Labels[schedv1alpha1.SharedDeviceClaimLabel]
+	if claimName == "" {
+		return nil
+	}
+
+	containerShares, err := d.containerShares(ctx, pod, claimName)
+	if err != nil {
+		return err
+	}
+
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+		env := sharedDeviceEnv()
+		if containerShares.Has(c.Name) {
+			env = sharedDeviceContainerEnv(c.Name)
+		}
+		for _, e := range env {
+			if !hasEnv(c, e.Name) {
+				c.Env = append(c.Env, e)
+			}
+		}
+	}
+	return nil
+}
+
+// sharedDeviceEnv returns the env vars of the containers using the pod's shares.
+// The client ID is expanded by the kubelet from the pod's namespace, name and
+// UID, the latter only being known once the pod is created.
+func sharedDeviceEnv() []v1.EnvVar {
+	return append(podIdentityEnv(),
+		v1.EnvVar{
+			Name:  "CLIENT_ID",
+			Value: sharedDeviceClientID,
+		},
+		fieldRefEnv("DEVICE_ID", annotationFieldPath(schedv1alpha1.SharedDeviceIDAnnotation)),
+		fieldRefEnv("HOST_IP", annotationFieldPath(schedv1alpha1.SharedDeviceHostIPAnnotation)),
+	)
+}
+
+// sharedDeviceContainerEnv returns the env vars of a container with a share of
+// its own.
+func sharedDeviceContainerEnv(container string) []v1.EnvVar {
+	return append(podIdentityEnv(),
+		v1.EnvVar{
+			Name:  "CLIENT_ID",
+			Value: sharedDeviceClientID + "." + container,
+		},
+		fieldRefEnv("DEVICE_ID", annotationFieldPath(schedv1alpha1.SharedDeviceContainerIDAnnotationPrefix+container)),
+		fieldRefEnv("HOST_IP", annotationFieldPath(schedv1alpha1.SharedDeviceHostIPAnnotation)),
+	)
+}
+
+// sharedDeviceClientID is the "<namespace>/<name>/<uid>" client ID of the pod
+// the scheduler reserves the shares under.
+const sharedDeviceClientID = "$(SHAREDEV_POD_NAMESPACE)/$(SHAREDEV_POD_NAME)/$(SHAREDEV_POD_UID)"
+
+// podIdentityEnv returns the env vars sharedDeviceClientID is expanded from.
+// They must come before CLIENT_ID for the kubelet to expand them.
+func podIdentityEnv() []v1.EnvVar {
+	return []v1.EnvVar{
+		fieldRefEnv("SHAREDEV_POD_NAMESPACE", "metadata.namespace"),
+		fieldRefEnv("SHAREDEV_POD_NAME", "metadata.name"),
+		fieldRefEnv("SHAREDEV_POD_UID", "metadata.uid"),
+	}
+}
+
+func fieldRefEnv(name, fieldPath string) v1.EnvVar {
+	return v1.EnvVar{
+		Name:      name,
+		ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: fieldPath}},
+	}
+}
+
+// containerShares returns the containers with a share of their own in the claim.
+// A missing claim has none; the scheduler rejects the pod until it exists.
+func (d *SharedDevicePodDefaulter) containerShares(ctx context.Context, pod *v1.Pod, claimName string) (sets.String, error) {
+	containers := sets.NewString()
+	if d.Client == nil {
+		return containers, nil
+	}
+
+	namespace := pod.Namespace
+	if namespace == "" {
+		// The namespace of pods being created is only in the request.
+		if req, err := admission.RequestFromContext(ctx); err == nil {
+			namespace = req.Namespace
+		}
+	}
+	claim := &schedv1alpha1.SharedDeviceClaim{}
+	if err := d.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: claimName}, claim); err != nil {
+		if apierrs.IsNotFound(err) {
+			return containers, nil
+		}
+		return nil, err
+	}
+	for _, c := range claim.Spec.Containers {
+		containers.Insert(c.Name)
+	}
+	return containers, nil
+}
+
+func annotationFieldPath(key string) string {
+	return fmt.Sprintf("metadata.annotations['%s']", key)
+}
+
+func hasEnv(c *v1.Container, name string) bool {
+	for _, e := range c.Env {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	schedv1alpha1 "sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+)
+
+func TestSharedDevicePodDefaulter(t *testing.T) {
+	injected := []v1.EnvVar{
+		{Name: "SHAREDEV_POD_NAMESPACE", ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.namespace"}}},
+		{Name: "SHAREDEV_POD_NAME", ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.name"}}},
+		{Name: "SHAREDEV_POD_UID", ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.uid"}}},
+		{Name: "CLIENT_ID", Value: "$(SHAREDEV_POD_NAMESPACE)/$(SHAREDEV_POD_NAME)/$(SHAREDEV_POD_UID)"},
+		{Name: "DEVICE_ID", ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.annotations['scheduling.x-k8s.io/shared-device-id']"}}},
+		{Name: "HOST_IP", ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.annotations['scheduling.x-k8s.io/shared-device-host-ip']"}}},
+	}
+
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		env     []v1.EnvVar
+		wantEnv []v1.EnvVar
+	}{
+		{
+			name:    "pod without claim is left alone",
+			env:     []v1.EnvVar{{Name: "FOO", Value: "bar"}},
+			wantEnv: []v1.EnvVar{{Name: "FOO", Value: "bar"}},
+		},
+		{
+			name:    "pod with claim gets device env",
+			labels:  map[string]string{schedv1alpha1.SharedDeviceClaimLabel: "quarter"},
+			env:     []v1.EnvVar{{Name: "FOO", Value: "bar"}},
+			wantEnv: append([]v1.EnvVar{{Name: "FOO", Value: "bar"}}, injected...),
+		},
+		{
+			name:    "existing env is not overridden",
+			labels:  map[string]string{schedv1alpha1.SharedDeviceClaimLabel: "quarter"},
+			env:     []v1.EnvVar{{Name: "CLIENT_ID", Value: "custom"}},
+			wantEnv: append(append([]v1.EnvVar{{Name: "CLIENT_ID", Value: "custom"}}, injected[:3]...), injected[4:]...),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "ns", Labels: tt.labels},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{Name: "c1", Env: tt.env}, {Name: "c2", Env: append([]v1.EnvVar{}, tt.env...)}},
+				},
+			}
+			d := &SharedDevicePodDefaulter{}
+			if err := d.Default(context.Background(), pod); err != nil {
+				t.Fatal(err)
+			}
+			// Defaulting must be idempotent for webhook reinvocation.
+			if err := d.Default(context.Background(), pod); err != nil {
+				t.Fatal(err)
+			}
+			for _, c := range pod.Spec.Containers {
+				if diff := cmp.Diff(tt.wantEnv, c.Env); diff != "" {
+					t.Errorf("unexpected env in container %s (-want,+got):\n%s", c.Name, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestSharedDevicePodDefaulterContainerShares(t *testing.T) {
+	s := scheme.Scheme
+	utilruntime.Must(schedv1alpha1.AddToScheme(s))
+	claim := &schedv1alpha1.SharedDeviceClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "multi", Namespace: "ns"},
+		Spec: schedv1alpha1.SharedDeviceClaimSpec{
+			Containers: []schedv1alpha1.SharedDeviceContainerShare{{Name: "sidecar"}},
+		},
+	}
+	d := &SharedDevicePodDefaulter{Client: fake.NewClientBuilder().WithScheme(s).WithObjects(claim).Build()}
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "ns", Labels: map[string]string{schedv1alpha1.SharedDeviceClaimLabel: "multi"}},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "main"}, {Name: "sidecar"}}},
+	}
+	if err := d.Default(context.Background(), pod); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{
+		"main":    {"CLIENT_ID=$(SHAREDEV_POD_NAMESPACE)/$(SHAREDEV_POD_NAME)/$(SHAREDEV_POD_UID)", "DEVICE_ID=metadata.annotations['scheduling.x-k8s.io/shared-device-id']"},
+		"sidecar": {"CLIENT_ID=$(SHAREDEV_POD_NAMESPACE)/$(SHAREDEV_POD_NAME)/$(SHAREDEV_POD_UID).sidecar", "DEVICE_ID=metadata.annotations['scheduling.x-k8s.io/shared-device-id.sidecar']"},
+	}
+	for _, c := range pod.Spec.Containers {
+		var got []string
+		for _, e := range c.Env {
+			if e.Name != "CLIENT_ID" && e.Name != "DEVICE_ID" {
+				continue
+			}
+			value := e.Value
+			if e.ValueFrom != nil {
+				value = e.ValueFrom.FieldRef.FieldPath
+			}
+			got = append(got, e.Name+"="+value)
+		}
+		if diff := cmp.Diff(want[c.Name], got); diff != "" {
+			t.Errorf("unexpected env in container %s (-want,+got):\n%s", c.Name, diff)
+		}
+	}
+}
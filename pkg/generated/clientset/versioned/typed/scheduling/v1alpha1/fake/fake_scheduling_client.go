@@ -36,6 +36,10 @@ func (c *FakeSchedulingV1alpha1) PodGroups(namespace string) v1alpha1.PodGroupIn
 	return &FakePodGroups{c, namespace}
 }
 
+func (c *FakeSchedulingV1alpha1) SharedDeviceClaims(namespace string) v1alpha1.SharedDeviceClaimInterface {
+	return &FakeSharedDeviceClaims{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeSchedulingV1alpha1) RESTClient() rest.Interface {
@@ -0,0 +1,142 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+	v1alpha1 "sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+)
+
+// FakeSharedDeviceClaims implements SharedDeviceClaimInterface
+type FakeSharedDeviceClaims struct {
+	Fake *FakeSchedulingV1alpha1
+	ns   string
+}
+
+var shareddeviceclaimsResource = schema.GroupVersionResource{Group: "scheduling.x-k8s.io", Version: "v1alpha1", Resource: "shareddeviceclaims"}
+
+var shareddeviceclaimsKind = schema.GroupVersionKind{Group: "scheduling.x-k8s.io", Version: "v1alpha1", Kind: "SharedDeviceClaim"}
+
+// Get takes name of the sharedDeviceClaim, and returns the corresponding sharedDeviceClaim object, and an error if there is any.
+func (c *FakeSharedDeviceClaims) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.SharedDeviceClaim, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(shareddeviceclaimsResource, c.ns, name), &v1alpha1.SharedDeviceClaim{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SharedDeviceClaim), err
+}
+
+// List takes label and field selectors, and returns the list of SharedDeviceClaims that match those selectors.
+func (c *FakeSharedDeviceClaims) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.SharedDeviceClaimList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(shareddeviceclaimsResource, shareddeviceclaimsKind, c.ns, opts), &v1alpha1.SharedDeviceClaimList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.SharedDeviceClaimList{ListMeta: obj.(*v1alpha1.SharedDeviceClaimList).ListMeta}
+	for _, item := range obj.(*v1alpha1.SharedDeviceClaimList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested sharedDeviceClaims.
+func (c *FakeSharedDeviceClaims) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(shareddeviceclaimsResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a sharedDeviceClaim and creates it.  Returns the server's representation of the sharedDeviceClaim, and an error, if there is any.
+func (c *FakeSharedDeviceClaims) Create(ctx context.Context, sharedDeviceClaim *v1alpha1.SharedDeviceClaim, opts v1.CreateOptions) (result *v1alpha1.SharedDeviceClaim, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(shareddeviceclaimsResource, c.ns, sharedDeviceClaim), &v1alpha1.SharedDeviceClaim{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SharedDeviceClaim), err
+}
+
+// Update takes the representation of a sharedDeviceClaim and updates it. Returns the server's representation of the sharedDeviceClaim, and an error, if there is any.
+func (c *FakeSharedDeviceClaims) Update(ctx context.Context, sharedDeviceClaim *v1alpha1.SharedDeviceClaim, opts v1.UpdateOptions) (result *v1alpha1.SharedDeviceClaim, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(shareddeviceclaimsResource, c.ns, sharedDeviceClaim), &v1alpha1.SharedDeviceClaim{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SharedDeviceClaim), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeSharedDeviceClaims) UpdateStatus(ctx context.Context, sharedDeviceClaim *v1alpha1.SharedDeviceClaim, opts v1.UpdateOptions) (*v1alpha1.SharedDeviceClaim, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(shareddeviceclaimsResource, "status", c.ns, sharedDeviceClaim), &v1alpha1.SharedDeviceClaim{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SharedDeviceClaim), err
+}
+
+// Delete takes name of the sharedDeviceClaim and deletes it. Returns an error if one occurs.
+func (c *FakeSharedDeviceClaims) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteActionWithOptions(shareddeviceclaimsResource, c.ns, name, opts), &v1alpha1.SharedDeviceClaim{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeSharedDeviceClaims) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(shareddeviceclaimsResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.SharedDeviceClaimList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched sharedDeviceClaim.
+func (c *FakeSharedDeviceClaims) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.SharedDeviceClaim, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(shareddeviceclaimsResource, c.ns, name, pt, data, subresources...), &v1alpha1.SharedDeviceClaim{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SharedDeviceClaim), err
+}
@@ -21,3 +21,5 @@ package v1alpha1
 type ElasticQuotaExpansion interface{}
 
 type PodGroupExpansion interface{}
+
+type SharedDeviceClaimExpansion interface{}
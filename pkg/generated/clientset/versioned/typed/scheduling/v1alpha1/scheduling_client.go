@@ -30,6 +30,7 @@ type SchedulingV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	ElasticQuotasGetter
 	PodGroupsGetter
+	SharedDeviceClaimsGetter
 }
 
 // SchedulingV1alpha1Client is used to interact with features provided by the scheduling.x-k8s.io group.
@@ -45,6 +46,10 @@ func (c *SchedulingV1alpha1Client) PodGroups(namespace string) PodGroupInterface
 	return newPodGroups(c, namespace)
 }
 
+func (c *SchedulingV1alpha1Client) SharedDeviceClaims(namespace string) SharedDeviceClaimInterface {
+	return newSharedDeviceClaims(c, namespace)
+}
+
 // NewForConfig creates a new SchedulingV1alpha1Client for the given config.
 // NewForConfig is equivalent to NewForConfigAndClient(c, httpClient),
 // where httpClient was generated with rest.HTTPClientFor(c).
@@ -0,0 +1,195 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+	v1alpha1 "sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	scheme "sigs.k8s.io/scheduler-plugins/pkg/generated/clientset/versioned/scheme"
+)
+
+// SharedDeviceClaimsGetter has a method to return a SharedDeviceClaimInterface.
+// A group's client should implement this interface.
+type SharedDeviceClaimsGetter interface {
+	SharedDeviceClaims(namespace string) SharedDeviceClaimInterface
+}
+
+// SharedDeviceClaimInterface has methods to work with SharedDeviceClaim resources.
+type SharedDeviceClaimInterface interface {
+	Create(ctx context.Context, sharedDeviceClaim *v1alpha1.SharedDeviceClaim, opts v1.CreateOptions) (*v1alpha1.SharedDeviceClaim, error)
+	Update(ctx context.Context, sharedDeviceClaim *v1alpha1.SharedDeviceClaim, opts v1.UpdateOptions) (*v1alpha1.SharedDeviceClaim, error)
+	UpdateStatus(ctx context.Context, sharedDeviceClaim *v1alpha1.SharedDeviceClaim, opts v1.UpdateOptions) (*v1alpha1.SharedDeviceClaim, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1alpha1.SharedDeviceClaim, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1alpha1.SharedDeviceClaimList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.SharedDeviceClaim, err error)
+	SharedDeviceClaimExpansion
+}
+
+// sharedDeviceClaims implements SharedDeviceClaimInterface
+type sharedDeviceClaims struct {
+	client rest.Interface
+	ns     string
+}
+
+// newSharedDeviceClaims returns a SharedDeviceClaims
+func newSharedDeviceClaims(c *SchedulingV1alpha1Client, namespace string) *sharedDeviceClaims {
+	return &sharedDeviceClaims{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the sharedDeviceClaim, and returns the corresponding sharedDeviceClaim object, and an error if there is any.
+func (c *sharedDeviceClaims) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.SharedDeviceClaim, err error) {
+	result = &v1alpha1.SharedDeviceClaim{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("shareddeviceclaims").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of SharedDeviceClaims that match those selectors.
+func (c *sharedDeviceClaims) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.SharedDeviceClaimList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.SharedDeviceClaimList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("shareddeviceclaims").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested sharedDeviceClaims.
+func (c *sharedDeviceClaims) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("shareddeviceclaims").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a sharedDeviceClaim and creates it.  Returns the server's representation of the sharedDeviceClaim, and an error, if there is any.
+func (c *sharedDeviceClaims) Create(ctx context.Context, sharedDeviceClaim *v1alpha1.SharedDeviceClaim, opts v1.CreateOptions) (result *v1alpha1.SharedDeviceClaim, err error) {
+	result = &v1alpha1.SharedDeviceClaim{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("shareddeviceclaims").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(sharedDeviceClaim).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a sharedDeviceClaim and updates it. Returns the server's representation of the sharedDeviceClaim, and an error, if there is any.
+func (c *sharedDeviceClaims) Update(ctx context.Context, sharedDeviceClaim *v1alpha1.SharedDeviceClaim, opts v1.UpdateOptions) (result *v1alpha1.SharedDeviceClaim, err error) {
+	result = &v1alpha1.SharedDeviceClaim{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("shareddeviceclaims").
+		Name(sharedDeviceClaim.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(sharedDeviceClaim).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *sharedDeviceClaims) UpdateStatus(ctx context.Context, sharedDeviceClaim *v1alpha1.SharedDeviceClaim, opts v1.UpdateOptions) (result *v1alpha1.SharedDeviceClaim, err error) {
+	result = &v1alpha1.SharedDeviceClaim{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("shareddeviceclaims").
+		Name(sharedDeviceClaim.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(sharedDeviceClaim).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the sharedDeviceClaim and deletes it. Returns an error if one occurs.
+func (c *sharedDeviceClaims) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("shareddeviceclaims").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *sharedDeviceClaims) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("shareddeviceclaims").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched sharedDeviceClaim.
+func (c *sharedDeviceClaims) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.SharedDeviceClaim, err error) {
+	result = &v1alpha1.SharedDeviceClaim{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("shareddeviceclaims").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
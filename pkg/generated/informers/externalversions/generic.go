@@ -57,6 +57,8 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Scheduling().V1alpha1().ElasticQuotas().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("podgroups"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Scheduling().V1alpha1().PodGroups().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("shareddeviceclaims"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Scheduling().V1alpha1().SharedDeviceClaims().Informer()}, nil
 
 	}
 
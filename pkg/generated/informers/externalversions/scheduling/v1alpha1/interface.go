@@ -28,6 +28,8 @@ type Interface interface {
 	ElasticQuotas() ElasticQuotaInformer
 	// PodGroups returns a PodGroupInformer.
 	PodGroups() PodGroupInformer
+	// SharedDeviceClaims returns a SharedDeviceClaimInformer.
+	SharedDeviceClaims() SharedDeviceClaimInformer
 }
 
 type version struct {
@@ -50,3 +52,8 @@ func (v *version) ElasticQuotas() ElasticQuotaInformer {
 func (v *version) PodGroups() PodGroupInformer {
 	return &podGroupInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// SharedDeviceClaims returns a SharedDeviceClaimInformer.
+func (v *version) SharedDeviceClaims() SharedDeviceClaimInformer {
+	return &sharedDeviceClaimInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
@@ -33,3 +33,11 @@ type PodGroupListerExpansion interface{}
 // PodGroupNamespaceListerExpansion allows custom methods to be added to
 // PodGroupNamespaceLister.
 type PodGroupNamespaceListerExpansion interface{}
+
+// SharedDeviceClaimListerExpansion allows custom methods to be added to
+// SharedDeviceClaimLister.
+type SharedDeviceClaimListerExpansion interface{}
+
+// SharedDeviceClaimNamespaceListerExpansion allows custom methods to be added to
+// SharedDeviceClaimNamespaceLister.
+type SharedDeviceClaimNamespaceListerExpansion interface{}
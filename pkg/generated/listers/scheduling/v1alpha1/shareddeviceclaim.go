@@ -0,0 +1,99 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	v1alpha1 "sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+)
+
+// SharedDeviceClaimLister helps list SharedDeviceClaims.
+// All objects returned here must be treated as read-only.
+type SharedDeviceClaimLister interface {
+	// List lists all SharedDeviceClaims in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.SharedDeviceClaim, err error)
+	// SharedDeviceClaims returns an object that can list and get SharedDeviceClaims.
+	SharedDeviceClaims(namespace string) SharedDeviceClaimNamespaceLister
+	SharedDeviceClaimListerExpansion
+}
+
+// sharedDeviceClaimLister implements the SharedDeviceClaimLister interface.
+type sharedDeviceClaimLister struct {
+	indexer cache.Indexer
+}
+
+// NewSharedDeviceClaimLister returns a new SharedDeviceClaimLister.
+func NewSharedDeviceClaimLister(indexer cache.Indexer) SharedDeviceClaimLister {
+	return &sharedDeviceClaimLister{indexer: indexer}
+}
+
+// List lists all SharedDeviceClaims in the indexer.
+func (s *sharedDeviceClaimLister) List(selector labels.Selector) (ret []*v1alpha1.SharedDeviceClaim, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.SharedDeviceClaim))
+	})
+	return ret, err
+}
+
+// SharedDeviceClaims returns an object that can list and get SharedDeviceClaims.
+func (s *sharedDeviceClaimLister) SharedDeviceClaims(namespace string) SharedDeviceClaimNamespaceLister {
+	return sharedDeviceClaimNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// SharedDeviceClaimNamespaceLister helps list and get SharedDeviceClaims.
+// All objects returned here must be treated as read-only.
+type SharedDeviceClaimNamespaceLister interface {
+	// List lists all SharedDeviceClaims in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.SharedDeviceClaim, err error)
+	// Get retrieves the SharedDeviceClaim from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1alpha1.SharedDeviceClaim, error)
+	SharedDeviceClaimNamespaceListerExpansion
+}
+
+// sharedDeviceClaimNamespaceLister implements the SharedDeviceClaimNamespaceLister
+// interface.
+type sharedDeviceClaimNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all SharedDeviceClaims in the indexer for a given namespace.
+func (s sharedDeviceClaimNamespaceLister) List(selector labels.Selector) (ret []*v1alpha1.SharedDeviceClaim, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.SharedDeviceClaim))
+	})
+	return ret, err
+}
+
+// Get retrieves the SharedDeviceClaim from the indexer for a given namespace and name.
+func (s sharedDeviceClaimNamespaceLister) Get(name string) (*v1alpha1.SharedDeviceClaim, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("shareddeviceclaim"), name)
+	}
+	return obj.(*v1alpha1.SharedDeviceClaim), nil
+}
@@ -0,0 +1,145 @@
+package sharedev
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+)
+
+// bindClaim records the devices and node the shares of pod were reserved on in
+// the status of its SharedDeviceClaim, deviceIds being by share index of podQ.
+// The pods of the claim that are gone are dropped from the status.
+func (sp *ShareDevPlugin) bindClaim(ctx context.Context, pod *v1.Pod, nodeName string, podQ PodRequestedQuota, deviceIds []string) error {
+	podDeviceIds, containerDeviceIds := splitDeviceIds(podQ, deviceIds)
+	binding := v1alpha1.SharedDeviceClaimPodStatus{
+		Name:               pod.Name,
+		UID:                pod.UID,
+		NodeName:           nodeName,
+		DeviceIDs:          podDeviceIds,
+		ContainerDeviceIDs: containerDeviceIds,
+		Vendor:             podQ.Vendor,
+		Model:              podQ.Model,
+	}
+
+	claims := sp.claimClient.SchedulingV1alpha1().SharedDeviceClaims(pod.Namespace)
+	claim, err := sp.claimLister.SharedDeviceClaims(pod.Namespace).Get(podQ.ClaimName)
+	retried := false
+	// Pods of the same claim bind concurrently, retry with the latest status.
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if retried {
+			claim, err = claims.Get(ctx, podQ.ClaimName, metav1.GetOptions{})
+		}
+		retried = true
+		if err != nil {
+			return err
+		}
+
+		claimCopy := claim.DeepCopy()
+		claimCopy.Status.Pods = append(sp.boundClaimPods(claim, pod), binding)
+		setFirstPod(&claimCopy.Status)
+		_, err := claims.UpdateStatus(ctx, claimCopy, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// unbindClaim removes pod from the status of its SharedDeviceClaim, the claim
+// going back to pending once none of its pods is bound.
+func (sp *ShareDevPlugin) unbindClaim(ctx context.Context, pod *v1.Pod, claimName string) error {
+	claims := sp.claimClient.SchedulingV1alpha1().SharedDeviceClaims(pod.Namespace)
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		claim, err := claims.Get(ctx, claimName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		var pods []v1alpha1.SharedDeviceClaimPodStatus
+		for _, p := range claim.Status.Pods {
+			if p.UID != pod.UID {
+				pods = append(pods, p)
+			}
+		}
+		if len(pods) == len(claim.Status.Pods) {
+			return nil
+		}
+		claimCopy := claim.DeepCopy()
+		if len(pods) == 0 {
+			claimCopy.Status = v1alpha1.SharedDeviceClaimStatus{Phase: v1alpha1.SharedDeviceClaimPending}
+		} else {
+			claimCopy.Status.Pods = pods
+			setFirstPod(&claimCopy.Status)
+		}
+		_, err = claims.UpdateStatus(ctx, claimCopy, metav1.UpdateOptions{})
+		return err
+	})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// boundClaimPods returns the pods of the claim status other than pod that
+// still hold their devices.
+func (sp *ShareDevPlugin) boundClaimPods(claim *v1alpha1.SharedDeviceClaim, pod *v1.Pod) []v1alpha1.SharedDeviceClaimPodStatus {
+	var bound []v1alpha1.SharedDeviceClaimPodStatus
+	for _, p := range claim.Status.Pods {
+		if p.UID == pod.UID {
+			continue
+		}
+		current, err := sp.podLister.Pods(claim.Namespace).Get(p.Name)
+		if err != nil || current.UID != p.UID || current.Status.Phase == v1.PodSucceeded || current.Status.Phase == v1.PodFailed {
+			continue
+		}
+		bound = append(bound, p)
+	}
+	return bound
+}
+
+// setFirstPod sets the single pod fields of status from its first pod.
+func setFirstPod(status *v1alpha1.SharedDeviceClaimStatus) {
+	first := status.Pods[0]
+	status.Phase = v1alpha1.SharedDeviceClaimBound
+	status.DeviceID = ""
+	if len(first.DeviceIDs) > 0 {
+		status.DeviceID = first.DeviceIDs[0]
+	}
+	status.DeviceIDs = first.DeviceIDs
+	status.ContainerDeviceIDs = first.ContainerDeviceIDs
+	status.NodeName = first.NodeName
+	status.Vendor = first.Vendor
+	status.Model = first.Model
+}
+
+// BoundShares returns the quota of a bound client pod on the model it runs on,
+// from its claim and the model PreBind recorded on it, and the device of each
+// of its shares, by share index, "" where none is recorded. It fails if the
+// claim is invalid or doesn't accept the model anymore.
+func BoundShares(pod *v1.Pod, claim *v1alpha1.SharedDeviceClaim) (PodRequestedQuota, []string, error) {
+	models, err := claimToQuotas(pod, claim)
+	if err != nil {
+		return PodRequestedQuota{}, nil, err
+	}
+	podQ := boundModel(pod, models)
+	if podQ == nil {
+		return PodRequestedQuota{}, nil, fmt.Errorf("SharedDeviceClaim %s/%s doesn't accept model %s anymore",
+			claim.Namespace, claim.Name, pod.Annotations[v1alpha1.SharedDeviceModelAnnotation])
+	}
+
+	devices := map[string][]string{}
+	for _, d := range reservedDevices(podQ.PodId, pod) {
+		devices[d.clientId] = append(devices[d.clientId], d.deviceId)
+	}
+	deviceIds := make([]string, len(podQ.Shares))
+	for i, share := range podQ.Shares {
+		if ids := devices[share.ClientId]; len(ids) > 0 {
+			deviceIds[i] = ids[0]
+			devices[share.ClientId] = ids[1:]
+		}
+	}
+	return *podQ, deviceIds, nil
+}
@@ -0,0 +1,159 @@
+package sharedev
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/scheduler-plugins/apis/config"
+)
+
+// newTransportCredentials returns the credentials of the device manager
+// connections. Plaintext is only used when cfg.Insecure is set.
+func newTransportCredentials(cfg config.DeviceManagerTLS) (credentials.TransportCredentials, error) {
+	if cfg.Insecure {
+		return insecure.NewCredentials(), nil
+	}
+	files := &tlsFiles{caFile: cfg.CAFile, certFile: cfg.CertFile, keyFile: cfg.KeyFile}
+	// Fail at startup rather than on every handshake.
+	if _, err := files.tlsConfig(); err != nil {
+		return nil, err
+	}
+	return &reloadingCredentials{files: files, serverName: cfg.ServerName}, nil
+}
+
+// fileStamp tells whether a file changed since it was read.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// tlsFiles reads the CA bundle and the client certificate and key, and reads
+// them again once they change. Certificates that fail to load, e.g. while
+// being rotated, keep the previous ones in use.
+type tlsFiles struct {
+	caFile   string
+	certFile string
+	keyFile  string
+
+	lock   sync.Mutex
+	stamps []fileStamp
+	config *tls.Config
+}
+
+// tlsConfig returns the client TLS config built from the current files.
+func (f *tlsFiles) tlsConfig() (*tls.Config, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	stamps, err := f.stat()
+	if err == nil && f.config != nil && equalStamps(stamps, f.stamps) {
+		return f.config, nil
+	}
+	var cfg *tls.Config
+	if err == nil {
+		cfg, err = f.load()
+	}
+	if err != nil {
+		if f.config == nil {
+			return nil, err
+		}
+		klog.ErrorS(err, "Failed to reload device manager TLS certificates, keeping the previous ones")
+		return f.config, nil
+	}
+	f.stamps, f.config = stamps, cfg
+	return cfg, nil
+}
+
+func (f *tlsFiles) stat() ([]fileStamp, error) {
+	var stamps []fileStamp
+	for _, name := range []string{f.caFile, f.certFile, f.keyFile} {
+		if name == "" {
+			stamps = append(stamps, fileStamp{})
+			continue
+		}
+		// Stat follows the symlinks Secret volumes swap on updates.
+		info, err := os.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+		stamps = append(stamps, fileStamp{modTime: info.ModTime(), size: info.Size()})
+	}
+	return stamps, nil
+}
+
+func (f *tlsFiles) load() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if f.caFile != "" {
+		roots, err := cert.NewPool(f.caFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading the device manager CA bundle: %w", err)
+		}
+		cfg.RootCAs = roots
+	}
+	if f.certFile != "" {
+		clientCert, err := tls.LoadX509KeyPair(f.certFile, f.keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading the device manager client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{clientCert}
+	}
+	return cfg, nil
+}
+
+func equalStamps(a, b []fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].modTime.Equal(b[i].modTime) || a[i].size != b[i].size {
+			return false
+		}
+	}
+	return true
+}
+
+// reloadingCredentials are TLS client credentials that use the latest
+// certificates of files for every new connection.
+type reloadingCredentials struct {
+	files      *tlsFiles
+	serverName string
+}
+
+var _ credentials.TransportCredentials = &reloadingCredentials{}
+
+func (c *reloadingCredentials) ClientHandshake(ctx context.Context, authority string, rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	cfg, err := c.files.tlsConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg = cfg.Clone()
+	cfg.ServerName = c.serverName
+	return credentials.NewTLS(cfg).ClientHandshake(ctx, authority, rawConn)
+}
+
+func (c *reloadingCredentials) ServerHandshake(net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, fmt.Errorf("device manager credentials are client only")
+}
+
+func (c *reloadingCredentials) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "tls", SecurityVersion: "1.2", ServerName: c.serverName}
+}
+
+func (c *reloadingCredentials) Clone() credentials.TransportCredentials {
+	return &reloadingCredentials{files: c.files, serverName: c.serverName}
+}
+
+func (c *reloadingCredentials) OverrideServerName(serverName string) error {
+	c.serverName = serverName
+	return nil
+}
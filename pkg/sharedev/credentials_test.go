@@ -0,0 +1,275 @@
+package sharedev
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "github.com/zbsss/device-manager/generated"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"sigs.k8s.io/scheduler-plugins/apis/config"
+	testutil "sigs.k8s.io/scheduler-plugins/test/util"
+)
+
+// testCA signs the certificates of a test.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key for the names, valid for servers
+// and clients.
+func (ca *testCA) issue(t *testing.T, commonName string, dnsNames []string, ips []net.IP) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     dnsNames,
+		IPAddresses:  ips,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, key.Public(), ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFile writes data to name in dir, with a modification time after the
+// one of any previous version.
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	path := filepath.Join(dir, name)
+	modTime := time.Now()
+	if info, err := os.Stat(path); err == nil && !modTime.After(info.ModTime()) {
+		modTime = info.ModTime().Add(time.Second)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// serveTLSDeviceManager serves a fake device manager on 127.0.0.1 with a
+// certificate of ca. Clients must present a certificate of clientCA, if any.
+func serveTLSDeviceManager(t *testing.T, ca, clientCA *testCA) string {
+	certPEM, keyPEM := ca.issue(t, "device-manager", []string{"device-manager.example.com"}, []net.IP{net.ParseIP("127.0.0.1")})
+	serverCert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{serverCert}}
+	if clientCA != nil {
+		tlsConfig.ClientCAs = x509.NewCertPool()
+		tlsConfig.ClientCAs.AddCert(clientCA.cert)
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.NewFakeDeviceManager().
+		AddDevice("example.com", "mydev", "dev1").
+		Serve(t, lis, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	return lis.Addr().String()
+}
+
+// callDeviceManager makes an RPC on a new connection, and so a new handshake.
+func callDeviceManager(t *testing.T, creds credentials.TransportCredentials, address string) error {
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = pb.NewDeviceManagerClient(conn).GetAvailableDevices(ctx, &pb.GetAvailableDevicesRequest{Vendor: "example.com", Model: "mydev"})
+	return err
+}
+
+func TestTransportCredentials(t *testing.T) {
+	serverCA := newTestCA(t, "server-ca")
+	clientCA := newTestCA(t, "client-ca")
+	otherCA := newTestCA(t, "other-ca")
+
+	dir := t.TempDir()
+	caFile := writeFile(t, dir, "ca.crt", serverCA.pem)
+	otherCAFile := writeFile(t, dir, "other-ca.crt", otherCA.pem)
+	clientCert, clientKey := clientCA.issue(t, "scheduler", nil, nil)
+	certFile := writeFile(t, dir, "tls.crt", clientCert)
+	keyFile := writeFile(t, dir, "tls.key", clientKey)
+	otherCert, otherKey := otherCA.issue(t, "scheduler", nil, nil)
+	otherCertFile := writeFile(t, dir, "other-tls.crt", otherCert)
+	otherKeyFile := writeFile(t, dir, "other-tls.key", otherKey)
+
+	tlsAddress := serveTLSDeviceManager(t, serverCA, nil)
+	mtlsAddress := serveTLSDeviceManager(t, serverCA, clientCA)
+
+	tests := []struct {
+		name    string
+		tls     config.DeviceManagerTLS
+		address string
+		wantErr bool
+	}{
+		{
+			name:    "TLS",
+			tls:     config.DeviceManagerTLS{CAFile: caFile},
+			address: tlsAddress,
+		},
+		{
+			name:    "mutual TLS",
+			tls:     config.DeviceManagerTLS{CAFile: caFile, CertFile: certFile, KeyFile: keyFile},
+			address: mtlsAddress,
+		},
+		{
+			name:    "mutual TLS without client certificate",
+			tls:     config.DeviceManagerTLS{CAFile: caFile},
+			address: mtlsAddress,
+			wantErr: true,
+		},
+		{
+			name:    "mutual TLS with client certificate of another CA",
+			tls:     config.DeviceManagerTLS{CAFile: caFile, CertFile: otherCertFile, KeyFile: otherKeyFile},
+			address: mtlsAddress,
+			wantErr: true,
+		},
+		{
+			name:    "server certificate of another CA",
+			tls:     config.DeviceManagerTLS{CAFile: otherCAFile},
+			address: tlsAddress,
+			wantErr: true,
+		},
+		{
+			name:    "server name",
+			tls:     config.DeviceManagerTLS{CAFile: caFile, ServerName: "device-manager.example.com"},
+			address: tlsAddress,
+		},
+		{
+			name:    "server name not in the server certificate",
+			tls:     config.DeviceManagerTLS{CAFile: caFile, ServerName: "other.example.com"},
+			address: tlsAddress,
+			wantErr: true,
+		},
+		{
+			name:    "insecure against a TLS device manager",
+			tls:     config.DeviceManagerTLS{Insecure: true},
+			address: tlsAddress,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creds, err := newTransportCredentials(tt.tls)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := callDeviceManager(t, creds, tt.address); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTransportCredentialsMissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := newTransportCredentials(config.DeviceManagerTLS{CAFile: filepath.Join(dir, "ca.crt")}); err == nil {
+		t.Errorf("expected an error for a missing CA bundle")
+	}
+	caFile := writeFile(t, dir, "ca.crt", []byte("not a certificate"))
+	if _, err := newTransportCredentials(config.DeviceManagerTLS{CAFile: caFile}); err == nil {
+		t.Errorf("expected an error for an invalid CA bundle")
+	}
+}
+
+func TestTransportCredentialsReload(t *testing.T) {
+	serverCA := newTestCA(t, "server-ca")
+	clientCA := newTestCA(t, "client-ca")
+	otherCA := newTestCA(t, "other-ca")
+	address := serveTLSDeviceManager(t, serverCA, clientCA)
+
+	// The client starts with the wrong CA bundle and client certificate.
+	dir := t.TempDir()
+	caFile := writeFile(t, dir, "ca.crt", otherCA.pem)
+	otherCert, otherKey := otherCA.issue(t, "scheduler", nil, nil)
+	certFile := writeFile(t, dir, "tls.crt", otherCert)
+	keyFile := writeFile(t, dir, "tls.key", otherKey)
+
+	creds, err := newTransportCredentials(config.DeviceManagerTLS{CAFile: caFile, CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := callDeviceManager(t, creds, address); err == nil {
+		t.Fatalf("expected the server certificate to be rejected")
+	}
+
+	writeFile(t, dir, "ca.crt", serverCA.pem)
+	if err := callDeviceManager(t, creds, address); err == nil {
+		t.Fatalf("expected the client certificate to be rejected")
+	}
+
+	// The certificate is rotated before its key, which doesn't match until then.
+	clientCert, clientKey := clientCA.issue(t, "scheduler", nil, nil)
+	writeFile(t, dir, "tls.crt", clientCert)
+	if err := callDeviceManager(t, creds, address); err == nil {
+		t.Fatalf("expected the previous client certificate to be kept")
+	}
+	writeFile(t, dir, "tls.key", clientKey)
+	if err := callDeviceManager(t, creds, address); err != nil {
+		t.Fatalf("expected the rotated certificates to be used, got %v", err)
+	}
+}
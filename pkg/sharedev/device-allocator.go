@@ -3,19 +3,65 @@ package sharedev
 import (
 	"context"
 	"fmt"
-	"time"
+	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+)
+
+// Labels of the pods using shared devices.
+const (
+	// sharedevLabel tells allocator pods from client pods.
+	sharedevLabel       = v1alpha1.SharedDeviceRoleLabel
+	allocatorLabelValue = v1alpha1.SharedDeviceAllocatorRole
+	clientLabelValue    = v1alpha1.SharedDeviceClientRole
+	// allocatorNameLabel is the name of an allocator pod's Deployment.
+	allocatorNameLabel = v1alpha1.SharedDeviceAllocatorNameLabel
 )
 
-func (sp *ShareDevPlugin) allocateNewDevice(vendor, model string) (string, error) {
-	cli := sp.handle.ClientSet().AppsV1().Deployments("default")
+// allocatorSuffixLength is the length of the random suffix of the allocator names.
+const allocatorSuffixLength = 8
+
+// allocatorName returns a new name for an allocator Deployment. The name is
+// also the value of its pods' allocatorNameLabel, so it is a DNS-1123 label:
+// the vendor and model are lowercased, their other characters replaced with
+// '-' and truncated to leave room for the random suffix.
+func allocatorName(vendor, model string) string {
+	prefix := sanitizeName("allocator-" + vendor + "-" + model)
+	if limit := validation.DNS1123LabelMaxLength - allocatorSuffixLength - 1; len(prefix) > limit {
+		prefix = strings.TrimRight(prefix[:limit], "-")
+	}
+	return prefix + "-" + utilrand.String(allocatorSuffixLength)
+}
+
+// sanitizeName lowercases name and replaces the characters a DNS-1123 label
+// can't have with '-', collapsing repeats.
+func sanitizeName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			r = '-'
+		}
+		if r == '-' && strings.HasSuffix(b.String(), "-") {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+// createAllocator creates the allocator Deployment deployName, which claims a
+// new vendor/model device on whatever node its pod lands on.
+func (sp *ShareDevPlugin) createAllocator(deployName, vendor, model string) error {
+	cli := sp.handle.ClientSet().AppsV1().Deployments(sp.allocatorNamespace)
 
 	//TODO: should we create a Deployment or maybe a Pod would be enough?
-	deployName := "allocator" + "-" + vendor + "-" + model + fmt.Sprint(time.Now().Unix())
 	var replicas int32 = 1
 
 	deviceName := vendor + "/" + model
@@ -29,20 +75,20 @@ func (sp *ShareDevPlugin) allocateNewDevice(vendor, model string) (string, error
 			Replicas: &replicas, // One replica
 			Selector: &metav1.LabelSelector{ // The deployment will manage pods with these labels
 				MatchLabels: map[string]string{
-					"app": deployName,
+					allocatorNameLabel: deployName,
 				},
 			},
 			Template: corev1.PodTemplateSpec{ // The pods that will be created
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: map[string]string{
-						"app":      deployName,
-						"sharedev": "allocator",
+						allocatorNameLabel: deployName,
+						sharedevLabel:      allocatorLabelValue,
 					},
 				},
 				Spec: corev1.PodSpec{
 					Containers: []corev1.Container{{
 						Name:  "allocator",
-						Image: "docker.io/zbsss/device-allocator:latest",
+						Image: sp.allocatorImage,
 						Resources: corev1.ResourceRequirements{
 							Limits: corev1.ResourceList{
 								corev1.ResourceName(deviceName): deviceQuantity,
@@ -82,34 +128,7 @@ func (sp *ShareDevPlugin) allocateNewDevice(vendor, model string) (string, error
 
 	_, err := cli.Create(context.Background(), deployment, metav1.CreateOptions{})
 	if err != nil {
-		return "", fmt.Errorf("error creating deployment: %s", err.Error())
-	}
-
-	timeout := time.After(60 * time.Second)
-	watch, err := sp.handle.ClientSet().CoreV1().Pods("default").Watch(
-		context.Background(),
-		metav1.ListOptions{
-			LabelSelector: fmt.Sprintf("app=%s", deployName),
-		},
-	)
-	if err != nil {
-		return "", fmt.Errorf("error watching pod status: %s", err.Error())
-	}
-	defer watch.Stop()
-
-	for {
-		select {
-		case <-timeout:
-			return "", fmt.Errorf("timeout waiting for pod to be running")
-		case event := <-watch.ResultChan():
-			pod, ok := event.Object.(*corev1.Pod)
-			if !ok {
-				return "", fmt.Errorf("unexpected type: %T", event.Object)
-			}
-
-			if pod.Status.Phase == corev1.PodRunning {
-				return pod.Spec.NodeName, nil
-			}
-		}
+		return fmt.Errorf("error creating deployment: %s", err.Error())
 	}
+	return nil
 }
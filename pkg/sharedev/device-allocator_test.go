@@ -0,0 +1,50 @@
+package sharedev
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestAllocatorName(t *testing.T) {
+	tests := []struct {
+		name       string
+		vendor     string
+		model      string
+		wantPrefix string
+	}{
+		{
+			name:       "domain vendor",
+			vendor:     "example.com",
+			model:      "mydev",
+			wantPrefix: "allocator-example-com-mydev-",
+		},
+		{
+			name:       "uppercase and symbols",
+			vendor:     "NVIDIA",
+			model:      "A100_80GB (SXM)",
+			wantPrefix: "allocator-nvidia-a100-80gb-sxm-",
+		},
+		{
+			name:       "long model",
+			vendor:     "example.com",
+			model:      strings.Repeat("x", 100),
+			wantPrefix: "allocator-example-com-" + strings.Repeat("x", 32) + "-",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := allocatorName(tt.vendor, tt.model)
+			if !strings.HasPrefix(got, tt.wantPrefix) {
+				t.Errorf("expected prefix %q, got %q", tt.wantPrefix, got)
+			}
+			if errs := validation.IsDNS1123Label(got); len(errs) > 0 {
+				t.Errorf("expected a DNS-1123 label, got %q: %v", got, errs)
+			}
+			if other := allocatorName(tt.vendor, tt.model); other == got {
+				t.Errorf("expected different names, got %q twice", got)
+			}
+		})
+	}
+}
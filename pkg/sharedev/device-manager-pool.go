@@ -0,0 +1,126 @@
+package sharedev
+
+import (
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+
+	pb "sigs.k8s.io/scheduler-plugins/pkg/sharedev/devicemanagerpb"
+)
+
+// deviceManagerClients gives the plugin the device manager client of every
+// node. deviceManagerPool is the implementation used outside of tests.
+type deviceManagerClients interface {
+	// get returns the client for the device manager of nodeName at endpoint.
+	get(nodeName, endpoint string) (pb.DeviceManagerClient, error)
+	// healthy tells whether the device manager on nodeName is reachable.
+	healthy(nodeName string) bool
+	// remove forgets the device manager on nodeName.
+	remove(nodeName string)
+}
+
+var _ deviceManagerClients = &deviceManagerPool{}
+
+// deviceManagerPool keeps one long-lived connection per node to the device
+// manager running there. Connections are dialed lazily on first use, reconnect
+// on their own with gRPC's backoff and are closed when the node is deleted.
+type deviceManagerPool struct {
+	dialOpts []grpc.DialOption
+
+	mu    sync.Mutex
+	conns map[string]*deviceManagerConn
+}
+
+type deviceManagerConn struct {
+	address string
+	conn    *grpc.ClientConn
+	client  pb.DeviceManagerClient
+}
+
+func newDeviceManagerPool(dialOpts ...grpc.DialOption) *deviceManagerPool {
+	return &deviceManagerPool{
+		dialOpts: dialOpts,
+		conns:    map[string]*deviceManagerConn{},
+	}
+}
+
+// get returns the client for the device manager on nodeName. A connection is
+// dialed if there is none yet or if the node's endpoint changed.
+func (p *deviceManagerPool) get(nodeName, address string) (pb.DeviceManagerClient, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if c, ok := p.conns[nodeName]; ok {
+		state := c.conn.GetState()
+		if c.address == address && state != connectivity.Shutdown {
+			if state == connectivity.TransientFailure {
+				// Someone needs the node now, don't wait out the reconnect backoff.
+				c.conn.ResetConnectBackoff()
+			}
+			return c.client, nil
+		}
+		c.conn.Close()
+		delete(p.conns, nodeName)
+	}
+
+	conn, err := grpc.Dial(address, p.dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("did not connect to %s: %v", address, err)
+	}
+	p.conns[nodeName] = &deviceManagerConn{
+		address: address,
+		conn:    conn,
+		client:  pb.NewDeviceManagerClient(conn),
+	}
+	return p.conns[nodeName].client, nil
+}
+
+// healthy tells whether the connection to nodeName is usable. Nodes that were
+// never dialed are considered healthy.
+func (p *deviceManagerPool) healthy(nodeName string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c, ok := p.conns[nodeName]
+	if !ok {
+		return true
+	}
+	state := c.conn.GetState()
+	return state != connectivity.TransientFailure && state != connectivity.Shutdown
+}
+
+// health returns the state of the connection to every node in the pool.
+func (p *deviceManagerPool) health() map[string]connectivity.State {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	states := make(map[string]connectivity.State, len(p.conns))
+	for nodeName, c := range p.conns {
+		states[nodeName] = c.conn.GetState()
+	}
+	return states
+}
+
+// remove closes and forgets the connection to nodeName.
+func (p *deviceManagerPool) remove(nodeName string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if c, ok := p.conns[nodeName]; ok {
+		c.conn.Close()
+		delete(p.conns, nodeName)
+	}
+}
+
+// close closes all connections in the pool.
+func (p *deviceManagerPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for nodeName, c := range p.conns {
+		c.conn.Close()
+		delete(p.conns, nodeName)
+	}
+}
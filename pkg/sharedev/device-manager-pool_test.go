@@ -0,0 +1,89 @@
+package sharedev
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/zbsss/device-manager/generated"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+
+	testutil "sigs.k8s.io/scheduler-plugins/test/util"
+)
+
+func TestDeviceManagerPool(t *testing.T) {
+	fdm := testutil.NewFakeDeviceManager()
+	p := newDeviceManagerPool(
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		testutil.ServeFakeDeviceManagers(t, map[string]*testutil.FakeDeviceManager{"127.0.0.1": fdm, "localhost": fdm}))
+	defer p.close()
+
+	if _, err := p.get("node1", "127.0.0.1:50051"); err != nil {
+		t.Fatal(err)
+	}
+	conn := p.conns["node1"].conn
+
+	// The connection is reused across calls.
+	client, err := p.get("node1", "127.0.0.1:50051")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.conns["node1"].conn != conn {
+		t.Errorf("expected the connection to node1 to be reused")
+	}
+	if _, err := client.GetAvailableDevices(context.Background(), &pb.GetAvailableDevicesRequest{}); err != nil {
+		t.Errorf("expected GetAvailableDevices to succeed, got %v", err)
+	}
+	if !p.healthy("node1") {
+		t.Errorf("expected node1 to be healthy, got %v", p.health()["node1"])
+	}
+
+	// A new address for the node gets a new connection.
+	if _, err := p.get("node1", "localhost:50051"); err != nil {
+		t.Fatal(err)
+	}
+	if p.conns["node1"].conn == conn {
+		t.Errorf("expected a new connection after the node's address changed")
+	}
+	if conn.GetState() != connectivity.Shutdown {
+		t.Errorf("expected the old connection to be closed, got %v", conn.GetState())
+	}
+
+	// Removed nodes are closed and forgotten.
+	conn = p.conns["node1"].conn
+	p.remove("node1")
+	if len(p.health()) != 0 {
+		t.Errorf("expected node1 to be removed, got %v", p.health())
+	}
+	if conn.GetState() != connectivity.Shutdown {
+		t.Errorf("expected the removed connection to be closed, got %v", conn.GetState())
+	}
+}
+
+func TestDeviceManagerPoolUnhealthy(t *testing.T) {
+	// Nothing listens on port 1.
+	p := newDeviceManagerPool(grpc.WithTransportCredentials(insecure.NewCredentials()))
+	defer p.close()
+
+	if !p.healthy("node1") {
+		t.Errorf("expected a node that was never dialed to be healthy")
+	}
+	if _, err := p.get("node1", "127.0.0.1:1"); err != nil {
+		t.Fatal(err)
+	}
+	conn := p.conns["node1"].conn
+	conn.Connect()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for state := conn.GetState(); state != connectivity.TransientFailure; state = conn.GetState() {
+		if !conn.WaitForStateChange(ctx, state) {
+			t.Fatalf("timed out waiting for the connection to fail, last state %v", state)
+		}
+	}
+	if p.healthy("node1") {
+		t.Errorf("expected node1 to be unhealthy")
+	}
+}
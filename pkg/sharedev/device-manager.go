@@ -2,72 +2,125 @@ package sharedev
 
 import (
 	"context"
-	"fmt"
 	"time"
 
 	pb "github.com/zbsss/device-manager/generated"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+
+	dmpb "sigs.k8s.io/scheduler-plugins/pkg/sharedev/devicemanagerpb"
 )
 
-func getFreeResources(nodeIP string, pod PodRequestedQuota) ([]FreeDeviceResources, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+// getFreeResources returns the free resources and the health of the vendor
+// and model devices on nodeName. Its failures are tracked in sp.dmFailures.
+func (sp *ShareDevPlugin) getFreeResources(nodeName, endpoint, vendor, model string) (_ []FreeDeviceResources, err error) {
+	defer func() {
+		sp.dmFailures.observe(nodeName, err)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), sp.getAvailableDevicesTimeout)
 	defer cancel()
 
-	conn, err := grpc.DialContext(
-		ctx,
-		fmt.Sprintf("%s:%s", nodeIP, deviceManagerPort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	client, err := sp.deviceManagers.get(nodeName, endpoint)
 	if err != nil {
-		return nil, fmt.Errorf("did not connect: %v", err)
+		return nil, err
 	}
-	defer conn.Close()
 
-	grpc := pb.NewDeviceManagerClient(conn)
+	start := time.Now()
+	freeResources, err := availableDevices(ctx, client, vendor, model)
+	observeDeviceManagerRequest("GetAvailableDevices", nodeName, start, err)
+	return freeResources, err
+}
+
+// reservePodQuota reserves share on deviceId under a lease of sp.leaseSeconds,
+// if any. It returns when the lease expires, zero if the quota is reserved
+// until it is released.
+func (sp *ShareDevPlugin) reservePodQuota(nodeName, endpoint, deviceId string, share ShareQuota) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sp.reservePodQuotaTimeout)
+	defer cancel()
+
+	client, err := sp.deviceManagers.get(nodeName, endpoint)
+	if err != nil {
+		return time.Time{}, err
+	}
 
-	resp, err := grpc.GetAvailableDevices(ctx, &pb.GetAvailableDevicesRequest{
-		Vendor: pod.Vendor,
-		Model:  pod.Model,
+	start := time.Now()
+	resp, err := client.ReservePodQuotaLease(ctx, &dmpb.ReservePodQuotaLeaseRequest{
+		DeviceId:     deviceId,
+		PodId:        share.ClientId,
+		Requests:     share.Requests,
+		Memory:       share.Memory,
+		Limit:        share.Limits,
+		LeaseSeconds: sp.leaseSeconds,
 	})
+	observeDeviceManagerRequest("ReservePodQuota", nodeName, start, err)
 	if err != nil {
-		return nil, err
+		return time.Time{}, err
+	}
+	return expiry(resp.ExpiresAt), nil
+}
+
+// renewPodQuota extends the lease of the quota of clientId on deviceId.
+func (sp *ShareDevPlugin) renewPodQuota(nodeName, endpoint, deviceId, clientId string) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sp.reservePodQuotaTimeout)
+	defer cancel()
+
+	client, err := sp.deviceManagers.get(nodeName, endpoint)
+	if err != nil {
+		return time.Time{}, err
 	}
 
-	freeResources := []FreeDeviceResources{}
-	for _, free := range resp.Free {
-		freeResources = append(freeResources, FreeDeviceResources{
-			DeviceId: free.DeviceId,
-			Requests: free.Requests,
-			Memory:   free.Memory,
-		})
+	start := time.Now()
+	resp, err := client.RenewPodQuota(ctx, &dmpb.RenewPodQuotaRequest{
+		DeviceId:     deviceId,
+		PodId:        clientId,
+		LeaseSeconds: sp.leaseSeconds,
+	})
+	observeDeviceManagerRequest("RenewPodQuota", nodeName, start, err)
+	if err != nil {
+		return time.Time{}, err
 	}
+	return expiry(resp.ExpiresAt), nil
+}
 
-	return freeResources, nil
+// expiry converts the Unix seconds of a lease expiry, 0 meaning no lease.
+func expiry(expiresAt int64) time.Time {
+	if expiresAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(expiresAt, 0)
 }
 
-func reservePodQuota(nodeIP, deviceId string, pod PodRequestedQuota) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (sp *ShareDevPlugin) unreservePodQuota(nodeName, endpoint, deviceId, podId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), sp.reservePodQuotaTimeout)
 	defer cancel()
 
-	conn, err := grpc.DialContext(
-		ctx,
-		fmt.Sprintf("%s:%s", nodeIP, deviceManagerPort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	client, err := sp.deviceManagers.get(nodeName, endpoint)
 	if err != nil {
-		return fmt.Errorf("did not connect: %v", err)
+		return err
 	}
-	defer conn.Close()
 
-	grpc := pb.NewDeviceManagerClient(conn)
-
-	_, err = grpc.ReservePodQuota(ctx, &pb.ReservePodQuotaRequest{
+	start := time.Now()
+	_, err = client.UnreservePodQuota(ctx, &pb.UnreservePodQuotaRequest{
 		DeviceId: deviceId,
-		PodId:    pod.PodId,
-		Requests: pod.Requests,
-		Memory:   pod.Memory,
-		Limit:    pod.Limits,
+		PodId:    podId,
 	})
+	observeDeviceManagerRequest("UnreservePodQuota", nodeName, start, err)
 	return err
 }
+
+func (sp *ShareDevPlugin) listReservations(nodeName, endpoint string) ([]*dmpb.Reservation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sp.getAvailableDevicesTimeout)
+	defer cancel()
+
+	client, err := sp.deviceManagers.get(nodeName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := client.ListReservations(ctx, &dmpb.ListReservationsRequest{})
+	observeDeviceManagerRequest("ListReservations", nodeName, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Reservations, nil
+}
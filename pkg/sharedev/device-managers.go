@@ -0,0 +1,82 @@
+package sharedev
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	v1 "k8s.io/api/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+
+	"sigs.k8s.io/scheduler-plugins/apis/config"
+	dmpb "sigs.k8s.io/scheduler-plugins/pkg/sharedev/devicemanagerpb"
+)
+
+// DeviceManagers reads the devices of the nodes from their device managers,
+// found and dialed the way the plugin configured with the same args does, so
+// that components outside of the scheduler see the same devices.
+type DeviceManagers struct {
+	resolver endpointResolver
+	pool     *deviceManagerPool
+	timeout  time.Duration
+}
+
+// NewDeviceManagers returns the device managers of the plugin configured with
+// args. podLister is only used to find the device manager pods of the Pod
+// endpoint type. Endpoints are resolved again on every call. dialOpts are
+// used on top of the credentials of args.
+func NewDeviceManagers(args *config.ShareDevPluginArgs, podLister corelisters.PodLister, dialOpts ...grpc.DialOption) (*DeviceManagers, error) {
+	resolver, err := newEndpointResolver(args.DeviceManagerEndpoint, args.DeviceManagerPort, podLister)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := newTransportCredentials(args.DeviceManagerTLS)
+	if err != nil {
+		return nil, err
+	}
+	return &DeviceManagers{
+		resolver: resolver,
+		pool:     newDeviceManagerPool(append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, dialOpts...)...),
+		timeout:  time.Duration(args.GetAvailableDevicesTimeoutSeconds) * time.Second,
+	}, nil
+}
+
+// FreeDevices returns the devices of vendor and model on node and their free
+// resources.
+func (d *DeviceManagers) FreeDevices(ctx context.Context, node *v1.Node, vendor, model string) ([]FreeDeviceResources, error) {
+	endpoint, err := d.resolver.resolve(node)
+	if err != nil {
+		return nil, err
+	}
+	client, err := d.pool.get(node.Name, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+	return availableDevices(ctx, client, vendor, model)
+}
+
+// availableDevices returns the devices of vendor and model the device manager
+// of client has and their free resources.
+func availableDevices(ctx context.Context, client dmpb.DeviceManagerClient, vendor, model string) ([]FreeDeviceResources, error) {
+	resp, err := client.GetAvailableDevicesHealth(ctx, &dmpb.GetAvailableDevicesHealthRequest{
+		Vendor: vendor,
+		Model:  model,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	freeResources := []FreeDeviceResources{}
+	for _, free := range resp.Free {
+		freeResources = append(freeResources, FreeDeviceResources{
+			DeviceId:      free.DeviceId,
+			Requests:      free.Requests,
+			Memory:        free.Memory,
+			Unhealthy:     free.Unhealthy,
+			HealthMessage: free.HealthMessage,
+		})
+	}
+	return freeResources, nil
+}
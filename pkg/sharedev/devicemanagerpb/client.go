@@ -0,0 +1,97 @@
+// Package devicemanagerpb extends the DeviceManager service of
+// github.com/zbsss/device-manager/generated with the DeviceManagerExtensions
+// service of device-manager-extensions.proto, for what the released device
+// manager API doesn't have yet: listing the reservations, reserving quota
+// under a lease that expires unless renewed, and the health of the devices.
+package devicemanagerpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative device-manager-extensions.proto
+
+import (
+	"context"
+
+	pb "github.com/zbsss/device-manager/generated"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DeviceManagerClient is the client of the DeviceManager service and of its
+// extensions.
+type DeviceManagerClient interface {
+	pb.DeviceManagerClient
+	DeviceManagerExtensionsClient
+}
+
+// deviceManagerClient falls back on the DeviceManager RPCs when the device
+// manager doesn't serve the extensions: the quota is reserved until it is
+// released and the devices are healthy.
+type deviceManagerClient struct {
+	pb.DeviceManagerClient
+	DeviceManagerExtensionsClient
+}
+
+func NewDeviceManagerClient(cc grpc.ClientConnInterface) DeviceManagerClient {
+	return &deviceManagerClient{
+		DeviceManagerClient:           pb.NewDeviceManagerClient(cc),
+		DeviceManagerExtensionsClient: NewDeviceManagerExtensionsClient(cc),
+	}
+}
+
+// ReservePodQuotaLease calls ReservePodQuota, without lease, if the device
+// manager doesn't serve the extensions. The reply then has no expiry.
+func (c *deviceManagerClient) ReservePodQuotaLease(ctx context.Context, in *ReservePodQuotaLeaseRequest, opts ...grpc.CallOption) (*ReservePodQuotaLeaseReply, error) {
+	reply, err := c.DeviceManagerExtensionsClient.ReservePodQuotaLease(ctx, in, opts...)
+	if status.Code(err) != codes.Unimplemented {
+		return reply, err
+	}
+	_, err = c.DeviceManagerClient.ReservePodQuota(ctx, &pb.ReservePodQuotaRequest{
+		DeviceId: in.DeviceId,
+		PodId:    in.PodId,
+		Requests: in.Requests,
+		Limit:    in.Limit,
+		Memory:   in.Memory,
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &ReservePodQuotaLeaseReply{}, nil
+}
+
+// GetAvailableDevicesHealth calls GetAvailableDevices if the device manager
+// doesn't serve the extensions. All devices are then healthy.
+func (c *deviceManagerClient) GetAvailableDevicesHealth(ctx context.Context, in *GetAvailableDevicesHealthRequest, opts ...grpc.CallOption) (*GetAvailableDevicesHealthReply, error) {
+	reply, err := c.DeviceManagerExtensionsClient.GetAvailableDevicesHealth(ctx, in, opts...)
+	if status.Code(err) != codes.Unimplemented {
+		return reply, err
+	}
+	resp, err := c.DeviceManagerClient.GetAvailableDevices(ctx, &pb.GetAvailableDevicesRequest{
+		Vendor: in.Vendor,
+		Model:  in.Model,
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	reply = &GetAvailableDevicesHealthReply{}
+	for _, free := range resp.Free {
+		reply.Free = append(reply.Free, &FreeDeviceResourcesHealth{
+			DeviceId: free.DeviceId,
+			Memory:   free.Memory,
+			Requests: free.Requests,
+		})
+	}
+	return reply, nil
+}
+
+// DeviceManagerServer is the server API of the DeviceManager service and of
+// its extensions.
+type DeviceManagerServer interface {
+	pb.DeviceManagerServer
+	DeviceManagerExtensionsServer
+}
+
+// RegisterDeviceManagerServer registers srv for both services.
+func RegisterDeviceManagerServer(s grpc.ServiceRegistrar, srv DeviceManagerServer) {
+	pb.RegisterDeviceManagerServer(s, srv)
+	RegisterDeviceManagerExtensionsServer(s, srv)
+}
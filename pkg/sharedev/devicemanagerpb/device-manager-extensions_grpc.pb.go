@@ -0,0 +1,224 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: device-manager-extensions.proto
+
+package devicemanagerpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// DeviceManagerExtensionsClient is the client API for DeviceManagerExtensions service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DeviceManagerExtensionsClient interface {
+	// ListReservations returns the quota reserved on every device.
+	ListReservations(ctx context.Context, in *ListReservationsRequest, opts ...grpc.CallOption) (*ListReservationsReply, error)
+	// ReservePodQuotaLease is DeviceManager.ReservePodQuota with a lease.
+	ReservePodQuotaLease(ctx context.Context, in *ReservePodQuotaLeaseRequest, opts ...grpc.CallOption) (*ReservePodQuotaLeaseReply, error)
+	// RenewPodQuota extends the lease of the quota of a client on a device.
+	RenewPodQuota(ctx context.Context, in *RenewPodQuotaRequest, opts ...grpc.CallOption) (*RenewPodQuotaReply, error)
+	// GetAvailableDevicesHealth is DeviceManager.GetAvailableDevices with the
+	// health of the devices.
+	GetAvailableDevicesHealth(ctx context.Context, in *GetAvailableDevicesHealthRequest, opts ...grpc.CallOption) (*GetAvailableDevicesHealthReply, error)
+}
+
+type deviceManagerExtensionsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDeviceManagerExtensionsClient(cc grpc.ClientConnInterface) DeviceManagerExtensionsClient {
+	return &deviceManagerExtensionsClient{cc}
+}
+
+func (c *deviceManagerExtensionsClient) ListReservations(ctx context.Context, in *ListReservationsRequest, opts ...grpc.CallOption) (*ListReservationsReply, error) {
+	out := new(ListReservationsReply)
+	err := c.cc.Invoke(ctx, "/sharedev.v1alpha1.DeviceManagerExtensions/ListReservations", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceManagerExtensionsClient) ReservePodQuotaLease(ctx context.Context, in *ReservePodQuotaLeaseRequest, opts ...grpc.CallOption) (*ReservePodQuotaLeaseReply, error) {
+	out := new(ReservePodQuotaLeaseReply)
+	err := c.cc.Invoke(ctx, "/sharedev.v1alpha1.DeviceManagerExtensions/ReservePodQuotaLease", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceManagerExtensionsClient) RenewPodQuota(ctx context.Context, in *RenewPodQuotaRequest, opts ...grpc.CallOption) (*RenewPodQuotaReply, error) {
+	out := new(RenewPodQuotaReply)
+	err := c.cc.Invoke(ctx, "/sharedev.v1alpha1.DeviceManagerExtensions/RenewPodQuota", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceManagerExtensionsClient) GetAvailableDevicesHealth(ctx context.Context, in *GetAvailableDevicesHealthRequest, opts ...grpc.CallOption) (*GetAvailableDevicesHealthReply, error) {
+	out := new(GetAvailableDevicesHealthReply)
+	err := c.cc.Invoke(ctx, "/sharedev.v1alpha1.DeviceManagerExtensions/GetAvailableDevicesHealth", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeviceManagerExtensionsServer is the server API for DeviceManagerExtensions service.
+// All implementations must embed UnimplementedDeviceManagerExtensionsServer
+// for forward compatibility
+type DeviceManagerExtensionsServer interface {
+	// ListReservations returns the quota reserved on every device.
+	ListReservations(context.Context, *ListReservationsRequest) (*ListReservationsReply, error)
+	// ReservePodQuotaLease is DeviceManager.ReservePodQuota with a lease.
+	ReservePodQuotaLease(context.Context, *ReservePodQuotaLeaseRequest) (*ReservePodQuotaLeaseReply, error)
+	// RenewPodQuota extends the lease of the quota of a client on a device.
+	RenewPodQuota(context.Context, *RenewPodQuotaRequest) (*RenewPodQuotaReply, error)
+	// GetAvailableDevicesHealth is DeviceManager.GetAvailableDevices with the
+	// health of the devices.
+	GetAvailableDevicesHealth(context.Context, *GetAvailableDevicesHealthRequest) (*GetAvailableDevicesHealthReply, error)
+	mustEmbedUnimplementedDeviceManagerExtensionsServer()
+}
+
+// UnimplementedDeviceManagerExtensionsServer must be embedded to have forward compatible implementations.
+type UnimplementedDeviceManagerExtensionsServer struct {
+}
+
+func (UnimplementedDeviceManagerExtensionsServer) ListReservations(context.Context, *ListReservationsRequest) (*ListReservationsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListReservations not implemented")
+}
+func (UnimplementedDeviceManagerExtensionsServer) ReservePodQuotaLease(context.Context, *ReservePodQuotaLeaseRequest) (*ReservePodQuotaLeaseReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReservePodQuotaLease not implemented")
+}
+func (UnimplementedDeviceManagerExtensionsServer) RenewPodQuota(context.Context, *RenewPodQuotaRequest) (*RenewPodQuotaReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenewPodQuota not implemented")
+}
+func (UnimplementedDeviceManagerExtensionsServer) GetAvailableDevicesHealth(context.Context, *GetAvailableDevicesHealthRequest) (*GetAvailableDevicesHealthReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAvailableDevicesHealth not implemented")
+}
+func (UnimplementedDeviceManagerExtensionsServer) mustEmbedUnimplementedDeviceManagerExtensionsServer() {
+}
+
+// UnsafeDeviceManagerExtensionsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DeviceManagerExtensionsServer will
+// result in compilation errors.
+type UnsafeDeviceManagerExtensionsServer interface {
+	mustEmbedUnimplementedDeviceManagerExtensionsServer()
+}
+
+func RegisterDeviceManagerExtensionsServer(s grpc.ServiceRegistrar, srv DeviceManagerExtensionsServer) {
+	s.RegisterService(&DeviceManagerExtensions_ServiceDesc, srv)
+}
+
+func _DeviceManagerExtensions_ListReservations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListReservationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceManagerExtensionsServer).ListReservations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sharedev.v1alpha1.DeviceManagerExtensions/ListReservations",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceManagerExtensionsServer).ListReservations(ctx, req.(*ListReservationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeviceManagerExtensions_ReservePodQuotaLease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReservePodQuotaLeaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceManagerExtensionsServer).ReservePodQuotaLease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sharedev.v1alpha1.DeviceManagerExtensions/ReservePodQuotaLease",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceManagerExtensionsServer).ReservePodQuotaLease(ctx, req.(*ReservePodQuotaLeaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeviceManagerExtensions_RenewPodQuota_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenewPodQuotaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceManagerExtensionsServer).RenewPodQuota(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sharedev.v1alpha1.DeviceManagerExtensions/RenewPodQuota",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceManagerExtensionsServer).RenewPodQuota(ctx, req.(*RenewPodQuotaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeviceManagerExtensions_GetAvailableDevicesHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAvailableDevicesHealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceManagerExtensionsServer).GetAvailableDevicesHealth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sharedev.v1alpha1.DeviceManagerExtensions/GetAvailableDevicesHealth",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceManagerExtensionsServer).GetAvailableDevicesHealth(ctx, req.(*GetAvailableDevicesHealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// DeviceManagerExtensions_ServiceDesc is the grpc.ServiceDesc for DeviceManagerExtensions service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DeviceManagerExtensions_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "sharedev.v1alpha1.DeviceManagerExtensions",
+	HandlerType: (*DeviceManagerExtensionsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListReservations",
+			Handler:    _DeviceManagerExtensions_ListReservations_Handler,
+		},
+		{
+			MethodName: "ReservePodQuotaLease",
+			Handler:    _DeviceManagerExtensions_ReservePodQuotaLease_Handler,
+		},
+		{
+			MethodName: "RenewPodQuota",
+			Handler:    _DeviceManagerExtensions_RenewPodQuota_Handler,
+		},
+		{
+			MethodName: "GetAvailableDevicesHealth",
+			Handler:    _DeviceManagerExtensions_GetAvailableDevicesHealth_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "device-manager-extensions.proto",
+}
@@ -0,0 +1,230 @@
+package sharedev
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"text/template"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/scheduler-plugins/apis/config"
+)
+
+// endpointResolver finds the "host:port" address of the device manager on a node.
+type endpointResolver interface {
+	resolve(node *v1.Node) (string, error)
+}
+
+// newEndpointResolver returns the resolver configured by endpoint.
+func newEndpointResolver(endpoint config.DeviceManagerEndpoint, port int32, podLister corelisters.PodLister) (endpointResolver, error) {
+	switch endpoint.Type {
+	case config.NodeAddressEndpoint, "":
+		return &nodeAddressResolver{port: port}, nil
+	case config.NodeAnnotationEndpoint:
+		return &nodeAnnotationResolver{annotation: endpoint.Annotation, port: port}, nil
+	case config.PodEndpoint:
+		selector, err := labels.Parse(endpoint.PodSelector)
+		if err != nil {
+			return nil, err
+		}
+		return &podResolver{lister: podLister, namespace: endpoint.PodNamespace, selector: selector, port: port}, nil
+	case config.TemplateEndpoint:
+		tmpl, err := template.New("endpoint").Option("missingkey=error").Parse(endpoint.Template)
+		if err != nil {
+			return nil, err
+		}
+		return &templateResolver{template: tmpl, port: port}, nil
+	default:
+		return nil, fmt.Errorf("unknown device manager endpoint type %q", endpoint.Type)
+	}
+}
+
+// withPort adds the default port to hostport if it has none.
+func withPort(hostport string, port int32) string {
+	if _, _, err := net.SplitHostPort(hostport); err == nil {
+		return hostport
+	}
+	return net.JoinHostPort(hostport, strconv.Itoa(int(port)))
+}
+
+// endpointHost returns the host of a "host:port" endpoint.
+func endpointHost(endpoint string) string {
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	return host
+}
+
+// nodeAddress returns the InternalIP of the node, or its ExternalIP if it has none.
+func nodeAddress(node *v1.Node) string {
+	var external string
+	for _, addr := range node.Status.Addresses {
+		switch addr.Type {
+		case v1.NodeInternalIP:
+			return addr.Address
+		case v1.NodeExternalIP:
+			if external == "" {
+				external = addr.Address
+			}
+		}
+	}
+	return external
+}
+
+// nodeAddressResolver dials the device manager on the node's own address.
+type nodeAddressResolver struct {
+	port int32
+}
+
+func (r *nodeAddressResolver) resolve(node *v1.Node) (string, error) {
+	address := nodeAddress(node)
+	if address == "" {
+		return "", fmt.Errorf("node %s has no InternalIP or ExternalIP", node.Name)
+	}
+	return withPort(address, r.port), nil
+}
+
+// nodeAnnotationResolver takes the endpoint from a node annotation.
+type nodeAnnotationResolver struct {
+	annotation string
+	port       int32
+}
+
+func (r *nodeAnnotationResolver) resolve(node *v1.Node) (string, error) {
+	endpoint := node.Annotations[r.annotation]
+	if endpoint == "" {
+		return "", fmt.Errorf("node %s has no %s annotation", node.Name, r.annotation)
+	}
+	return withPort(endpoint, r.port), nil
+}
+
+// podResolver dials the running device manager pod on the node, e.g. a
+// DaemonSet pod that doesn't use the host network.
+type podResolver struct {
+	lister    corelisters.PodLister
+	namespace string
+	selector  labels.Selector
+	port      int32
+}
+
+func (r *podResolver) resolve(node *v1.Node) (string, error) {
+	pods, err := r.lister.Pods(r.namespace).List(r.selector)
+	if err != nil {
+		return "", err
+	}
+	for _, pod := range pods {
+		if pod.Spec.NodeName == node.Name && pod.DeletionTimestamp == nil &&
+			pod.Status.Phase == v1.PodRunning && pod.Status.PodIP != "" {
+			return withPort(pod.Status.PodIP, r.port), nil
+		}
+	}
+	return "", fmt.Errorf("no running device manager pod %s in namespace %s on node %s", r.selector, r.namespace, node.Name)
+}
+
+// matches tells whether pod is one of the device manager pods.
+func (r *podResolver) matches(pod *v1.Pod) bool {
+	return pod.Namespace == r.namespace && r.selector.Matches(labels.Set(pod.Labels))
+}
+
+// templateResolver builds the endpoint from the node, e.g. a per-node DNS name.
+type templateResolver struct {
+	template *template.Template
+	port     int32
+}
+
+func (r *templateResolver) resolve(node *v1.Node) (string, error) {
+	var buf bytes.Buffer
+	err := r.template.Execute(&buf, struct {
+		NodeName   string
+		InternalIP string
+	}{
+		NodeName:   node.Name,
+		InternalIP: nodeAddress(node),
+	})
+	if err != nil {
+		return "", fmt.Errorf("error building the device manager endpoint of node %s: %w", node.Name, err)
+	}
+	return withPort(buf.String(), r.port), nil
+}
+
+// endpointCache caches the resolved device manager endpoint of every node.
+// Node and device manager pod events drop the entries they may change.
+type endpointCache struct {
+	resolver endpointResolver
+
+	lock      sync.Mutex
+	endpoints map[string]string
+}
+
+func newEndpointCache(resolver endpointResolver) *endpointCache {
+	return &endpointCache{
+		resolver:  resolver,
+		endpoints: map[string]string{},
+	}
+}
+
+// get returns the endpoint of the device manager on node. Failures aren't
+// cached, the node is resolved again next time.
+func (c *endpointCache) get(node *v1.Node) (string, error) {
+	c.lock.Lock()
+	endpoint, ok := c.endpoints[node.Name]
+	c.lock.Unlock()
+	if ok {
+		return endpoint, nil
+	}
+
+	endpoint, err := c.resolver.resolve(node)
+	if err != nil {
+		return "", err
+	}
+	c.lock.Lock()
+	c.endpoints[node.Name] = endpoint
+	c.lock.Unlock()
+	return endpoint, nil
+}
+
+// invalidate makes the next get for nodeName resolve it again.
+func (c *endpointCache) invalidate(nodeName string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.endpoints, nodeName)
+}
+
+// updateNode is the node informer's update handler.
+func (c *endpointCache) updateNode(_, newObj interface{}) {
+	if node, ok := newObj.(*v1.Node); ok {
+		c.invalidate(node.Name)
+	}
+}
+
+// updatePod is the pod informer's handler, it drops the node of a device
+// manager pod that came, changed or left.
+func (c *endpointCache) updatePod(obj interface{}) {
+	r, ok := c.resolver.(*podResolver)
+	if !ok {
+		return
+	}
+	var pod *v1.Pod
+	switch t := obj.(type) {
+	case *v1.Pod:
+		pod = t
+	case cache.DeletedFinalStateUnknown:
+		if pod, ok = t.Obj.(*v1.Pod); !ok {
+			klog.ErrorS(nil, "Cannot convert to *v1.Pod", "obj", t.Obj)
+			return
+		}
+	default:
+		return
+	}
+	if r.matches(pod) && pod.Spec.NodeName != "" {
+		c.invalidate(pod.Spec.NodeName)
+	}
+}
@@ -0,0 +1,191 @@
+package sharedev
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/scheduler-plugins/apis/config"
+)
+
+func makeEndpointNode(name string, annotations map[string]string, addresses ...v1.NodeAddress) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: annotations},
+		Status:     v1.NodeStatus{Addresses: addresses},
+	}
+}
+
+func makeDeviceManagerPod(name, nodeName, podIP string, phase v1.PodPhase) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "device-manager", Labels: map[string]string{"app": "device-manager"}},
+		Spec:       v1.PodSpec{NodeName: nodeName},
+		Status:     v1.PodStatus{Phase: phase, PodIP: podIP},
+	}
+}
+
+func TestEndpointResolver(t *testing.T) {
+	internal := v1.NodeAddress{Type: v1.NodeInternalIP, Address: "10.0.0.1"}
+	external := v1.NodeAddress{Type: v1.NodeExternalIP, Address: "203.0.113.1"}
+	annotation := "scheduling.x-k8s.io/device-manager-endpoint"
+	podEndpoint := config.DeviceManagerEndpoint{Type: config.PodEndpoint, PodNamespace: "device-manager", PodSelector: "app=device-manager"}
+
+	tests := []struct {
+		name     string
+		endpoint config.DeviceManagerEndpoint
+		node     *v1.Node
+		pods     []*v1.Pod
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "node InternalIP",
+			endpoint: config.DeviceManagerEndpoint{Type: config.NodeAddressEndpoint},
+			node:     makeEndpointNode("node1", nil, external, internal),
+			want:     "10.0.0.1:50051",
+		},
+		{
+			name:     "node ExternalIP without InternalIP",
+			endpoint: config.DeviceManagerEndpoint{Type: config.NodeAddressEndpoint},
+			node:     makeEndpointNode("node1", nil, external),
+			want:     "203.0.113.1:50051",
+		},
+		{
+			name:     "IPv6 node address",
+			endpoint: config.DeviceManagerEndpoint{Type: config.NodeAddressEndpoint},
+			node:     makeEndpointNode("node1", nil, v1.NodeAddress{Type: v1.NodeInternalIP, Address: "fd00::1"}),
+			want:     "[fd00::1]:50051",
+		},
+		{
+			name:     "node without address",
+			endpoint: config.DeviceManagerEndpoint{Type: config.NodeAddressEndpoint},
+			node:     makeEndpointNode("node1", nil),
+			wantErr:  true,
+		},
+		{
+			name:     "node annotation",
+			endpoint: config.DeviceManagerEndpoint{Type: config.NodeAnnotationEndpoint, Annotation: annotation},
+			node:     makeEndpointNode("node1", map[string]string{annotation: "10.1.0.1"}, internal),
+			want:     "10.1.0.1:50051",
+		},
+		{
+			name:     "node annotation with port",
+			endpoint: config.DeviceManagerEndpoint{Type: config.NodeAnnotationEndpoint, Annotation: annotation},
+			node:     makeEndpointNode("node1", map[string]string{annotation: "dm.node1.example.com:6000"}, internal),
+			want:     "dm.node1.example.com:6000",
+		},
+		{
+			name:     "node without annotation",
+			endpoint: config.DeviceManagerEndpoint{Type: config.NodeAnnotationEndpoint, Annotation: annotation},
+			node:     makeEndpointNode("node1", nil, internal),
+			wantErr:  true,
+		},
+		{
+			name:     "running device manager pod",
+			endpoint: podEndpoint,
+			node:     makeEndpointNode("node1", nil, internal),
+			pods: []*v1.Pod{
+				makeDeviceManagerPod("dm-node2", "node2", "10.244.2.5", v1.PodRunning),
+				makeDeviceManagerPod("dm-pending", "node1", "", v1.PodPending),
+				makeDeviceManagerPod("dm-node1", "node1", "10.244.1.5", v1.PodRunning),
+			},
+			want: "10.244.1.5:50051",
+		},
+		{
+			name:     "no running device manager pod",
+			endpoint: podEndpoint,
+			node:     makeEndpointNode("node1", nil, internal),
+			pods:     []*v1.Pod{makeDeviceManagerPod("dm-pending", "node1", "", v1.PodPending)},
+			wantErr:  true,
+		},
+		{
+			name:     "template",
+			endpoint: config.DeviceManagerEndpoint{Type: config.TemplateEndpoint, Template: "dm-{{ .NodeName }}.device-manager.svc"},
+			node:     makeEndpointNode("node1", nil, internal),
+			want:     "dm-node1.device-manager.svc:50051",
+		},
+		{
+			name:     "template with node address and port",
+			endpoint: config.DeviceManagerEndpoint{Type: config.TemplateEndpoint, Template: "{{ .InternalIP }}:6000"},
+			node:     makeEndpointNode("node1", nil, internal),
+			want:     "10.0.0.1:6000",
+		},
+		{
+			name:     "template with unknown field",
+			endpoint: config.DeviceManagerEndpoint{Type: config.TemplateEndpoint, Template: "{{ .Zone }}"},
+			node:     makeEndpointNode("node1", nil, internal),
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, pod := range tt.pods {
+				indexer.Add(pod)
+			}
+			resolver, err := newEndpointResolver(tt.endpoint, 50051, corelisters.NewPodLister(indexer))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := resolver.resolve(tt.node)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected endpoint %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEndpointCache(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	resolver, err := newEndpointResolver(config.DeviceManagerEndpoint{
+		Type:         config.PodEndpoint,
+		PodNamespace: "device-manager",
+		PodSelector:  "app=device-manager",
+	}, 50051, corelisters.NewPodLister(indexer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newEndpointCache(resolver)
+	node := makeEndpointNode("node1", nil)
+
+	if _, err := c.get(node); err == nil {
+		t.Fatalf("expected an error without a device manager pod")
+	}
+
+	pod := makeDeviceManagerPod("dm-node1", "node1", "10.244.1.5", v1.PodRunning)
+	indexer.Add(pod)
+	if got, err := c.get(node); err != nil || got != "10.244.1.5:50051" {
+		t.Fatalf("expected endpoint 10.244.1.5:50051, got %q, %v", got, err)
+	}
+
+	// The pod was replaced but the cache wasn't told yet.
+	indexer.Delete(pod)
+	pod = makeDeviceManagerPod("dm-node1-new", "node1", "10.244.1.6", v1.PodRunning)
+	indexer.Add(pod)
+	if got, _ := c.get(node); got != "10.244.1.5:50051" {
+		t.Errorf("expected the cached endpoint 10.244.1.5:50051, got %q", got)
+	}
+
+	// Other pods don't drop the entry.
+	c.updatePod(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "device-manager"}, Spec: v1.PodSpec{NodeName: "node1"}})
+	if got, _ := c.get(node); got != "10.244.1.5:50051" {
+		t.Errorf("expected the cached endpoint 10.244.1.5:50051, got %q", got)
+	}
+
+	c.updatePod(cache.DeletedFinalStateUnknown{Key: "device-manager/dm-node1", Obj: makeDeviceManagerPod("dm-node1", "node1", "10.244.1.5", v1.PodRunning)})
+	if got, _ := c.get(node); got != "10.244.1.6:50051" {
+		t.Errorf("expected the new endpoint 10.244.1.6:50051, got %q", got)
+	}
+
+	c.updateNode(nil, node)
+	if _, ok := c.endpoints["node1"]; ok {
+		t.Errorf("expected a node update to drop the cached endpoint")
+	}
+}
@@ -3,44 +3,63 @@ package sharedev
 import (
 	"context"
 	"fmt"
-	"log"
-	"strconv"
+	"math"
+	"strings"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"sigs.k8s.io/scheduler-plugins/apis/config"
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
 )
 
 func (sp *ShareDevPlugin) PreFilterExtensions() framework.PreFilterExtensions {
-	return nil
+	return sp
 }
 
 func (sp *ShareDevPlugin) PreFilter(ctx context.Context, state *framework.CycleState, pod *v1.Pod) (*framework.PreFilterResult, *framework.Status) {
-	log.Println("ShareDevPlugin Hello world")
-	log.Println("ShareDevPlugin PreFilter is working!!")
+	logger := klog.FromContext(ctx)
 
-	s, _ := getShareDevState(state)
-	if s != nil {
-		log.Printf("ShareDevPlugin PreFilter: State: %v", s)
+	if s, _ := getShareDevState(state); s != nil {
+		logger.V(5).Info("Shared device request already parsed", "pod", klog.KObj(pod), "vendor", s.PodQ.Vendor, "model", s.PodQ.Model)
 		return nil, framework.NewStatus(framework.Success)
 	}
 
-	podQ, err := sp.parsePod(pod)
+	models, err := sp.parsePodModels(pod)
 	if err != nil {
-		log.Printf("ShareDevPlugin PreFilter: error parsing pod: %s", err.Error())
+		logger.V(4).Info("Invalid shared device request", "pod", klog.KObj(pod), "err", err)
+		sp.eventRecorder.Eventf(pod, nil, v1.EventTypeWarning, reasonInvalidRequest, actionScheduling, "%v", err)
 		return nil, framework.NewStatus(framework.Unschedulable, err.Error())
 	}
+	podQ := models[0]
+	logger.V(5).Info("Shared device request", "pod", klog.KObj(pod), "vendor", podQ.Vendor, "model", podQ.Model, "shares", len(podQ.Shares), "models", len(models))
+	sp.requests.record(podQ)
 
 	state.Write(ShareDevStateKey, &ShareDevState{
 		FreeDeviceResourcesPerNode: map[string][]FreeDeviceResources{},
-		NodeNameToIP:               map[string]string{},
-		PodQ:                       *podQ,
+		NodeNameToEndpoint:         map[string]string{},
+		PodQ:                       podQ,
+		Models:                     models,
+		NodeModels:                 map[string]int{},
 	})
 
+	// PostFilter provisions devices for the pod when its group doesn't fit.
+	if err := sp.checkPodGroup(ctx, pod, models); err != nil {
+		logger.V(4).Info("PodGroup doesn't fit the free shared devices", "pod", klog.KObj(pod), "err", err)
+		sp.eventRecorder.Eventf(pod, nil, v1.EventTypeWarning, reasonPodGroupDoesNotFit, actionScheduling, "%v", err)
+		return nil, framework.NewStatus(framework.Unschedulable, err.Error())
+	}
+
 	return nil, framework.NewStatus(framework.Success)
 }
 
 func (sp *ShareDevPlugin) Filter(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
-	log.Println("ShareDevPlugin Filter is working!!")
+	logger := klog.FromContext(ctx)
 
 	shareDevState, err := getShareDevState(state)
 	if err != nil {
@@ -48,89 +67,279 @@ func (sp *ShareDevPlugin) Filter(ctx context.Context, state *framework.CycleStat
 	}
 
 	nodeName := nodeInfo.Node().Name
-	var nodeIP string
-	for _, addr := range nodeInfo.Node().Status.Addresses {
-		if addr.Type == "InternalIP" {
-			nodeIP = addr.Address
-			break
-		}
-	}
-	if nodeIP == "" {
-		return framework.NewStatus(framework.Error, "node does not have InternalIP")
-	}
-	log.Println("ShareDevPlugin nodeIP: ", nodeIP)
-
-	freeResources, err := getFreeResources(nodeIP, shareDevState.PodQ)
+	endpoint, err := sp.endpoints.get(nodeInfo.Node())
 	if err != nil {
-		return framework.NewStatus(framework.Error, err.Error())
+		filterRejections.WithLabelValues(rejectEndpointNotFound).Inc()
+		return framework.NewStatus(framework.Unschedulable, fmt.Sprintf("device manager not found: %s", err.Error()))
 	}
-	if len(freeResources) == 0 {
-		return framework.NewStatus(framework.Unschedulable, "no resources available")
+
+	if failing, ok := sp.dmFailures.skip(nodeName); ok {
+		// Asked again once per retry period, by the next Filter or resync.
+		filterRejections.WithLabelValues(rejectDeviceManagerUnavailable).Inc()
+		return framework.NewStatus(framework.UnschedulableAndUnresolvable, fmt.Sprintf("device manager unreachable for %s", failing.Round(time.Second)))
 	}
 
-	shareDevState.FreeDeviceResourcesPerNode[nodeInfo.Node().Name] = freeResources
-	shareDevState.NodeNameToIP[nodeName] = nodeIP
-	log.Println("ShareDevPlugin freeResources: ", freeResources)
+	// The models the claim accepts are tried in preference order, the node
+	// keeps the first one the pod fits.
+	var getErr error
+	found, unhealthy := false, false
+	for i, podQ := range shareDevState.models() {
+		devices, err := sp.inventory.get(nodeName, endpoint, podQ.Vendor, podQ.Model)
+		if err != nil {
+			getErr = err
+			continue
+		}
+		freeResources := healthyDevices(devices)
+		if len(freeResources) < len(devices) {
+			logger.V(5).Info("Leaving out unhealthy shared devices", "pod", klog.KObj(pod), "node", nodeName, "vendor", podQ.Vendor, "model", podQ.Model, "unhealthy", len(devices)-len(freeResources))
+			unhealthy = true
+		}
+		if len(freeResources) == 0 {
+			continue
+		}
+		freeResources = withReleased(freeResources, shareDevState.Released[nodeName])
+		freeResources = withNominated(freeResources, shareDevState.Nominated[nodeName], podQ, sp.scoreDevice)
+		logger.V(5).Info("Free shared devices", "pod", klog.KObj(pod), "node", nodeName, "endpoint", endpoint, "vendor", podQ.Vendor, "model", podQ.Model, "free", freeResources)
 
-	for _, free := range freeResources {
-		if podFits(shareDevState.PodQ, free) {
-			log.Printf("ShareDevPlugin Filter: pod %s fits device %s", pod.Name, free.DeviceId)
+		// All shares must fit at once, the pod can't use only some of them.
+		_, deviceIds := assignShares(podQ, freeResources, sp.scoreDevice)
+		if !found || deviceIds != nil {
+			shareDevState.setNode(nodeName, endpoint, freeResources)
+			found = true
+		}
+		if deviceIds != nil {
+			shareDevState.setModelOn(nodeName, i)
+			logger.V(4).Info("Pod fits shared devices", "pod", klog.KObj(pod), "node", nodeName, "vendor", podQ.Vendor, "model", podQ.Model, "devices", deviceIds)
 			return framework.NewStatus(framework.Success)
 		}
 	}
+	if getErr != nil {
+		if failing, ok := sp.dmFailures.unreachable(nodeName); ok {
+			logger.V(4).Info("Leaving out the node of an unreachable device manager", "node", nodeName, "failing", failing, "err", getErr)
+			filterRejections.WithLabelValues(rejectDeviceManagerUnavailable).Inc()
+			return framework.NewStatus(framework.UnschedulableAndUnresolvable, fmt.Sprintf("device manager unreachable for %s: %s", failing.Round(time.Second), getErr.Error()))
+		}
+		if !sp.deviceManagers.healthy(nodeName) {
+			// One unreachable device manager must not fail scheduling on every other node.
+			filterRejections.WithLabelValues(rejectDeviceManagerUnavailable).Inc()
+			return framework.NewStatus(framework.Unschedulable, fmt.Sprintf("device manager unavailable: %s", getErr.Error()))
+		}
+		return framework.NewStatus(framework.Error, getErr.Error())
+	}
+	if !found && unhealthy {
+		filterRejections.WithLabelValues(rejectUnhealthyDevices).Inc()
+		return framework.NewStatus(framework.Unschedulable, "no healthy devices")
+	}
+	if !found {
+		filterRejections.WithLabelValues(rejectNoDevices).Inc()
+		return framework.NewStatus(framework.Unschedulable, "no resources available")
+	}
 
 	// DONE: check if CLASSIC resources like CPU and memory are available, maybe use the normal Filter plugin for that?
 	// Yes, the default NodeResourcesFit plugin already implements filter
 
+	filterRejections.WithLabelValues(rejectInsufficientResources).Inc()
+	if sp.noFitPolicy != config.ProvisionNoFit {
+		// Preempting lower-priority clients of the devices may make room.
+		return framework.NewStatus(framework.Unschedulable, "no resources available")
+	}
 	return framework.NewStatus(framework.UnschedulableAndUnresolvable, "no resources available")
 }
 
 func (sp *ShareDevPlugin) PostFilter(ctx context.Context, state *framework.CycleState, pod *v1.Pod, filteredNodeStatusMap framework.NodeToStatusMap) (*framework.PostFilterResult, *framework.Status) {
-	log.Println("ShareDevPlugin PostFilter is working!!")
-
 	shareDevState, err := getShareDevState(state)
 	if err != nil {
 		return nil, framework.NewStatus(framework.Error, err.Error())
 	}
 
-	vendor := shareDevState.PodQ.Vendor
-	model := shareDevState.PodQ.Model
-
-	nodeName, err := sp.allocateNewDevice(vendor, model)
-	if err != nil {
-		log.Printf("ShareDevPlugin PostFilter: error allocating new device: %s", err.Error())
-		return nil, framework.NewStatus(framework.Error, err.Error())
+	if sp.noFitPolicy != config.ProvisionNoFit {
+		result, status := sp.preempt(ctx, state, pod, filteredNodeStatusMap)
+		if status.IsSuccess() || sp.noFitPolicy == config.PreemptNoFit {
+			return result, status
+		}
+		klog.FromContext(ctx).V(4).Info("Preempting can't make room for the pod, provisioning", "pod", klog.KObj(pod), "status", status)
 	}
 
-	return &framework.PostFilterResult{NominatingInfo: &framework.NominatingInfo{NominatedNodeName: nodeName}}, framework.NewStatus(framework.Success)
+	// Allocators are created in the background, the pod is retried once
+	// one of them is running.
+	podQ := sp.cheapestModel(shareDevState.models())
+	sp.eventRecorder.Eventf(pod, nil, v1.EventTypeWarning, reasonNoFittingDevice, actionScheduling,
+		"No node has free %s/%s devices for the %d share(s) of the pod", podQ.Vendor, podQ.Model, len(podQ.Shares))
+	inFlight := sp.provisioner.request(podQ)
+	klog.FromContext(ctx).V(4).Info("Waiting for new shared devices", "pod", klog.KObj(pod), "vendor", podQ.Vendor, "model", podQ.Model, "allocators", inFlight)
+	sp.eventRecorder.Eventf(pod, nil, v1.EventTypeNormal, reasonProvisioning, actionScheduling,
+		"Waiting for %d new %s/%s device(s)", inFlight, podQ.Vendor, podQ.Model)
+
+	return nil, framework.NewStatus(framework.Unschedulable, fmt.Sprintf("waiting for %d new %s/%s device(s)", inFlight, podQ.Vendor, podQ.Model))
 }
 
+// parsePod returns what the pod asks for on its claim's own model.
 func (sp *ShareDevPlugin) parsePod(pod *v1.Pod) (*PodRequestedQuota, error) {
-	if pod.Labels["sharedev.vendor"] == "" || pod.Labels["sharedev.model"] == "" {
-		return nil, fmt.Errorf("pod does not have sharedev.vendor or sharedev.model label")
+	models, err := sp.parsePodModels(pod)
+	if err != nil {
+		return nil, err
 	}
+	return &models[0], nil
+}
 
-	requests, err := strconv.ParseFloat(pod.Labels["sharedev.requests"], 64)
+// parsePodModels returns what the pod asks for on every model its claim
+// accepts, in preference order, the claim's own model first.
+func (sp *ShareDevPlugin) parsePodModels(pod *v1.Pod) ([]PodRequestedQuota, error) {
+	claimName := pod.Labels[v1alpha1.SharedDeviceClaimLabel]
+	if claimName == "" {
+		return nil, fmt.Errorf("pod does not have %s label", v1alpha1.SharedDeviceClaimLabel)
+	}
+
+	claim, err := sp.claimLister.SharedDeviceClaims(pod.Namespace).Get(claimName)
 	if err != nil {
-		return nil, fmt.Errorf("label sharedev.requests is not a float")
+		return nil, fmt.Errorf("error getting SharedDeviceClaim %s/%s: %w", pod.Namespace, claimName, err)
 	}
 
-	memory, err := strconv.ParseFloat(pod.Labels["sharedev.memory"], 64)
+	return claimToQuotas(pod, claim)
+}
+
+// claimToQuotas returns the quota of the pod on the claim's own model followed
+// by its fallback models, their compute shares and limits scaled. Fallback
+// models the scaled shares don't fit on are left out.
+func claimToQuotas(pod *v1.Pod, claim *v1alpha1.SharedDeviceClaim) ([]PodRequestedQuota, error) {
+	podQ, err := claimToQuota(pod, claim)
 	if err != nil {
-		return nil, fmt.Errorf("label sharedev.memory is not a float")
+		return nil, err
 	}
 
-	limits, err := strconv.ParseFloat(pod.Labels["sharedev.limits"], 64)
+	models := []PodRequestedQuota{*podQ}
+	seen := sets.NewString(modelName(podQ.Vendor, podQ.Model))
+	for _, m := range claim.Spec.Fallbacks {
+		if m.Vendor == "" || m.Model == "" {
+			return nil, fmt.Errorf("SharedDeviceClaim %s/%s fallback does not have vendor or model", claim.Namespace, claim.Name)
+		}
+		name := modelName(m.Vendor, m.Model)
+		if seen.Has(name) {
+			return nil, fmt.Errorf("SharedDeviceClaim %s/%s model %s is listed twice", claim.Namespace, claim.Name, name)
+		}
+		seen.Insert(name)
+
+		scale := 1.0
+		if m.ComputeScale != nil {
+			scale = m.ComputeScale.AsApproximateFloat64()
+			if scale <= 0 {
+				return nil, fmt.Errorf("SharedDeviceClaim %s/%s fallback %s compute scale must be positive, got %s", claim.Namespace, claim.Name, name, m.ComputeScale.String())
+			}
+		}
+		if q, ok := scaleQuota(*podQ, m.Vendor, m.Model, scale); ok {
+			models = append(models, q)
+		}
+	}
+	return models, nil
+}
+
+// scaleQuota returns podQ on devices of another model, its compute shares and
+// limits multiplied by scale. It returns false if a share doesn't fit a whole
+// device once scaled.
+func scaleQuota(podQ PodRequestedQuota, vendor, model string, scale float64) (PodRequestedQuota, bool) {
+	q := podQ
+	q.Vendor = vendor
+	q.Model = model
+	q.Shares = make([]ShareQuota, len(podQ.Shares))
+	for i, share := range podQ.Shares {
+		share.Requests *= scale
+		if share.Requests > 1 {
+			return PodRequestedQuota{}, false
+		}
+		share.Limits = math.Min(share.Limits*scale, 1)
+		q.Shares[i] = share
+	}
+	return q, true
+}
+
+// modelName is how a device model is written in messages and annotations.
+func modelName(vendor, model string) string {
+	return vendor + "/" + model
+}
+
+func claimToQuota(pod *v1.Pod, claim *v1alpha1.SharedDeviceClaim) (*PodRequestedQuota, error) {
+	spec := claim.Spec
+	if spec.Vendor == "" || spec.Model == "" {
+		return nil, fmt.Errorf("SharedDeviceClaim %s/%s does not have vendor or model", claim.Namespace, claim.Name)
+	}
+
+	share, err := parseShare(spec.Compute, spec.Memory, spec.Limit)
 	if err != nil {
-		limits = requests
+		return nil, fmt.Errorf("SharedDeviceClaim %s/%s %w", claim.Namespace, claim.Name, err)
+	}
+	clientId := podClientId(pod)
+	share.ClientId = clientId
+
+	count := 1
+	if spec.Count != nil {
+		if *spec.Count < 1 {
+			return nil, fmt.Errorf("SharedDeviceClaim %s/%s count must be at least 1, got %d", claim.Namespace, claim.Name, *spec.Count)
+		}
+		count = int(*spec.Count)
+	}
+
+	podQ := &PodRequestedQuota{
+		PodId:     clientId,
+		ClaimName: claim.Name,
+		Vendor:    spec.Vendor,
+		Model:     spec.Model,
+	}
+	for i := 0; i < count; i++ {
+		podQ.Shares = append(podQ.Shares, share)
+	}
+
+	containers := sets.NewString()
+	for _, c := range pod.Spec.Containers {
+		containers.Insert(c.Name)
+	}
+	seen := sets.NewString()
+	for _, c := range spec.Containers {
+		if !containers.Has(c.Name) || seen.Has(c.Name) {
+			return nil, fmt.Errorf("SharedDeviceClaim %s/%s container %q is not a container of the pod or listed twice", claim.Namespace, claim.Name, c.Name)
+		}
+		seen.Insert(c.Name)
+		if errs := validation.IsQualifiedName(v1alpha1.SharedDeviceContainerIDAnnotationPrefix + c.Name); len(errs) > 0 {
+			return nil, fmt.Errorf("SharedDeviceClaim %s/%s container %q name is too long: %s", claim.Namespace, claim.Name, c.Name, strings.Join(errs, ", "))
+		}
+
+		share, err := parseShare(c.Compute, c.Memory, c.Limit)
+		if err != nil {
+			return nil, fmt.Errorf("SharedDeviceClaim %s/%s container %q %w", claim.Namespace, claim.Name, c.Name, err)
+		}
+		share.ClientId = clientId + "." + c.Name
+		share.Container = c.Name
+		podQ.Shares = append(podQ.Shares, share)
+	}
+
+	return podQ, nil
+}
+
+// podClientId returns the id the shares of pod are reserved under on the
+// device manager. The UID keeps a pod recreated with the same name from
+// inheriting the reservations of the previous one.
+func podClientId(pod *v1.Pod) string {
+	return fmt.Sprintf("%s/%s/%s", pod.Namespace, pod.Name, pod.UID)
+}
+
+// parseShare checks the share of one device is at most the whole device.
+func parseShare(compute, memoryQ resource.Quantity, limit *resource.Quantity) (ShareQuota, error) {
+	requests := compute.AsApproximateFloat64()
+	if requests <= 0 || requests > 1 {
+		return ShareQuota{}, fmt.Errorf("compute must be in range (0, 1], got %s", compute.String())
+	}
+
+	memory := memoryQ.AsApproximateFloat64()
+	if memory <= 0 || memory > 1 {
+		return ShareQuota{}, fmt.Errorf("memory must be in range (0, 1], got %s", memoryQ.String())
+	}
+
+	limits := requests
+	if limit != nil {
+		limits = limit.AsApproximateFloat64()
+		if limits < requests || limits > 1 {
+			return ShareQuota{}, fmt.Errorf("limit must be in range [compute, 1], got %s", limit.String())
+		}
 	}
 
-	return &PodRequestedQuota{
-		PodId:    pod.Name,
-		Vendor:   pod.Labels["sharedev.vendor"],
-		Model:    pod.Labels["sharedev.model"],
-		Requests: requests,
-		Limits:   limits,
-		Memory:   memory,
-	}, nil
+	return ShareQuota{Requests: requests, Limits: limits, Memory: memory}, nil
 }
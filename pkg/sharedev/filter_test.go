@@ -0,0 +1,731 @@
+package sharedev
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	fakeclientset "sigs.k8s.io/scheduler-plugins/pkg/generated/clientset/versioned/fake"
+	schedinformer "sigs.k8s.io/scheduler-plugins/pkg/generated/informers/externalversions"
+	testutil "sigs.k8s.io/scheduler-plugins/test/util"
+)
+
+func makeClaim(name, compute, memory string, limit *resource.Quantity) *v1alpha1.SharedDeviceClaim {
+	return &v1alpha1.SharedDeviceClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+		Spec: v1alpha1.SharedDeviceClaimSpec{
+			Vendor:  "example.com",
+			Model:   "mydev",
+			Compute: resource.MustParse(compute),
+			Memory:  resource.MustParse(memory),
+			Limit:   limit,
+		},
+	}
+}
+
+func makeClaimPod(name, claimName string) *v1.Pod {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", UID: types.UID(name + "-uid"), Labels: map[string]string{}}}
+	if claimName != "" {
+		pod.Labels[v1alpha1.SharedDeviceClaimLabel] = claimName
+	}
+	return pod
+}
+
+func withCount(claim *v1alpha1.SharedDeviceClaim, count int32) *v1alpha1.SharedDeviceClaim {
+	claim.Spec.Count = &count
+	return claim
+}
+
+// withSidecar gives a container of the pod a tenth of a device of its own.
+func withSidecar(claim *v1alpha1.SharedDeviceClaim, container string) *v1alpha1.SharedDeviceClaim {
+	claim.Spec.Containers = append(claim.Spec.Containers, v1alpha1.SharedDeviceContainerShare{
+		Name:    container,
+		Compute: resource.MustParse("100m"),
+		Memory:  resource.MustParse("100m"),
+	})
+	return claim
+}
+
+func withContainers(pod *v1.Pod, names ...string) *v1.Pod {
+	for _, name := range names {
+		pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{Name: name})
+	}
+	return pod
+}
+
+func TestParsePod(t *testing.T) {
+	limit := resource.MustParse("1")
+	tooSmallLimit := resource.MustParse("100m")
+
+	tests := []struct {
+		name    string
+		pod     *v1.Pod
+		claims  []*v1alpha1.SharedDeviceClaim
+		want    *PodRequestedQuota
+		wantErr bool
+	}{
+		{
+			name:   "claim without limit defaults limit to compute",
+			pod:    makeClaimPod("p1", "quarter"),
+			claims: []*v1alpha1.SharedDeviceClaim{makeClaim("quarter", "250m", "500m", nil)},
+			want: &PodRequestedQuota{
+				PodId:     "ns/p1/p1-uid",
+				ClaimName: "quarter",
+				Vendor:    "example.com",
+				Model:     "mydev",
+				Shares:    []ShareQuota{{ClientId: "ns/p1/p1-uid", Requests: 0.25, Limits: 0.25, Memory: 0.5}},
+			},
+		},
+		{
+			name:   "claim with limit",
+			pod:    makeClaimPod("p1", "quarter"),
+			claims: []*v1alpha1.SharedDeviceClaim{makeClaim("quarter", "250m", "250m", &limit)},
+			want: &PodRequestedQuota{
+				PodId:     "ns/p1/p1-uid",
+				ClaimName: "quarter",
+				Vendor:    "example.com",
+				Model:     "mydev",
+				Shares:    []ShareQuota{{ClientId: "ns/p1/p1-uid", Requests: 0.25, Limits: 1, Memory: 0.25}},
+			},
+		},
+		{
+			name:   "claim on several devices with a sidecar share",
+			pod:    withContainers(makeClaimPod("p1", "multi"), "main", "sidecar"),
+			claims: []*v1alpha1.SharedDeviceClaim{withSidecar(withCount(makeClaim("multi", "500m", "500m", nil), 2), "sidecar")},
+			want: &PodRequestedQuota{
+				PodId:     "ns/p1/p1-uid",
+				ClaimName: "multi",
+				Vendor:    "example.com",
+				Model:     "mydev",
+				Shares: []ShareQuota{
+					{ClientId: "ns/p1/p1-uid", Requests: 0.5, Limits: 0.5, Memory: 0.5},
+					{ClientId: "ns/p1/p1-uid", Requests: 0.5, Limits: 0.5, Memory: 0.5},
+					{ClientId: "ns/p1/p1-uid.sidecar", Container: "sidecar", Requests: 0.1, Limits: 0.1, Memory: 0.1},
+				},
+			},
+		},
+		{
+			name:    "zero devices",
+			pod:     makeClaimPod("p1", "none"),
+			claims:  []*v1alpha1.SharedDeviceClaim{withCount(makeClaim("none", "250m", "250m", nil), 0)},
+			wantErr: true,
+		},
+		{
+			name:    "share of a container the pod doesn't have",
+			pod:     withContainers(makeClaimPod("p1", "multi"), "main"),
+			claims:  []*v1alpha1.SharedDeviceClaim{withSidecar(makeClaim("multi", "250m", "250m", nil), "sidecar")},
+			wantErr: true,
+		},
+		{
+			name:    "pod without claim label",
+			pod:     makeClaimPod("p1", ""),
+			wantErr: true,
+		},
+		{
+			name:    "claim does not exist",
+			pod:     makeClaimPod("p1", "missing"),
+			wantErr: true,
+		},
+		{
+			name:    "compute larger than a whole device",
+			pod:     makeClaimPod("p1", "too-big"),
+			claims:  []*v1alpha1.SharedDeviceClaim{makeClaim("too-big", "1500m", "250m", nil)},
+			wantErr: true,
+		},
+		{
+			name:    "zero memory",
+			pod:     makeClaimPod("p1", "no-mem"),
+			claims:  []*v1alpha1.SharedDeviceClaim{makeClaim("no-mem", "250m", "0", nil)},
+			wantErr: true,
+		},
+		{
+			name:    "limit smaller than compute",
+			pod:     makeClaimPod("p1", "bad-limit"),
+			claims:  []*v1alpha1.SharedDeviceClaim{makeClaim("bad-limit", "250m", "250m", &tooSmallLimit)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := fakeclientset.NewSimpleClientset()
+			informerFactory := schedinformer.NewSharedInformerFactory(cs, 0)
+			claimInformer := informerFactory.Scheduling().V1alpha1().SharedDeviceClaims()
+			for _, c := range tt.claims {
+				claimInformer.Informer().GetStore().Add(c)
+			}
+
+			sp := &ShareDevPlugin{claimClient: cs, claimLister: claimInformer.Lister()}
+			got, err := sp.parsePod(tt.pod)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePod() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected quota (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// withFallback lets the claim run on model, its compute scaled by scale if set.
+func withFallback(claim *v1alpha1.SharedDeviceClaim, model, scale string) *v1alpha1.SharedDeviceClaim {
+	m := v1alpha1.SharedDeviceModel{Vendor: "example.com", Model: model}
+	if scale != "" {
+		q := resource.MustParse(scale)
+		m.ComputeScale = &q
+	}
+	claim.Spec.Fallbacks = append(claim.Spec.Fallbacks, m)
+	return claim
+}
+
+func TestClaimToQuotas(t *testing.T) {
+	limit := resource.MustParse("500m")
+
+	tests := []struct {
+		name    string
+		claim   *v1alpha1.SharedDeviceClaim
+		want    []PodRequestedQuota
+		wantErr bool
+	}{
+		{
+			name:  "claim without fallbacks",
+			claim: makeClaim("quarter", "250m", "250m", nil),
+			want: []PodRequestedQuota{
+				{PodId: "ns/p1/p1-uid", ClaimName: "quarter", Vendor: "example.com", Model: "mydev", Shares: []ShareQuota{{ClientId: "ns/p1/p1-uid", Requests: 0.25, Limits: 0.25, Memory: 0.25}}},
+			},
+		},
+		{
+			name:  "fallbacks in preference order, compute scaled",
+			claim: withFallback(withFallback(makeClaim("quarter", "250m", "250m", &limit), "small", "2"), "big", "500m"),
+			want: []PodRequestedQuota{
+				{PodId: "ns/p1/p1-uid", ClaimName: "quarter", Vendor: "example.com", Model: "mydev", Shares: []ShareQuota{{ClientId: "ns/p1/p1-uid", Requests: 0.25, Limits: 0.5, Memory: 0.25}}},
+				{PodId: "ns/p1/p1-uid", ClaimName: "quarter", Vendor: "example.com", Model: "small", Shares: []ShareQuota{{ClientId: "ns/p1/p1-uid", Requests: 0.5, Limits: 1, Memory: 0.25}}},
+				{PodId: "ns/p1/p1-uid", ClaimName: "quarter", Vendor: "example.com", Model: "big", Shares: []ShareQuota{{ClientId: "ns/p1/p1-uid", Requests: 0.125, Limits: 0.25, Memory: 0.25}}},
+			},
+		},
+		{
+			name:  "fallbacks the scaled shares don't fit are left out",
+			claim: withFallback(makeClaim("half", "750m", "250m", nil), "small", "2"),
+			want: []PodRequestedQuota{
+				{PodId: "ns/p1/p1-uid", ClaimName: "half", Vendor: "example.com", Model: "mydev", Shares: []ShareQuota{{ClientId: "ns/p1/p1-uid", Requests: 0.75, Limits: 0.75, Memory: 0.25}}},
+			},
+		},
+		{
+			name:    "model listed twice",
+			claim:   withFallback(makeClaim("quarter", "250m", "250m", nil), "mydev", ""),
+			wantErr: true,
+		},
+		{
+			name:    "fallback without model",
+			claim:   withFallback(makeClaim("quarter", "250m", "250m", nil), "", ""),
+			wantErr: true,
+		},
+		{
+			name:    "zero compute scale",
+			claim:   withFallback(makeClaim("quarter", "250m", "250m", nil), "small", "0"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := claimToQuotas(makeClaimPod("p1", tt.claim.Name), tt.claim)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("claimToQuotas() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected quotas (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPodClientId(t *testing.T) {
+	pod := makeClaimPod("p1", "quarter")
+	if got := podClientId(pod); got != "ns/p1/p1-uid" {
+		t.Errorf("expected client id ns/p1/p1-uid, got %q", got)
+	}
+
+	// Pods of the same name in another namespace, or recreated, don't share it.
+	other := makeClaimPod("p1", "quarter")
+	other.Namespace = "other"
+	recreated := makeClaimPod("p1", "quarter")
+	recreated.UID = "p1-new-uid"
+	for _, p := range []*v1.Pod{other, recreated} {
+		if podClientId(p) == podClientId(pod) {
+			t.Errorf("expected pod %s/%s with UID %s to have another client id than %q", p.Namespace, p.Name, p.UID, podClientId(pod))
+		}
+	}
+}
+
+func TestPreFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		pod        *v1.Pod
+		state      *ShareDevState
+		wantCode   framework.Code
+		wantPodQ   PodRequestedQuota
+		wantEvents []string
+	}{
+		{
+			name:     "pod with a claim",
+			pod:      makeClaimPod("p1", "quarter"),
+			wantCode: framework.Success,
+			wantPodQ: PodRequestedQuota{
+				PodId:     "ns/p1/p1-uid",
+				ClaimName: "quarter",
+				Vendor:    "example.com",
+				Model:     "mydev",
+				Shares:    []ShareQuota{{ClientId: "ns/p1/p1-uid", Requests: 0.25, Limits: 0.25, Memory: 0.25}},
+			},
+		},
+		{
+			name:     "state of an earlier cycle is kept",
+			pod:      makeClaimPod("p1", "quarter"),
+			state:    &ShareDevState{PodQ: quota("p1", 0.5, 0.5)},
+			wantCode: framework.Success,
+			wantPodQ: quota("p1", 0.5, 0.5),
+		},
+		{
+			name:       "pod without claim",
+			pod:        makeClaimPod("p1", ""),
+			wantCode:   framework.Unschedulable,
+			wantEvents: []string{"Warning InvalidSharedDeviceRequest"},
+		},
+		{
+			name:       "claim does not exist",
+			pod:        makeClaimPod("p1", "missing"),
+			wantCode:   framework.Unschedulable,
+			wantEvents: []string{"Warning InvalidSharedDeviceRequest"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp, _ := newTestPlugin(t, nil, makeClaim("quarter", "250m", "250m", nil))
+			cycleState := framework.NewCycleState()
+			if tt.state != nil {
+				cycleState.Write(ShareDevStateKey, tt.state)
+			}
+
+			_, gotStatus := sp.PreFilter(context.Background(), cycleState, tt.pod)
+			if gotStatus.Code() != tt.wantCode {
+				t.Fatalf("expected code %v, got %v", tt.wantCode, gotStatus)
+			}
+			if diff := cmp.Diff(tt.wantEvents, recordedEvents(sp)); diff != "" {
+				t.Errorf("unexpected events (-want,+got):\n%s", diff)
+			}
+			if tt.wantCode != framework.Success {
+				return
+			}
+			s, err := getShareDevState(cycleState)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.wantPodQ, s.PodQ); diff != "" {
+				t.Errorf("unexpected quota (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Status:     v1.NodeStatus{Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.1"}}},
+	}
+
+	tests := []struct {
+		name           string
+		node           *v1.Node
+		setup          func(fdm *testutil.FakeDeviceManager)
+		deviceManagers deviceManagerClients
+		wantCode       framework.Code
+		wantFree       []FreeDeviceResources
+		wantEvents     []string
+	}{
+		{
+			name: "pod fits a device",
+			node: node,
+			setup: func(fdm *testutil.FakeDeviceManager) {
+				fdm.AddDevice("example.com", "mydev", "dev1").Reserve("dev1", "other", 0.5, 0.5)
+			},
+			wantCode: framework.Success,
+			wantFree: []FreeDeviceResources{{DeviceId: "dev1", Requests: 0.5, Memory: 0.5}},
+		},
+		{
+			name: "pod does not fit any device",
+			node: node,
+			setup: func(fdm *testutil.FakeDeviceManager) {
+				fdm.AddDevice("example.com", "mydev", "dev1").Reserve("dev1", "other", 0.75, 0.5)
+			},
+			wantCode: framework.UnschedulableAndUnresolvable,
+			wantFree: []FreeDeviceResources{{DeviceId: "dev1", Requests: 0.25, Memory: 0.5}},
+		},
+		{
+			name: "device manager without the extensions",
+			node: node,
+			setup: func(fdm *testutil.FakeDeviceManager) {
+				fdm.AddDevice("example.com", "mydev", "dev1").Reserve("dev1", "other", 0.5, 0.5).WithoutExtensions()
+			},
+			wantCode: framework.Success,
+			wantFree: []FreeDeviceResources{{DeviceId: "dev1", Requests: 0.5, Memory: 0.5}},
+		},
+		{
+			name: "unhealthy devices are left out",
+			node: node,
+			setup: func(fdm *testutil.FakeDeviceManager) {
+				fdm.AddDevice("example.com", "mydev", "dev1").SetUnhealthy("dev1", "ECC errors").
+					AddDevice("example.com", "mydev", "dev2").Reserve("dev2", "other", 0.5, 0.5)
+			},
+			wantCode:   framework.Success,
+			wantFree:   []FreeDeviceResources{{DeviceId: "dev2", Requests: 0.5, Memory: 0.5}},
+			wantEvents: []string{"Warning SharedDeviceUnhealthy"},
+		},
+		{
+			name: "only unhealthy devices",
+			node: node,
+			setup: func(fdm *testutil.FakeDeviceManager) {
+				fdm.AddDevice("example.com", "mydev", "dev1").SetUnhealthy("dev1", "ECC errors")
+			},
+			wantCode:   framework.Unschedulable,
+			wantEvents: []string{"Warning SharedDeviceUnhealthy"},
+		},
+		{
+			name: "no device of the model",
+			node: node,
+			setup: func(fdm *testutil.FakeDeviceManager) {
+				fdm.AddDevice("example.com", "otherdev", "dev1")
+			},
+			wantCode: framework.Unschedulable,
+		},
+		{
+			name: "device manager error",
+			node: node,
+			setup: func(fdm *testutil.FakeDeviceManager) {
+				fdm.SetError("GetAvailableDevicesHealth", status.Error(codes.Internal, "boom"))
+			},
+			wantCode: framework.Error,
+		},
+		{
+			name: "device manager timeout",
+			node: node,
+			setup: func(fdm *testutil.FakeDeviceManager) {
+				fdm.SetDelay("GetAvailableDevicesHealth", 5*testRPCTimeout)
+			},
+			wantCode: framework.Error,
+		},
+		{
+			name:           "device manager unreachable",
+			node:           node,
+			deviceManagers: unreachableDeviceManagers{},
+			wantCode:       framework.Unschedulable,
+		},
+		{
+			name:     "device manager endpoint not found",
+			node:     &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+			wantCode: framework.Unschedulable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fdm := testutil.NewFakeDeviceManager()
+			if tt.setup != nil {
+				tt.setup(fdm)
+			}
+			sp, _ := newTestPlugin(t, map[string]*testutil.FakeDeviceManager{"10.0.0.1": fdm})
+			if tt.deviceManagers != nil {
+				sp.deviceManagers = tt.deviceManagers
+			}
+
+			s := &ShareDevState{
+				PodQ:                       quota("p1", 0.5, 0.5),
+				FreeDeviceResourcesPerNode: map[string][]FreeDeviceResources{},
+				NodeNameToEndpoint:         map[string]string{},
+			}
+			cycleState := framework.NewCycleState()
+			cycleState.Write(ShareDevStateKey, s)
+			nodeInfo := framework.NewNodeInfo()
+			nodeInfo.SetNode(tt.node)
+
+			if code := sp.Filter(context.Background(), cycleState, makeClaimPod("p1", "half"), nodeInfo).Code(); code != tt.wantCode {
+				t.Errorf("expected code %v, got %v", tt.wantCode, code)
+			}
+			if diff := cmp.Diff(tt.wantFree, s.FreeDeviceResourcesPerNode["node1"]); diff != "" {
+				t.Errorf("unexpected free resources (-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantEvents, recordedEvents(sp)); diff != "" {
+				t.Errorf("unexpected events (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFilterParallel(t *testing.T) {
+	fakes := map[string]*testutil.FakeDeviceManager{}
+	var nodeInfos []*framework.NodeInfo
+	for i := 1; i <= 16; i++ {
+		address := fmt.Sprintf("10.0.0.%d", i)
+		fakes[address] = testutil.NewFakeDeviceManager().AddDevice("example.com", "mydev", "dev1")
+		nodeInfo := framework.NewNodeInfo()
+		nodeInfo.SetNode(makeEndpointNode(fmt.Sprintf("node%d", i), nil, v1.NodeAddress{Type: v1.NodeInternalIP, Address: address}))
+		nodeInfos = append(nodeInfos, nodeInfo)
+	}
+	sp, _ := newTestPlugin(t, fakes)
+
+	// Like the scheduler, Filter runs on all nodes at once in the same cycle.
+	s := &ShareDevState{
+		PodQ:                       quota("p1", 0.5, 0.5),
+		FreeDeviceResourcesPerNode: map[string][]FreeDeviceResources{},
+		NodeNameToEndpoint:         map[string]string{},
+		NodeModels:                 map[string]int{},
+	}
+	cycleState := framework.NewCycleState()
+	cycleState.Write(ShareDevStateKey, s)
+	var wg sync.WaitGroup
+	for _, nodeInfo := range nodeInfos {
+		wg.Add(1)
+		go func(nodeInfo *framework.NodeInfo) {
+			defer wg.Done()
+			if code := sp.Filter(context.Background(), cycleState, makeClaimPod("p1", "half"), nodeInfo).Code(); code != framework.Success {
+				t.Errorf("expected the pod to fit on %s, got %v", nodeInfo.Node().Name, code)
+			}
+		}(nodeInfo)
+	}
+	wg.Wait()
+
+	if len(s.FreeDeviceResourcesPerNode) != len(nodeInfos) || len(s.NodeNameToEndpoint) != len(nodeInfos) {
+		t.Errorf("expected the devices of %d nodes, got %d free devices and %d endpoints",
+			len(nodeInfos), len(s.FreeDeviceResourcesPerNode), len(s.NodeNameToEndpoint))
+	}
+}
+
+func TestFilterUnreachableDeviceManager(t *testing.T) {
+	fdm := testutil.NewFakeDeviceManager().AddDevice("example.com", "mydev", "dev1")
+	fdm.SetError("GetAvailableDevicesHealth", status.Error(codes.Unavailable, "connection refused"))
+	sp, _ := newTestPlugin(t, map[string]*testutil.FakeDeviceManager{"10.0.0.1": fdm})
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	sp.dmFailures = newDeviceManagerFailures(fakeClock, time.Minute, 10*time.Second)
+	nodeInfo := framework.NewNodeInfo()
+	nodeInfo.SetNode(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Status:     v1.NodeStatus{Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.1"}}},
+	})
+
+	steps := []struct {
+		name string
+		// advance is how long after the previous step Filter runs.
+		advance   time.Duration
+		fixed     bool
+		wantCode  framework.Code
+		wantCalls int
+	}{
+		{name: "the device manager fails", wantCode: framework.Error, wantCalls: 1},
+		{name: "it keeps failing", advance: 30 * time.Second, wantCode: framework.Error, wantCalls: 2},
+		{name: "it is unreachable", advance: 31 * time.Second, wantCode: framework.UnschedulableAndUnresolvable, wantCalls: 3},
+		{name: "it isn't asked again before the retry period", advance: 5 * time.Second, wantCode: framework.UnschedulableAndUnresolvable, wantCalls: 3},
+		{name: "it is asked again after the retry period", advance: 5 * time.Second, wantCode: framework.UnschedulableAndUnresolvable, wantCalls: 4},
+		{name: "it answers again", advance: 10 * time.Second, fixed: true, wantCode: framework.Success, wantCalls: 5},
+	}
+	for _, step := range steps {
+		fakeClock.Step(step.advance)
+		if step.fixed {
+			fdm.SetError("GetAvailableDevicesHealth", nil)
+		}
+		cycleState := framework.NewCycleState()
+		cycleState.Write(ShareDevStateKey, &ShareDevState{
+			PodQ:                       quota("p1", 0.5, 0.5),
+			FreeDeviceResourcesPerNode: map[string][]FreeDeviceResources{},
+			NodeNameToEndpoint:         map[string]string{},
+		})
+		if code := sp.Filter(context.Background(), cycleState, makeClaimPod("p1", "half"), nodeInfo).Code(); code != step.wantCode {
+			t.Errorf("%s: expected code %v, got %v", step.name, step.wantCode, code)
+		}
+		if calls := fdm.Calls("GetAvailableDevicesHealth"); calls != step.wantCalls {
+			t.Errorf("%s: expected %d calls, got %d", step.name, step.wantCalls, calls)
+		}
+	}
+}
+
+func TestFilterFallbackModels(t *testing.T) {
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Status:     v1.NodeStatus{Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.1"}}},
+	}
+	fallback := quota("p1", 1, 0.5)
+	fallback.Model = "small"
+
+	tests := []struct {
+		name      string
+		setup     func(fdm *testutil.FakeDeviceManager)
+		wantCode  framework.Code
+		wantFree  []FreeDeviceResources
+		wantModel PodRequestedQuota
+	}{
+		{
+			name: "the claim's own model is preferred",
+			setup: func(fdm *testutil.FakeDeviceManager) {
+				fdm.AddDevice("example.com", "mydev", "dev1").AddDevice("example.com", "small", "dev2")
+			},
+			wantCode:  framework.Success,
+			wantFree:  []FreeDeviceResources{{DeviceId: "dev1", Requests: 1, Memory: 1}},
+			wantModel: quota("p1", 0.5, 0.5),
+		},
+		{
+			name: "the pod fits a fallback model",
+			setup: func(fdm *testutil.FakeDeviceManager) {
+				fdm.AddDevice("example.com", "mydev", "dev1").Reserve("dev1", "other", 0.75, 0.5).
+					AddDevice("example.com", "small", "dev2")
+			},
+			wantCode:  framework.Success,
+			wantFree:  []FreeDeviceResources{{DeviceId: "dev2", Requests: 1, Memory: 1}},
+			wantModel: fallback,
+		},
+		{
+			name: "only a device of a fallback model",
+			setup: func(fdm *testutil.FakeDeviceManager) {
+				fdm.AddDevice("example.com", "small", "dev2")
+			},
+			wantCode:  framework.Success,
+			wantFree:  []FreeDeviceResources{{DeviceId: "dev2", Requests: 1, Memory: 1}},
+			wantModel: fallback,
+		},
+		{
+			name: "the scaled shares don't fit the fallback model",
+			setup: func(fdm *testutil.FakeDeviceManager) {
+				fdm.AddDevice("example.com", "mydev", "dev1").Reserve("dev1", "other", 0.75, 0.5).
+					AddDevice("example.com", "small", "dev2").Reserve("dev2", "other", 0.25, 0.25)
+			},
+			wantCode:  framework.UnschedulableAndUnresolvable,
+			wantFree:  []FreeDeviceResources{{DeviceId: "dev1", Requests: 0.25, Memory: 0.5}},
+			wantModel: quota("p1", 0.5, 0.5),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fdm := testutil.NewFakeDeviceManager()
+			tt.setup(fdm)
+			sp, _ := newTestPlugin(t, map[string]*testutil.FakeDeviceManager{"10.0.0.1": fdm})
+
+			s := &ShareDevState{
+				PodQ:                       quota("p1", 0.5, 0.5),
+				Models:                     []PodRequestedQuota{quota("p1", 0.5, 0.5), fallback},
+				NodeModels:                 map[string]int{},
+				FreeDeviceResourcesPerNode: map[string][]FreeDeviceResources{},
+				NodeNameToEndpoint:         map[string]string{},
+			}
+			cycleState := framework.NewCycleState()
+			cycleState.Write(ShareDevStateKey, s)
+			nodeInfo := framework.NewNodeInfo()
+			nodeInfo.SetNode(node)
+
+			if code := sp.Filter(context.Background(), cycleState, makeClaimPod("p1", "half"), nodeInfo).Code(); code != tt.wantCode {
+				t.Errorf("expected code %v, got %v", tt.wantCode, code)
+			}
+			if diff := cmp.Diff(tt.wantFree, s.FreeDeviceResourcesPerNode["node1"]); diff != "" {
+				t.Errorf("unexpected free resources (-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantModel, s.quotaOn("node1")); diff != "" {
+				t.Errorf("unexpected model (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPostFilter(t *testing.T) {
+	sp, allocators := newTestPlugin(t, nil)
+
+	// Two halves of different devices need two new devices.
+	podQ := quota("p1", 0.5, 0.5)
+	podQ.Shares = append(podQ.Shares, podQ.Shares[0])
+	cycleState := framework.NewCycleState()
+	cycleState.Write(ShareDevStateKey, &ShareDevState{PodQ: podQ})
+
+	_, gotStatus := sp.PostFilter(context.Background(), cycleState, makeClaimPod("p1", "halves"), nil)
+	if gotStatus.Code() != framework.Unschedulable {
+		t.Errorf("expected code %v, got %v", framework.Unschedulable, gotStatus)
+	}
+	if want := "waiting for 2 new example.com/mydev device(s)"; gotStatus.Message() != want {
+		t.Errorf("expected message %q, got %q", want, gotStatus.Message())
+	}
+	allocators.wait(t, 2)
+	if got := len(allocators.createdNames()); got != 2 {
+		t.Errorf("expected 2 allocators to be created, got %d", got)
+	}
+	if diff := cmp.Diff([]string{"Warning NoFittingSharedDevice", "Normal ProvisioningSharedDevice"}, recordedEvents(sp)); diff != "" {
+		t.Errorf("unexpected events (-want,+got):\n%s", diff)
+	}
+
+	// Without PreFilter's state there is nothing to provision.
+	if _, gotStatus := sp.PostFilter(context.Background(), framework.NewCycleState(), makeClaimPod("p2", "halves"), nil); gotStatus.Code() != framework.Error {
+		t.Errorf("expected code %v, got %v", framework.Error, gotStatus)
+	}
+}
+
+func TestPostFilterCheapestModel(t *testing.T) {
+	// Half of a device of the claim's own model, or all of a slower one.
+	small := quota("p1", 1, 0.5)
+	small.Model = "small"
+	big := quota("p1", 0.25, 0.5)
+	big.Model = "big"
+
+	tests := []struct {
+		name        string
+		costs       map[deviceModel]int64
+		wantMessage string
+	}{
+		{
+			name:        "without costs the claim's own model is provisioned",
+			wantMessage: "waiting for 1 new example.com/mydev device(s)",
+		},
+		{
+			name: "the cheapest model for the pod",
+			costs: map[deviceModel]int64{
+				{vendor: "example.com", model: "mydev"}: 10,
+				{vendor: "example.com", model: "small"}: 4,
+				{vendor: "example.com", model: "big"}:   20,
+			},
+			wantMessage: "waiting for 1 new example.com/small device(s)",
+		},
+		{
+			name: "models without a cost come last",
+			costs: map[deviceModel]int64{
+				{vendor: "example.com", model: "big"}: 20,
+			},
+			wantMessage: "waiting for 1 new example.com/big device(s)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp, allocators := newTestPlugin(t, nil)
+			sp.deviceModelCosts = tt.costs
+			cycleState := framework.NewCycleState()
+			cycleState.Write(ShareDevStateKey, &ShareDevState{
+				PodQ:   quota("p1", 0.5, 0.5),
+				Models: []PodRequestedQuota{quota("p1", 0.5, 0.5), small, big},
+			})
+
+			_, gotStatus := sp.PostFilter(context.Background(), cycleState, makeClaimPod("p1", "half"), nil)
+			if gotStatus.Message() != tt.wantMessage {
+				t.Errorf("expected message %q, got %q", tt.wantMessage, gotStatus.Message())
+			}
+			allocators.wait(t, 1)
+		})
+	}
+}
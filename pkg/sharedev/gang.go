@@ -0,0 +1,191 @@
+package sharedev
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	"sigs.k8s.io/scheduler-plugins/pkg/util"
+)
+
+// checkPodGroup checks that the free shared devices of the cluster can host
+// the shares of the pod and of the members of its PodGroup still needed to
+// reach minMember, like Coscheduling's CheckClusterResource does for the
+// regular resources of the group. Members bound or waiting at Permit already
+// hold their shares. models are what the pod asks for on every model its claim
+// accepts, in preference order: the group passes if it fits on any of them,
+// each member running on the same model when its own claim accepts it. The
+// check is optimistic: the devices of every node are pooled, so a group it
+// lets through may still not fit.
+func (sp *ShareDevPlugin) checkPodGroup(ctx context.Context, pod *v1.Pod, models []PodRequestedQuota) error {
+	pgName := util.GetPodGroupLabel(pod)
+	if pgName == "" {
+		return nil
+	}
+	// Coscheduling rejects the pods of missing PodGroups.
+	pg, err := sp.podGroupLister.PodGroups(pod.Namespace).Get(pgName)
+	if err != nil {
+		return nil
+	}
+	members, err := sp.podLister.Pods(pod.Namespace).List(labels.SelectorFromSet(labels.Set{v1alpha1.PodGroupLabel: pgName}))
+	if err != nil {
+		return nil
+	}
+
+	assigned := 0
+	var pending []*v1.Pod
+	for _, m := range members {
+		switch {
+		case m.UID == pod.UID:
+		case m.Spec.NodeName != "" || sp.waiting(m.UID):
+			assigned++
+		case m.DeletionTimestamp == nil && m.Status.Phase != v1.PodSucceeded && m.Status.Phase != v1.PodFailed:
+			pending = append(pending, m)
+		}
+	}
+	// Like Coscheduling, the pod completing the group only needs to fit itself.
+	needed := int(pg.Spec.MinMember) - assigned - 1
+	if needed <= 0 {
+		return nil
+	}
+	// The members most likely to be scheduled next, in the queue order of Coscheduling.
+	sort.Slice(pending, func(i, j int) bool {
+		if !pending[i].CreationTimestamp.Equal(&pending[j].CreationTimestamp) {
+			return pending[i].CreationTimestamp.Before(&pending[j].CreationTimestamp)
+		}
+		return pending[i].Name < pending[j].Name
+	})
+	if len(pending) > needed {
+		pending = pending[:needed]
+	}
+
+	var memberModels [][]PodRequestedQuota
+	for _, m := range pending {
+		// Members with an invalid claim are reported by their own PreFilter.
+		if qs, err := sp.parsePodModels(m); err == nil {
+			memberModels = append(memberModels, qs)
+		}
+	}
+
+	candidates := make([][]PodRequestedQuota, len(models))
+	var all []PodRequestedQuota
+	for i, podQ := range models {
+		candidates[i] = append([]PodRequestedQuota{podQ}, onModel(memberModels, podQ.Vendor, podQ.Model)...)
+		all = append(all, candidates[i]...)
+	}
+	free := sp.clusterFreeDevices(ctx, all)
+
+	var firstErr error
+	for _, quotas := range candidates {
+		err := groupFits(free, quotas, sp.scoreDevice)
+		if err == nil {
+			return nil
+		}
+		if firstErr == nil {
+			firstErr = fmt.Errorf("%v of PodGroup %s/%s still to be scheduled", err, pod.Namespace, pgName)
+		}
+	}
+	return firstErr
+}
+
+// onModel returns the quota of each member on vendor/model, or on its own
+// preferred model when its claim doesn't accept it.
+func onModel(memberModels [][]PodRequestedQuota, vendor, model string) []PodRequestedQuota {
+	quotas := make([]PodRequestedQuota, 0, len(memberModels))
+	for _, qs := range memberModels {
+		q := qs[0]
+		for _, m := range qs {
+			if m.Vendor == vendor && m.Model == model {
+				q = m
+				break
+			}
+		}
+		quotas = append(quotas, q)
+	}
+	return quotas
+}
+
+// groupFits tells whether the shares of every quota fit on free together,
+// leaving free untouched.
+func groupFits(free map[deviceModel][]FreeDeviceResources, quotas []PodRequestedQuota, scoreDevice deviceScorer) error {
+	left := map[deviceModel][]FreeDeviceResources{}
+	for key, devices := range free {
+		left[key] = append([]FreeDeviceResources(nil), devices...)
+	}
+	for _, q := range quotas {
+		key := deviceModel{vendor: q.Vendor, model: q.Model}
+		_, deviceIds := assignShares(q, left[key], scoreDevice)
+		if deviceIds == nil {
+			return fmt.Errorf("the free %s/%s devices can't host the shares of the %d member(s)", q.Vendor, q.Model, len(quotas))
+		}
+		takeShares(left[key], q, deviceIds)
+	}
+	return nil
+}
+
+// clusterFreeDevices returns the free devices of every schedulable node, by
+// vendor and model, for the models of quotas. The devices of each node are
+// prefixed with its name, so devices of different nodes never share an id.
+// Nodes whose devices can't be listed are left out.
+func (sp *ShareDevPlugin) clusterFreeDevices(ctx context.Context, quotas []PodRequestedQuota) map[deviceModel][]FreeDeviceResources {
+	logger := klog.FromContext(ctx)
+	models := map[deviceModel]bool{}
+	for _, q := range quotas {
+		models[deviceModel{vendor: q.Vendor, model: q.Model}] = true
+	}
+
+	free := map[deviceModel][]FreeDeviceResources{}
+	nodes, err := sp.nodeLister.List(labels.Everything())
+	if err != nil {
+		logger.Error(err, "Failed to list nodes")
+		return free
+	}
+	var lock sync.Mutex
+	workqueue.ParallelizeUntil(ctx, inventoryResyncWorkers, len(nodes), func(i int) {
+		node := nodes[i]
+		if node.Spec.Unschedulable {
+			return
+		}
+		endpoint, err := sp.endpoints.get(node)
+		if err != nil {
+			return
+		}
+		if _, ok := sp.dmFailures.skip(node.Name); ok {
+			return
+		}
+		for key := range models {
+			devices, err := sp.inventory.get(node.Name, endpoint, key.vendor, key.model)
+			if err != nil {
+				logger.V(5).Info("Leaving out the shared devices of a node", "node", node.Name, "err", err)
+				continue
+			}
+			lock.Lock()
+			for _, d := range healthyDevices(devices) {
+				d.DeviceId = node.Name + "/" + d.DeviceId
+				free[key] = append(free[key], d)
+			}
+			lock.Unlock()
+		}
+	})
+	return free
+}
+
+// takeShares subtracts the shares of q assigned to deviceIds from free.
+func takeShares(free []FreeDeviceResources, q PodRequestedQuota, deviceIds []string) {
+	for i, deviceId := range deviceIds {
+		for j := range free {
+			if free[j].DeviceId == deviceId {
+				free[j].Requests -= q.Shares[i].Requests
+				free[j].Memory -= q.Shares[i].Memory
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package sharedev
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	fakeclientset "sigs.k8s.io/scheduler-plugins/pkg/generated/clientset/versioned/fake"
+	schedinformer "sigs.k8s.io/scheduler-plugins/pkg/generated/informers/externalversions"
+	testutil "sigs.k8s.io/scheduler-plugins/test/util"
+)
+
+// makeMemberPod returns the pod name of PodGroup pg, created at second i.
+func makeMemberPod(name, pg string, i int) *v1.Pod {
+	pod := makeClaimPod(name, "half")
+	pod.Labels[v1alpha1.PodGroupLabel] = pg
+	pod.CreationTimestamp = metav1.Unix(int64(1700000000+i), 0)
+	return pod
+}
+
+func TestPreFilterPodGroup(t *testing.T) {
+	tests := []struct {
+		name      string
+		minMember int32
+		// bound and waiting are how many members other than p1 are
+		// bound and waiting at Permit.
+		bound   int
+		waiting int
+		// invalid are the members other than p1 with a missing claim.
+		invalid int
+		// fallback lets the claim run on otherdev, of which node2 has two
+		// devices, its mydev device being taken.
+		fallback   bool
+		wantStatus *framework.Status
+		wantEvents []string
+	}{
+		{
+			name:       "the group fits the devices of every node",
+			minMember:  4,
+			wantStatus: framework.NewStatus(framework.Success),
+		},
+		{
+			name:       "the group doesn't fit",
+			minMember:  5,
+			wantStatus: framework.NewStatus(framework.Unschedulable, "the free example.com/mydev devices can't host the shares of the 5 member(s) of PodGroup ns/pg1 still to be scheduled"),
+			wantEvents: []string{"Warning PodGroupSharedDevicesUnavailable"},
+		},
+		{
+			name:       "bound members already hold their shares",
+			minMember:  6,
+			bound:      2,
+			wantStatus: framework.NewStatus(framework.Success),
+		},
+		{
+			name:       "members waiting at Permit already hold their shares",
+			minMember:  6,
+			waiting:    2,
+			wantStatus: framework.NewStatus(framework.Success),
+		},
+		{
+			name:       "members with an invalid claim are left out",
+			minMember:  6,
+			invalid:    2,
+			wantStatus: framework.NewStatus(framework.Success),
+		},
+		{
+			name:       "the group fits a fallback model",
+			minMember:  4,
+			fallback:   true,
+			wantStatus: framework.NewStatus(framework.Success),
+		},
+		{
+			name:       "the group fits no model",
+			minMember:  5,
+			fallback:   true,
+			wantStatus: framework.NewStatus(framework.Unschedulable, "the free example.com/mydev devices can't host the shares of the 5 member(s) of PodGroup ns/pg1 still to be scheduled"),
+			wantEvents: []string{"Warning PodGroupSharedDevicesUnavailable"},
+		},
+		{
+			name:       "the pod completes the group",
+			minMember:  3,
+			bound:      2,
+			wantStatus: framework.NewStatus(framework.Success),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Two devices, of the same id on both nodes, host four members.
+			fakes := map[string]*testutil.FakeDeviceManager{
+				"10.0.0.1": testutil.NewFakeDeviceManager().AddDevice("example.com", "mydev", "dev1"),
+				"10.0.0.2": testutil.NewFakeDeviceManager().AddDevice("example.com", "mydev", "dev1"),
+			}
+			claim := makeClaim("half", "500m", "500m", nil)
+			if tt.fallback {
+				fakes["10.0.0.2"].Reserve("dev1", "other", 1, 1).
+					AddDevice("example.com", "otherdev", "dev2").AddDevice("example.com", "otherdev", "dev3")
+				claim = withFallback(claim, "otherdev", "")
+			}
+			sp, _ := newTestPlugin(t, fakes, claim)
+
+			nodes := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			nodes.Add(makeEndpointNode("node1", nil, v1.NodeAddress{Type: v1.NodeInternalIP, Address: "10.0.0.1"}))
+			nodes.Add(makeEndpointNode("node2", nil, v1.NodeAddress{Type: v1.NodeInternalIP, Address: "10.0.0.2"}))
+			// Without a device manager.
+			nodes.Add(makeEndpointNode("node3", nil))
+			sp.nodeLister = corelisters.NewNodeLister(nodes)
+
+			pgInformer := schedinformer.NewSharedInformerFactory(fakeclientset.NewSimpleClientset(), 0).Scheduling().V1alpha1().PodGroups()
+			pgInformer.Informer().GetStore().Add(&v1alpha1.PodGroup{
+				ObjectMeta: metav1.ObjectMeta{Name: "pg1", Namespace: "ns"},
+				Spec:       v1alpha1.PodGroupSpec{MinMember: tt.minMember},
+			})
+			sp.podGroupLister = pgInformer.Lister()
+
+			pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			pod := makeMemberPod("p1", "pg1", 0)
+			pods.Add(pod)
+			waiting := map[types.UID]bool{}
+			for i := 1; i < 6; i++ {
+				m := makeMemberPod(fmt.Sprintf("p%d", i+1), "pg1", i)
+				switch {
+				case i <= tt.bound:
+					m.Spec.NodeName = "node1"
+				case i <= tt.bound+tt.waiting:
+					waiting[m.UID] = true
+				case i <= tt.bound+tt.waiting+tt.invalid:
+					m.Labels[v1alpha1.SharedDeviceClaimLabel] = "missing"
+				}
+				pods.Add(m)
+			}
+			// Not a member.
+			pods.Add(makeClaimPod("other", "half"))
+			sp.podLister = corelisters.NewPodLister(pods)
+			sp.waiting = func(uid types.UID) bool { return waiting[uid] }
+
+			_, gotStatus := sp.PreFilter(context.Background(), framework.NewCycleState(), pod)
+			if diff := cmp.Diff(tt.wantStatus, gotStatus); diff != "" {
+				t.Errorf("unexpected status (-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantEvents, recordedEvents(sp)); diff != "" {
+				t.Errorf("unexpected events (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPreFilterPodGroupNotFound(t *testing.T) {
+	sp, _ := newTestPlugin(t, nil, makeClaim("half", "500m", "500m", nil))
+	// Coscheduling rejects the pod.
+	_, gotStatus := sp.PreFilter(context.Background(), framework.NewCycleState(), makeMemberPod("p1", "missing", 0))
+	if !gotStatus.IsSuccess() {
+		t.Errorf("expected success, got %v", gotStatus)
+	}
+}
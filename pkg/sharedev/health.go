@@ -0,0 +1,104 @@
+package sharedev
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// deviceManagerFailure is how long the device manager of a node has been
+// failing.
+type deviceManagerFailure struct {
+	// since is the first failure after the last success.
+	since time.Time
+	// last is the latest failure.
+	last time.Time
+}
+
+// deviceManagerFailures tracks the device managers that fail to report their
+// devices. A device manager failing for longer than unreachableAfter is
+// unreachable: its node is left out of scheduling instead of failing the
+// scheduling cycle, and it is only asked again once per retryPeriod.
+type deviceManagerFailures struct {
+	clock            clock.Clock
+	unreachableAfter time.Duration
+	retryPeriod      time.Duration
+
+	lock     sync.Mutex
+	failures map[string]*deviceManagerFailure // nodeName -> failure
+}
+
+func newDeviceManagerFailures(clock clock.Clock, unreachableAfter, retryPeriod time.Duration) *deviceManagerFailures {
+	return &deviceManagerFailures{
+		clock:            clock,
+		unreachableAfter: unreachableAfter,
+		retryPeriod:      retryPeriod,
+		failures:         map[string]*deviceManagerFailure{},
+	}
+}
+
+// observe records the outcome of a request to the device manager on nodeName.
+func (f *deviceManagerFailures) observe(nodeName string, err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if err == nil {
+		delete(f.failures, nodeName)
+		return
+	}
+	now := f.clock.Now()
+	failure, ok := f.failures[nodeName]
+	if !ok {
+		failure = &deviceManagerFailure{since: now}
+		f.failures[nodeName] = failure
+	}
+	failure.last = now
+}
+
+// unreachable tells whether the device manager on nodeName has been failing
+// for longer than unreachableAfter, and for how long.
+func (f *deviceManagerFailures) unreachable(nodeName string) (time.Duration, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	failure, ok := f.failures[nodeName]
+	if !ok {
+		return 0, false
+	}
+	failing := f.clock.Since(failure.since)
+	return failing, failing > f.unreachableAfter
+}
+
+// skip tells whether the device manager on nodeName is unreachable and was
+// asked less than retryPeriod ago, and for how long it has been failing.
+func (f *deviceManagerFailures) skip(nodeName string) (time.Duration, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	failure, ok := f.failures[nodeName]
+	if !ok {
+		return 0, false
+	}
+	failing := f.clock.Since(failure.since)
+	return failing, failing > f.unreachableAfter && f.clock.Since(failure.last) < f.retryPeriod
+}
+
+// remove forgets the failures of a deleted node.
+func (f *deviceManagerFailures) remove(nodeName string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	delete(f.failures, nodeName)
+}
+
+// healthyDevices returns the devices that may receive new shares.
+func healthyDevices(devices []FreeDeviceResources) []FreeDeviceResources {
+	healthy := make([]FreeDeviceResources, 0, len(devices))
+	for _, d := range devices {
+		if !d.Unhealthy {
+			healthy = append(healthy, d)
+		}
+	}
+	return healthy
+}
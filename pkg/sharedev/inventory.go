@@ -0,0 +1,296 @@
+package sharedev
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+// inventoryResyncWorkers bounds how many device managers are queried at once
+// during a resync.
+const inventoryResyncWorkers = 16
+
+// inventoryIdleResyncs is how many resync periods an entry nobody asked for is
+// kept refreshed before it is dropped.
+const inventoryIdleResyncs = 60
+
+// inventoryKey identifies the devices of one vendor and model on a node.
+type inventoryKey struct {
+	nodeName string
+	vendor   string
+	model    string
+}
+
+type inventoryEntry struct {
+	endpoint string
+	free     []FreeDeviceResources
+	// fetched is when the fetch that returned free was started.
+	fetched  time.Time
+	lastUsed time.Time
+}
+
+// assumedReservation is the quota taken by Reserve for a pod that the
+// inventory may not have seen yet.
+type assumedReservation struct {
+	key      inventoryKey
+	shares   []assumedShare
+	reserved time.Time
+}
+
+type assumedShare struct {
+	deviceId string
+	requests float64
+	memory   float64
+}
+
+type fetchFreeResourcesFunc func(nodeName, endpoint, vendor, model string) ([]FreeDeviceResources, error)
+
+// deviceUnhealthyFunc is called when a device the inventory knew as healthy,
+// or didn't know yet, is reported unhealthy.
+type deviceUnhealthyFunc func(nodeName string, model deviceModel, device FreeDeviceResources)
+
+// deviceInventory caches the free resources of the devices on every node, so
+// Filter doesn't have to ask every node's device manager in every scheduling
+// cycle. Entries are filled on first use and refreshed in the background.
+//
+// Like noderesourcetopology's OverReserve cache, it assumes the quota taken in
+// Reserve is gone until a fetch started after the reservation confirms it. The
+// free resources may therefore be underestimated for a while, but never
+// overestimated because of our own reservations.
+type deviceInventory struct {
+	fetch fetchFreeResourcesFunc
+	// unhealthy, if set, is told about the devices that become unhealthy.
+	unhealthy   deviceUnhealthyFunc
+	clock       clock.Clock
+	idleTimeout time.Duration
+
+	lock    sync.Mutex
+	entries map[inventoryKey]*inventoryEntry
+	assumed map[string]*assumedReservation // podId -> reservation
+	// unhealthyDevices are the ids of the devices last seen unhealthy. They
+	// outlive the entries, so a device is only reported once per failure.
+	unhealthyDevices map[inventoryKey]sets.String
+}
+
+func newDeviceInventory(fetch fetchFreeResourcesFunc, unhealthy deviceUnhealthyFunc, clock clock.Clock, resyncPeriod time.Duration) *deviceInventory {
+	return &deviceInventory{
+		fetch:            fetch,
+		unhealthy:        unhealthy,
+		clock:            clock,
+		idleTimeout:      inventoryIdleResyncs * resyncPeriod,
+		entries:          map[inventoryKey]*inventoryEntry{},
+		assumed:          map[string]*assumedReservation{},
+		unhealthyDevices: map[inventoryKey]sets.String{},
+	}
+}
+
+// get returns the free resources of the vendor and model devices on nodeName,
+// minus the assumed reservations. It only asks the device manager if the node
+// is not cached yet.
+func (inv *deviceInventory) get(nodeName, endpoint, vendor, model string) ([]FreeDeviceResources, error) {
+	key := inventoryKey{nodeName: nodeName, vendor: vendor, model: model}
+
+	inv.lock.Lock()
+	if entry, ok := inv.entries[key]; ok && entry.endpoint == endpoint {
+		entry.lastUsed = inv.clock.Now()
+		free := inv.freeLocked(key, entry)
+		inv.lock.Unlock()
+		return free, nil
+	}
+	inv.lock.Unlock()
+
+	return inv.refresh(key, endpoint)
+}
+
+// refresh fetches the free resources for key and returns them minus the
+// assumed reservations.
+func (inv *deviceInventory) refresh(key inventoryKey, endpoint string) ([]FreeDeviceResources, error) {
+	start := inv.clock.Now()
+	free, err := inv.fetch(key.nodeName, endpoint, key.vendor, key.model)
+
+	var failed []FreeDeviceResources
+	inv.lock.Lock()
+	defer func() {
+		inv.lock.Unlock()
+		// The callback may take its time, don't hold up the scheduling cycles.
+		if inv.unhealthy != nil {
+			for _, d := range failed {
+				inv.unhealthy(key.nodeName, deviceModel{vendor: key.vendor, model: key.model}, d)
+			}
+		}
+	}()
+
+	if err != nil {
+		// Make the next Filter ask the device manager instead of using stale data.
+		delete(inv.entries, key)
+		return nil, err
+	}
+	failed = inv.updateHealthLocked(key, free)
+
+	entry, ok := inv.entries[key]
+	if !ok {
+		entry = &inventoryEntry{lastUsed: start}
+		inv.entries[key] = entry
+	}
+	entry.endpoint = endpoint
+	entry.free = free
+	entry.fetched = start
+
+	for podId, r := range inv.assumed {
+		if r.key == key && start.After(r.reserved) {
+			delete(inv.assumed, podId)
+		}
+	}
+	return inv.freeLocked(key, entry), nil
+}
+
+// updateHealthLocked records which devices of key are unhealthy and returns
+// the ones that weren't before.
+func (inv *deviceInventory) updateHealthLocked(key inventoryKey, free []FreeDeviceResources) []FreeDeviceResources {
+	was := inv.unhealthyDevices[key]
+	now := sets.NewString()
+	var failed []FreeDeviceResources
+	for _, d := range free {
+		if !d.Unhealthy {
+			continue
+		}
+		now.Insert(d.DeviceId)
+		if !was.Has(d.DeviceId) {
+			failed = append(failed, d)
+		}
+	}
+	for _, deviceId := range was.Difference(now).List() {
+		klog.V(2).InfoS("Shared device is healthy again", "node", key.nodeName, "device", deviceId, "vendor", key.vendor, "model", key.model)
+	}
+	if now.Len() == 0 {
+		delete(inv.unhealthyDevices, key)
+	} else {
+		inv.unhealthyDevices[key] = now
+	}
+	return failed
+}
+
+func (inv *deviceInventory) freeLocked(key inventoryKey, entry *inventoryEntry) []FreeDeviceResources {
+	free := make([]FreeDeviceResources, len(entry.free))
+	copy(free, entry.free)
+
+	for _, r := range inv.assumed {
+		if r.key != key || entry.fetched.After(r.reserved) {
+			continue
+		}
+		for _, share := range r.shares {
+			for i := range free {
+				if free[i].DeviceId == share.deviceId {
+					free[i].Requests -= share.requests
+					free[i].Memory -= share.memory
+				}
+			}
+		}
+	}
+	return free
+}
+
+// resync refreshes all entries that were used recently and drops the others.
+func (inv *deviceInventory) resync() {
+	type target struct {
+		key      inventoryKey
+		endpoint string
+	}
+
+	now := inv.clock.Now()
+	var targets []target
+	inv.lock.Lock()
+	for key, entry := range inv.entries {
+		if now.Sub(entry.lastUsed) > inv.idleTimeout {
+			delete(inv.entries, key)
+			continue
+		}
+		targets = append(targets, target{key: key, endpoint: entry.endpoint})
+	}
+	inv.lock.Unlock()
+
+	workqueue.ParallelizeUntil(context.Background(), inventoryResyncWorkers, len(targets), func(i int) {
+		t := targets[i]
+		if _, err := inv.refresh(t.key, t.endpoint); err != nil {
+			klog.ErrorS(err, "Failed to refresh shared devices", "node", t.key.nodeName, "vendor", t.key.vendor, "model", t.key.model)
+		}
+	})
+}
+
+// devices returns the free resources of every cached device, minus the
+// assumed reservations, by device model.
+func (inv *deviceInventory) devices() map[deviceModel][]FreeDeviceResources {
+	inv.lock.Lock()
+	defer inv.lock.Unlock()
+
+	devices := map[deviceModel][]FreeDeviceResources{}
+	for key, entry := range inv.entries {
+		model := deviceModel{vendor: key.vendor, model: key.model}
+		devices[model] = append(devices[model], inv.freeLocked(key, entry)...)
+	}
+	return devices
+}
+
+// assume records the shares of a pod reserved on devices, by share index,
+// until the inventory sees them.
+func (inv *deviceInventory) assume(nodeName string, deviceIds []string, pod PodRequestedQuota) {
+	inv.lock.Lock()
+	defer inv.lock.Unlock()
+
+	r := &assumedReservation{
+		key:      inventoryKey{nodeName: nodeName, vendor: pod.Vendor, model: pod.Model},
+		reserved: inv.clock.Now(),
+	}
+	for i, share := range pod.Shares {
+		r.shares = append(r.shares, assumedShare{deviceId: deviceIds[i], requests: share.Requests, memory: share.Memory})
+	}
+	inv.assumed[pod.PodId] = r
+}
+
+// forget drops the assumed reservation of a pod whose quota was released.
+func (inv *deviceInventory) forget(podId string) {
+	inv.lock.Lock()
+	defer inv.lock.Unlock()
+
+	delete(inv.assumed, podId)
+}
+
+// invalidate makes the next get for nodeName ask the device manager, e.g.
+// because a new device was allocated on it.
+func (inv *deviceInventory) invalidate(nodeName string) {
+	inv.lock.Lock()
+	defer inv.lock.Unlock()
+
+	for key := range inv.entries {
+		if key.nodeName == nodeName {
+			delete(inv.entries, key)
+		}
+	}
+}
+
+// removeNode forgets everything about a deleted node.
+func (inv *deviceInventory) removeNode(nodeName string) {
+	inv.lock.Lock()
+	defer inv.lock.Unlock()
+
+	for key := range inv.entries {
+		if key.nodeName == nodeName {
+			delete(inv.entries, key)
+		}
+	}
+	for podId, r := range inv.assumed {
+		if r.key.nodeName == nodeName {
+			delete(inv.assumed, podId)
+		}
+	}
+	for key := range inv.unhealthyDevices {
+		if key.nodeName == nodeName {
+			delete(inv.unhealthyDevices, key)
+		}
+	}
+}
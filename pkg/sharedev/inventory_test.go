@@ -0,0 +1,296 @@
+package sharedev
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+// fakeInventoryFetcher serves the free resources set per node and counts the fetches.
+type fakeInventoryFetcher struct {
+	mu      sync.Mutex
+	free    map[string][]FreeDeviceResources
+	err     error
+	fetches int
+	// during is called in the middle of a fetch.
+	during func()
+}
+
+func (f *fakeInventoryFetcher) fetch(nodeName, nodeIP, vendor, model string) ([]FreeDeviceResources, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fetches++
+	if f.during != nil {
+		f.during()
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	free := make([]FreeDeviceResources, len(f.free[nodeName]))
+	copy(free, f.free[nodeName])
+	return free, nil
+}
+
+func TestDeviceInventoryGet(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	f := &fakeInventoryFetcher{free: map[string][]FreeDeviceResources{
+		"node1": {{DeviceId: "dev1", Requests: 1, Memory: 1}},
+	}}
+	inv := newDeviceInventory(f.fetch, nil, fakeClock, time.Second)
+	want := []FreeDeviceResources{{DeviceId: "dev1", Requests: 1, Memory: 1}}
+
+	for i := 0; i < 3; i++ {
+		got, err := inv.get("node1", "10.0.0.1", "example.com", "mydev")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected free resources (-want,+got):\n%s", diff)
+		}
+	}
+	if f.fetches != 1 {
+		t.Errorf("expected 1 fetch, got %d", f.fetches)
+	}
+
+	// A different model and a new node address aren't cached.
+	if _, err := inv.get("node1", "10.0.0.1", "example.com", "otherdev"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inv.get("node1", "10.0.0.2", "example.com", "mydev"); err != nil {
+		t.Fatal(err)
+	}
+	if f.fetches != 3 {
+		t.Errorf("expected 3 fetches, got %d", f.fetches)
+	}
+
+	// Invalidated nodes are fetched again.
+	inv.invalidate("node1")
+	if _, err := inv.get("node1", "10.0.0.2", "example.com", "mydev"); err != nil {
+		t.Fatal(err)
+	}
+	if f.fetches != 4 {
+		t.Errorf("expected 4 fetches, got %d", f.fetches)
+	}
+
+	// Failed fetches aren't cached.
+	f.err = fmt.Errorf("unavailable")
+	inv.invalidate("node1")
+	for i := 0; i < 2; i++ {
+		if _, err := inv.get("node1", "10.0.0.2", "example.com", "mydev"); err == nil {
+			t.Errorf("expected an error")
+		}
+	}
+	if f.fetches != 6 {
+		t.Errorf("expected 6 fetches, got %d", f.fetches)
+	}
+}
+
+func TestDeviceInventoryAssume(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	f := &fakeInventoryFetcher{free: map[string][]FreeDeviceResources{
+		"node1": {{DeviceId: "dev1", Requests: 1, Memory: 1}, {DeviceId: "dev2", Requests: 1, Memory: 1}},
+	}}
+	inv := newDeviceInventory(f.fetch, nil, fakeClock, time.Second)
+	pod := PodRequestedQuota{PodId: "p1", Vendor: "example.com", Model: "mydev", Shares: []ShareQuota{
+		{ClientId: "p1", Requests: 0.25, Memory: 0.5},
+		{ClientId: "p1.sidecar", Container: "sidecar", Requests: 0.1, Memory: 0.1},
+	}}
+	deviceIds := []string{"dev1", "dev2"}
+	assumed := []FreeDeviceResources{{DeviceId: "dev1", Requests: 0.75, Memory: 0.5}, {DeviceId: "dev2", Requests: 0.9, Memory: 0.9}}
+	vanilla := []FreeDeviceResources{{DeviceId: "dev1", Requests: 1, Memory: 1}, {DeviceId: "dev2", Requests: 1, Memory: 1}}
+
+	get := func(want []FreeDeviceResources) {
+		t.Helper()
+		got, err := inv.get("node1", "10.0.0.1", "example.com", "mydev")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected free resources (-want,+got):\n%s", diff)
+		}
+	}
+
+	get(vanilla)
+	fakeClock.Step(time.Millisecond)
+	inv.assume("node1", deviceIds, pod)
+	get(assumed)
+
+	// The device manager doesn't report the reservation yet, a fetch
+	// started before it must not drop it.
+	fakeClock.Step(time.Millisecond)
+	f.during = func() {
+		fakeClock.Step(time.Millisecond)
+		inv.assume("node1", deviceIds, pod)
+	}
+	inv.resync()
+	f.during = nil
+	get(assumed)
+
+	// Once a fetch started after the reservation, the device manager's view is trusted.
+	fakeClock.Step(time.Millisecond)
+	inv.resync()
+	get(vanilla)
+	if len(inv.assumed) != 0 {
+		t.Errorf("expected no assumed reservations, got %v", inv.assumed)
+	}
+
+	fakeClock.Step(time.Millisecond)
+	inv.assume("node1", deviceIds, pod)
+	get(assumed)
+	inv.forget("p1")
+	get(vanilla)
+}
+
+func TestDeviceInventoryResync(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	f := &fakeInventoryFetcher{free: map[string][]FreeDeviceResources{
+		"node1": {{DeviceId: "dev1", Requests: 1, Memory: 1}},
+		"node2": {{DeviceId: "dev2", Requests: 1, Memory: 1}},
+	}}
+	inv := newDeviceInventory(f.fetch, nil, fakeClock, time.Second)
+
+	if _, err := inv.get("node1", "10.0.0.1", "example.com", "mydev"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inv.get("node2", "10.0.0.2", "example.com", "mydev"); err != nil {
+		t.Fatal(err)
+	}
+
+	f.free["node1"] = []FreeDeviceResources{{DeviceId: "dev1", Requests: 0.5, Memory: 0.5}}
+	inv.resync()
+	if f.fetches != 4 {
+		t.Errorf("expected 4 fetches, got %d", f.fetches)
+	}
+	got, err := inv.get("node1", "10.0.0.1", "example.com", "mydev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(f.free["node1"], got); diff != "" {
+		t.Errorf("unexpected free resources (-want,+got):\n%s", diff)
+	}
+
+	// node2 wasn't asked for in a while and is dropped instead of refreshed.
+	fakeClock.Step(inv.idleTimeout + time.Second)
+	if _, err := inv.get("node1", "10.0.0.1", "example.com", "mydev"); err != nil {
+		t.Fatal(err)
+	}
+	inv.resync()
+	if f.fetches != 5 {
+		t.Errorf("expected 5 fetches, got %d", f.fetches)
+	}
+	if _, ok := inv.entries[inventoryKey{nodeName: "node2", vendor: "example.com", model: "mydev"}]; ok {
+		t.Errorf("expected node2 to be dropped")
+	}
+}
+
+func TestDeleteNode(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	f := &fakeInventoryFetcher{free: map[string][]FreeDeviceResources{}}
+	deviceManagers := newDeviceManagerPool(grpc.WithTransportCredentials(insecure.NewCredentials()))
+	defer deviceManagers.close()
+	sp := &ShareDevPlugin{
+		endpoints:      newEndpointCache(&nodeAddressResolver{port: 50051}),
+		deviceManagers: deviceManagers,
+		dmFailures:     newDeviceManagerFailures(fakeClock, time.Minute, time.Second),
+		inventory:      newDeviceInventory(f.fetch, nil, fakeClock, time.Second),
+	}
+
+	for _, nodeName := range []string{"node1", "node2"} {
+		if _, err := sp.deviceManagers.get(nodeName, "127.0.0.1:50051"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := sp.inventory.get(nodeName, "127.0.0.1:50051", "example.com", "mydev"); err != nil {
+			t.Fatal(err)
+		}
+		sp.inventory.assume(nodeName, nil, PodRequestedQuota{PodId: "p-" + nodeName, Vendor: "example.com", Model: "mydev"})
+		sp.dmFailures.observe(nodeName, fmt.Errorf("unavailable"))
+	}
+
+	sp.deleteNode(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}})
+	sp.deleteNode(cache.DeletedFinalStateUnknown{Key: "node2", Obj: &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}}})
+
+	if len(deviceManagers.health()) != 0 {
+		t.Errorf("expected all connections to be evicted, got %v", deviceManagers.health())
+	}
+	if len(sp.inventory.entries) != 0 || len(sp.inventory.assumed) != 0 {
+		t.Errorf("expected the inventory to be empty, got %v and %v", sp.inventory.entries, sp.inventory.assumed)
+	}
+	if len(sp.dmFailures.failures) != 0 {
+		t.Errorf("expected the device manager failures to be forgotten, got %v", sp.dmFailures.failures)
+	}
+}
+
+func TestDeviceInventoryUnhealthy(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	f := &fakeInventoryFetcher{free: map[string][]FreeDeviceResources{
+		"node1": {{DeviceId: "dev1", Requests: 1, Memory: 1}, {DeviceId: "dev2", Requests: 1, Memory: 1}},
+	}}
+	var reported []string
+	inv := newDeviceInventory(f.fetch, func(nodeName string, model deviceModel, device FreeDeviceResources) {
+		reported = append(reported, fmt.Sprintf("%s/%s/%s: %s", nodeName, modelName(model.vendor, model.model), device.DeviceId, device.HealthMessage))
+	}, fakeClock, time.Second)
+	setHealth := func(deviceId, message string) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for i := range f.free["node1"] {
+			if f.free["node1"][i].DeviceId == deviceId {
+				f.free["node1"][i].Unhealthy = message != ""
+				f.free["node1"][i].HealthMessage = message
+			}
+		}
+	}
+
+	steps := []struct {
+		name   string
+		change func()
+		want   []string
+	}{
+		{
+			name: "all healthy",
+		},
+		{
+			name:   "a device fails",
+			change: func() { setHealth("dev1", "ECC errors") },
+			want:   []string{"node1/example.com/mydev/dev1: ECC errors"},
+		},
+		{
+			name: "the device is only reported once",
+		},
+		{
+			name:   "the fetch fails",
+			change: func() { f.err = fmt.Errorf("unavailable") },
+		},
+		{
+			name:   "the device is still unhealthy once the fetch succeeds",
+			change: func() { f.err = nil },
+		},
+		{
+			name:   "the device recovers",
+			change: func() { setHealth("dev1", "") },
+		},
+		{
+			name:   "the device fails again",
+			change: func() { setHealth("dev1", "XID 79") },
+			want:   []string{"node1/example.com/mydev/dev1: XID 79"},
+		},
+	}
+	for _, step := range steps {
+		if step.change != nil {
+			step.change()
+		}
+		reported = nil
+		inv.refresh(inventoryKey{nodeName: "node1", vendor: "example.com", model: "mydev"}, "10.0.0.1")
+		if diff := cmp.Diff(step.want, reported); diff != "" {
+			t.Errorf("%s: unexpected unhealthy devices (-want,+got):\n%s", step.name, diff)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package sharedev
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+// reservationLease is the lease of the quota a share of a pod holds on a device.
+type reservationLease struct {
+	nodeName  string
+	endpoint  string
+	deviceId  string
+	clientId  string
+	podId     string
+	expiresAt time.Time
+}
+
+type renewLeaseFunc func(nodeName, endpoint, deviceId, clientId string) (time.Time, error)
+
+// leaseKeeper renews the leases of the quota reserved for pods that are bound,
+// or waiting at Permit, until they are gone. The other leases are left to
+// expire on the device managers: the quota of a pod is released on its own if
+// the scheduler crashes before binding it or if the pod never gets created.
+// The leases of the bound pods are taken from the devices PreBind recorded on
+// them as well, so the ones reserved before a restart, or by the previous
+// leader, keep being renewed.
+type leaseKeeper struct {
+	renew      renewLeaseFunc
+	clock      clock.Clock
+	podLister  corelisters.PodLister
+	nodeLister corelisters.NodeLister
+	endpoints  *endpointCache
+	// waiting tells whether the pod of uid holds its reservations at Permit.
+	waiting func(uid types.UID) bool
+
+	lock   sync.Mutex
+	leases map[string]*reservationLease // "device/client" -> lease
+}
+
+func newLeaseKeeper(renew renewLeaseFunc, clock clock.Clock, podLister corelisters.PodLister, nodeLister corelisters.NodeLister,
+	endpoints *endpointCache, waiting func(types.UID) bool) *leaseKeeper {
+	return &leaseKeeper{
+		renew:      renew,
+		clock:      clock,
+		podLister:  podLister,
+		nodeLister: nodeLister,
+		endpoints:  endpoints,
+		waiting:    waiting,
+		leases:     map[string]*reservationLease{},
+	}
+}
+
+// add starts renewing the lease of the quota clientId, a client of the pod
+// podId, reserved on deviceId. Quota reserved without a lease is ignored.
+func (k *leaseKeeper) add(nodeName, endpoint, deviceId, clientId, podId string, expiresAt time.Time) {
+	if expiresAt.IsZero() {
+		return
+	}
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	k.leases[deviceId+"/"+clientId] = &reservationLease{
+		nodeName:  nodeName,
+		endpoint:  endpoint,
+		deviceId:  deviceId,
+		clientId:  clientId,
+		podId:     podId,
+		expiresAt: expiresAt,
+	}
+}
+
+// remove stops renewing the lease of released quota.
+func (k *leaseKeeper) remove(deviceId, clientId string) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	delete(k.leases, deviceId+"/"+clientId)
+}
+
+// renewAll renews the leases of the pods still using their quota and forgets
+// the ones that expired, then adopts the leases of the bound pods it doesn't
+// keep yet.
+func (k *leaseKeeper) renewAll() {
+	k.lock.Lock()
+	leases := make([]reservationLease, 0, len(k.leases))
+	for _, l := range k.leases {
+		leases = append(leases, *l)
+	}
+	k.lock.Unlock()
+
+	for _, l := range leases {
+		if !k.clock.Now().Before(l.expiresAt) {
+			klog.InfoS("Shared device reservation lease expired", "node", l.nodeName, "device", l.deviceId, "client", l.clientId)
+			k.forget(l, time.Time{})
+			continue
+		}
+		renew, gone := k.inUse(l.podId)
+		if gone {
+			klog.V(4).InfoS("Letting the shared device reservation lease of a pod that is gone expire", "node", l.nodeName, "device", l.deviceId, "client", l.clientId)
+			k.forget(l, time.Time{})
+			continue
+		}
+		if !renew {
+			continue
+		}
+
+		expiresAt, err := k.renew(l.nodeName, l.endpoint, l.deviceId, l.clientId)
+		switch {
+		case status.Code(err) == codes.NotFound:
+			klog.InfoS("Shared device reservation lost before its lease was renewed", "node", l.nodeName, "device", l.deviceId, "client", l.clientId)
+			k.forget(l, time.Time{})
+		case err != nil:
+			klog.ErrorS(err, "Failed to renew shared device reservation lease", "node", l.nodeName, "device", l.deviceId, "client", l.clientId, "expiresAt", l.expiresAt)
+		default:
+			k.forget(l, expiresAt)
+		}
+	}
+	k.adoptBound()
+}
+
+// adoptBound renews the leases of the shares recorded on the bound client pods
+// that aren't kept, and keeps them from then on. Reserve only adds the leases
+// of the quota this scheduler reserved: the others would expire under running
+// pods after a restart or a leader failover.
+func (k *leaseKeeper) adoptBound() {
+	pods, err := k.podLister.List(labels.SelectorFromSet(labels.Set{sharedevLabel: clientLabelValue}))
+	if err != nil {
+		klog.ErrorS(err, "Failed to list shared device clients to renew their reservation leases")
+		return
+	}
+	// Nodes whose device manager doesn't lease quota, asked once per pass.
+	noLeases := sets.NewString()
+	for _, pod := range pods {
+		nodeName := pod.Spec.NodeName
+		if nodeName == "" || noLeases.Has(nodeName) || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		podId := podClientId(pod)
+		for _, d := range reservedDevices(podId, pod) {
+			if k.has(d.deviceId, d.clientId) {
+				continue
+			}
+			node, err := k.nodeLister.Get(nodeName)
+			if err != nil {
+				klog.V(4).InfoS("Skipping shared device reservation leases of a missing node", "pod", klog.KObj(pod), "node", nodeName, "err", err)
+				break
+			}
+			endpoint, err := k.endpoints.get(node)
+			if err != nil {
+				klog.V(4).InfoS("Skipping shared device reservation leases of node without device manager", "pod", klog.KObj(pod), "node", nodeName, "err", err)
+				break
+			}
+
+			expiresAt, err := k.renew(nodeName, endpoint, d.deviceId, d.clientId)
+			switch {
+			case status.Code(err) == codes.Unimplemented:
+				// The reservations of the device manager never expire.
+				noLeases.Insert(nodeName)
+			case status.Code(err) == codes.NotFound:
+				// Left to the reconciler, which reports it.
+				klog.V(4).InfoS("Shared device reservation of a bound pod is missing", "pod", klog.KObj(pod), "node", nodeName, "device", d.deviceId, "client", d.clientId)
+			case err != nil:
+				klog.ErrorS(err, "Failed to renew shared device reservation lease", "pod", klog.KObj(pod), "node", nodeName, "device", d.deviceId, "client", d.clientId)
+			default:
+				klog.V(4).InfoS("Adopted shared device reservation lease", "pod", klog.KObj(pod), "node", nodeName, "device", d.deviceId, "client", d.clientId, "expiresAt", expiresAt)
+				k.add(nodeName, endpoint, d.deviceId, d.clientId, podId, expiresAt)
+			}
+			if noLeases.Has(nodeName) {
+				break
+			}
+		}
+	}
+}
+
+// has tells whether the lease of the quota clientId reserved on deviceId is
+// kept.
+func (k *leaseKeeper) has(deviceId, clientId string) bool {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	_, ok := k.leases[deviceId+"/"+clientId]
+	return ok
+}
+
+// forget drops the lease, or records its new expiry if expiresAt is set,
+// unless the quota was reserved again since.
+func (k *leaseKeeper) forget(l reservationLease, expiresAt time.Time) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	key := l.deviceId + "/" + l.clientId
+	current, ok := k.leases[key]
+	if !ok || current.podId != l.podId || !current.expiresAt.Equal(l.expiresAt) {
+		return
+	}
+	if expiresAt.IsZero() {
+		delete(k.leases, key)
+		return
+	}
+	current.expiresAt = expiresAt
+}
+
+// inUse tells whether the lease of the pod podId is to be renewed, because the
+// pod is bound or waiting at Permit, and whether the pod is gone.
+func (k *leaseKeeper) inUse(podId string) (bool, bool) {
+	parts := strings.SplitN(podId, "/", 3)
+	if len(parts) != 3 {
+		return false, true
+	}
+	pod, err := k.podLister.Pods(parts[0]).Get(parts[1])
+	if err != nil || pod.UID != types.UID(parts[2]) ||
+		pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+		return false, true
+	}
+	return pod.Spec.NodeName != "" || k.waiting(pod.UID), false
+}
@@ -0,0 +1,150 @@
+package sharedev
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	testutil "sigs.k8s.io/scheduler-plugins/test/util"
+)
+
+func TestLeaseKeeper(t *testing.T) {
+	// Whole seconds, the device manager reports expiries in seconds.
+	fakeClock := clocktesting.NewFakeClock(time.Unix(1700000000, 0))
+	fdm := testutil.NewFakeDeviceManager().
+		WithClock(fakeClock).
+		AddDevice("example.com", "mydev", "dev1").
+		AddDevice("example.com", "mydev", "dev2")
+	sp, _ := newTestPlugin(t, map[string]*testutil.FakeDeviceManager{"10.0.0.1": fdm})
+	sp.leaseSeconds = 30
+
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	// Bound.
+	pods.Add(makeClientPod("p1", "p1-uid", nil))
+	// Never bound, e.g. the scheduler lost track of it.
+	unbound := makeClientPod("p2", "p2-uid", nil)
+	unbound.Spec.NodeName = ""
+	pods.Add(unbound)
+	// Waiting at Permit.
+	waiting := makeClientPod("p3", "p3-uid", nil)
+	waiting.Spec.NodeName = ""
+	pods.Add(waiting)
+	// p4 is deleted.
+	// Bound, but its reservation is lost by the device manager.
+	pods.Add(makeClientPod("p5", "p5-uid", nil))
+	sp.leases = newLeaseKeeper(sp.renewPodQuota, fakeClock, corelisters.NewPodLister(pods), sp.nodeLister, sp.endpoints, func(uid types.UID) bool { return uid == "p3-uid" })
+
+	for _, podId := range []string{"ns/p1/p1-uid", "ns/p2/p2-uid", "ns/p3/p3-uid", "ns/p4/p4-uid", "ns/p5/p5-uid"} {
+		q := quota(podId, 0.1, 0.1)
+		q.Shares[0].Limits = 0.1
+		expiresAt, err := sp.reservePodQuota("node1", "10.0.0.1:50051", "dev1", q.Shares[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fakeClock.Now().Add(30 * time.Second); !expiresAt.Equal(want) {
+			t.Errorf("expected the lease of %s to expire at %v, got %v", podId, want, expiresAt)
+		}
+		sp.leases.add("node1", "10.0.0.1:50051", "dev1", podId, podId, expiresAt)
+	}
+	// Forgotten once released.
+	q := quota("ns/p6/p6-uid", 0.1, 0.1)
+	q.Shares[0].ClientId += ".main"
+	q.Shares[0].Limits = 0.1
+	expiresAt, err := sp.reservePodQuota("node1", "10.0.0.1:50051", "dev2", q.Shares[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp.leases.add("node1", "10.0.0.1:50051", "dev2", q.Shares[0].ClientId, q.PodId, expiresAt)
+	sp.leases.remove("dev2", q.Shares[0].ClientId)
+	fdm.Release("ns/p5/p5-uid")
+
+	fakeClock.Step(20 * time.Second)
+	sp.leases.renewAll()
+	fakeClock.Step(15 * time.Second)
+
+	// The others weren't renewed and expired.
+	want := map[string]bool{
+		"dev1/ns/p1/p1-uid": true,
+		"dev1/ns/p3/p3-uid": true,
+	}
+	if diff := cmp.Diff(want, reservations(fdm, "dev1", "dev2")); diff != "" {
+		t.Errorf("unexpected reservations after the leases expired (-want,+got):\n%s", diff)
+	}
+
+	// The next pass forgets the expired leases.
+	sp.leases.renewAll()
+	leased := map[string]bool{}
+	for key := range sp.leases.leases {
+		leased[key] = true
+	}
+	if diff := cmp.Diff(want, leased); diff != "" {
+		t.Errorf("unexpected leases (-want,+got):\n%s", diff)
+	}
+}
+
+func TestLeaseKeeperAdoptsBoundPods(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Unix(1700000000, 0))
+	fdm := testutil.NewFakeDeviceManager().
+		WithClock(fakeClock).
+		AddDevice("example.com", "mydev", "dev1").
+		AddDevice("example.com", "mydev", "dev2")
+	sp, _ := newTestPlugin(t, map[string]*testutil.FakeDeviceManager{"10.0.0.1": fdm})
+	sp.leaseSeconds = 30
+
+	nodes := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	nodes.Add(makeEndpointNode("node1", nil, v1.NodeAddress{Type: v1.NodeInternalIP, Address: "10.0.0.1"}))
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	// Bound before the restart.
+	pods.Add(makeClientPod("p1", "p1-uid", map[string]string{
+		v1alpha1.SharedDeviceIDAnnotation:                            "dev1",
+		v1alpha1.SharedDeviceContainerIDAnnotationPrefix + "sidecar": "dev2",
+	}))
+	// Completed.
+	completed := makeClientPod("p2", "p2-uid", map[string]string{v1alpha1.SharedDeviceIDAnnotation: "dev1"})
+	completed.Status.Phase = v1.PodSucceeded
+	pods.Add(completed)
+	// Lost track of before PreBind.
+	pods.Add(makeClientPod("p3", "p3-uid", nil))
+	// Bound, but its reservation is lost by the device manager.
+	pods.Add(makeClientPod("p4", "p4-uid", map[string]string{v1alpha1.SharedDeviceIDAnnotation: "dev2"}))
+
+	// Reserved by the scheduler before it restarted.
+	for _, r := range []struct{ deviceId, clientId string }{
+		{"dev1", "ns/p1/p1-uid"},
+		{"dev2", "ns/p1/p1-uid.sidecar"},
+		{"dev1", "ns/p2/p2-uid"},
+		{"dev2", "ns/p3/p3-uid"},
+	} {
+		if _, err := sp.reservePodQuota("node1", "10.0.0.1:50051", r.deviceId, ShareQuota{ClientId: r.clientId, Requests: 0.1, Limits: 0.1, Memory: 0.1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sp.leases = newLeaseKeeper(sp.renewPodQuota, fakeClock, corelisters.NewPodLister(pods), corelisters.NewNodeLister(nodes),
+		sp.endpoints, func(types.UID) bool { return false })
+
+	fakeClock.Step(20 * time.Second)
+	sp.leases.renewAll()
+	fakeClock.Step(15 * time.Second)
+
+	want := map[string]bool{
+		"dev1/ns/p1/p1-uid":         true,
+		"dev2/ns/p1/p1-uid.sidecar": true,
+	}
+	if diff := cmp.Diff(want, reservations(fdm, "dev1", "dev2")); diff != "" {
+		t.Errorf("unexpected reservations after the leases expired (-want,+got):\n%s", diff)
+	}
+
+	// Adopted leases are renewed from then on.
+	sp.leases.renewAll()
+	fakeClock.Step(25 * time.Second)
+	if diff := cmp.Diff(want, reservations(fdm, "dev1", "dev2")); diff != "" {
+		t.Errorf("unexpected reservations after renewing the adopted leases (-want,+got):\n%s", diff)
+	}
+}
@@ -0,0 +1,261 @@
+package sharedev
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/status"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	metricsNamespace = "scheduler"
+	metricsSubsystem = "sharedev"
+)
+
+// Reasons of the Filter rejections.
+const (
+	rejectEndpointNotFound         = "endpoint_not_found"
+	rejectDeviceManagerUnavailable = "device_manager_unavailable"
+	rejectNoDevices                = "no_devices"
+	rejectUnhealthyDevices         = "unhealthy_devices"
+	rejectInsufficientResources    = "insufficient_resources"
+)
+
+const (
+	provisionSucceeded  = "success"
+	provisionFailed     = "error"
+	deviceStateFree     = "free"
+	deviceStateReserved = "reserved"
+	releaseSucceeded    = "success"
+	releaseFailed       = "error"
+)
+
+// Kinds of drift between the device manager reservations and the pods.
+const (
+	driftOrphaned = "orphaned"
+	driftMissing  = "missing"
+)
+
+// quotaEpsilon absorbs the rounding of the float quotas.
+const quotaEpsilon = 1e-9
+
+// recentRequestsWindow is how many recent shares of a device model the median
+// request is taken over.
+const recentRequestsWindow = 101
+
+var (
+	deviceManagerRequestDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Namespace:      metricsNamespace,
+			Subsystem:      metricsSubsystem,
+			Name:           "device_manager_request_duration_seconds",
+			Help:           "Latency of the device manager RPCs by method, node and gRPC status code.",
+			Buckets:        metrics.ExponentialBuckets(0.001, 2, 15),
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"method", "node", "code"},
+	)
+
+	filterRejections = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metricsNamespace,
+			Subsystem:      metricsSubsystem,
+			Name:           "filter_rejections_total",
+			Help:           "Number of nodes Filter rejected, by reason.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"reason"},
+	)
+
+	allocatorProvisions = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metricsNamespace,
+			Subsystem:      metricsSubsystem,
+			Name:           "allocator_provisions_total",
+			Help:           "Number of allocators created, by vendor, model and result.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"vendor", "model", "result"},
+	)
+
+	allocatorReadyDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Namespace:      metricsNamespace,
+			Subsystem:      metricsSubsystem,
+			Name:           "allocator_ready_duration_seconds",
+			Help:           "Time from requesting an allocator to its pod running, by vendor and model.",
+			Buckets:        metrics.ExponentialBuckets(1, 2, 10),
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"vendor", "model"},
+	)
+
+	deviceCompute = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      metricsNamespace,
+			Subsystem:      metricsSubsystem,
+			Name:           "device_compute",
+			Help:           "Free and reserved compute of the known devices, in devices, by vendor, model and state.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"vendor", "model", "state"},
+	)
+
+	deviceMemory = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      metricsNamespace,
+			Subsystem:      metricsSubsystem,
+			Name:           "device_memory",
+			Help:           "Free and reserved memory of the known devices, in devices, by vendor, model and state.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"vendor", "model", "state"},
+	)
+
+	fragmentedCompute = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      metricsNamespace,
+			Subsystem:      metricsSubsystem,
+			Name:           "fragmented_compute",
+			Help:           "Free compute, in devices, left on devices that can't host the median recent request, by vendor and model.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"vendor", "model"},
+	)
+
+	reservationDrift = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      metricsNamespace,
+			Subsystem:      metricsSubsystem,
+			Name:           "reservation_drift",
+			Help:           "Reservations without a pod, and reservations of bound pods missing on the device managers, found by the last reconciliation.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"kind"},
+	)
+
+	orphanReleases = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metricsNamespace,
+			Subsystem:      metricsSubsystem,
+			Name:           "orphaned_reservation_releases_total",
+			Help:           "Number of reservations released by the reconciliation because their pod is gone, by result.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"result"},
+	)
+
+	metricsList = []metrics.Registerable{
+		deviceManagerRequestDuration,
+		filterRejections,
+		allocatorProvisions,
+		allocatorReadyDuration,
+		deviceCompute,
+		deviceMemory,
+		fragmentedCompute,
+		reservationDrift,
+		orphanReleases,
+	}
+)
+
+var metricsOnce sync.Once
+
+// registerMetrics registers the ShareDevPlugin metrics with the legacy
+// registry the scheduler serves.
+func registerMetrics() {
+	metricsOnce.Do(func() {
+		for _, metric := range metricsList {
+			legacyregistry.MustRegister(metric)
+		}
+	})
+}
+
+// observeDeviceManagerRequest records an RPC to the device manager of nodeName.
+func observeDeviceManagerRequest(method, nodeName string, start time.Time, err error) {
+	deviceManagerRequestDuration.WithLabelValues(method, nodeName, status.Code(err).String()).Observe(time.Since(start).Seconds())
+}
+
+// recentRequests keeps the last shares requested of every device model.
+type recentRequests struct {
+	lock   sync.Mutex
+	shares map[deviceModel][]ShareQuota
+}
+
+func newRecentRequests() *recentRequests {
+	return &recentRequests{shares: map[deviceModel][]ShareQuota{}}
+}
+
+func (r *recentRequests) record(pod PodRequestedQuota) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	key := deviceModel{vendor: pod.Vendor, model: pod.Model}
+	shares := append(r.shares[key], pod.Shares...)
+	if len(shares) > recentRequestsWindow {
+		shares = append([]ShareQuota{}, shares[len(shares)-recentRequestsWindow:]...)
+	}
+	r.shares[key] = shares
+}
+
+// median returns the median compute and memory of the recent shares of key.
+func (r *recentRequests) median(key deviceModel) (float64, float64, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	shares := r.shares[key]
+	if len(shares) == 0 {
+		return 0, 0, false
+	}
+	requests := make([]float64, len(shares))
+	memory := make([]float64, len(shares))
+	for i, share := range shares {
+		requests[i], memory[i] = share.Requests, share.Memory
+	}
+	sort.Float64s(requests)
+	sort.Float64s(memory)
+	return requests[len(shares)/2], memory[len(shares)/2], true
+}
+
+// recordFleetMetrics sets the device gauges from the inventory.
+func (sp *ShareDevPlugin) recordFleetMetrics() {
+	deviceCompute.Reset()
+	deviceMemory.Reset()
+	fragmentedCompute.Reset()
+
+	for key, devices := range sp.inventory.devices() {
+		// Unhealthy devices can't host shares.
+		devices = healthyDevices(devices)
+		var freeRequests, freeMemory float64
+		for _, d := range devices {
+			freeRequests += d.Requests
+			freeMemory += d.Memory
+		}
+		total := float64(len(devices))
+		deviceCompute.WithLabelValues(key.vendor, key.model, deviceStateFree).Set(freeRequests)
+		deviceCompute.WithLabelValues(key.vendor, key.model, deviceStateReserved).Set(total - freeRequests)
+		deviceMemory.WithLabelValues(key.vendor, key.model, deviceStateFree).Set(freeMemory)
+		deviceMemory.WithLabelValues(key.vendor, key.model, deviceStateReserved).Set(total - freeMemory)
+
+		if requests, memory, ok := sp.requests.median(key); ok {
+			fragmentedCompute.WithLabelValues(key.vendor, key.model).Set(fragmented(devices, requests, memory))
+		}
+	}
+}
+
+// fragmented returns the free compute of the devices that can't host a share
+// of requests compute and memory.
+func fragmented(devices []FreeDeviceResources, requests, memory float64) float64 {
+	var stranded float64
+	for _, d := range devices {
+		if d.Requests <= quotaEpsilon {
+			continue
+		}
+		if d.Requests+quotaEpsilon < requests || d.Memory+quotaEpsilon < memory {
+			stranded += d.Requests
+		}
+	}
+	return stranded
+}
@@ -0,0 +1,194 @@
+package sharedev
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	metricstestutil "k8s.io/component-base/metrics/testutil"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	testutil "sigs.k8s.io/scheduler-plugins/test/util"
+)
+
+func rejections(t *testing.T, reason string) float64 {
+	t.Helper()
+	value, err := metricstestutil.GetCounterMetricValue(filterRejections.WithLabelValues(reason))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+func TestRecentRequestsMedian(t *testing.T) {
+	r := newRecentRequests()
+	key := deviceModel{vendor: "example.com", model: "mydev"}
+	if _, _, ok := r.median(key); ok {
+		t.Fatalf("expected no median without requests")
+	}
+
+	r.record(quota("p1", 0.5, 0.1))
+	r.record(quota("p2", 0.25, 0.3))
+	r.record(quota("p3", 0.75, 0.2))
+	if requests, memory, _ := r.median(key); requests != 0.5 || memory != 0.2 {
+		t.Errorf("expected median 0.5 compute and 0.2 memory, got %v and %v", requests, memory)
+	}
+
+	// Only the recent requests count.
+	for i := 0; i < recentRequestsWindow; i++ {
+		r.record(quota("p", 0.1, 0.1))
+	}
+	if requests, memory, _ := r.median(key); requests != 0.1 || memory != 0.1 {
+		t.Errorf("expected median 0.1 compute and 0.1 memory, got %v and %v", requests, memory)
+	}
+	if got := len(r.shares[key]); got != recentRequestsWindow {
+		t.Errorf("expected %d recent shares, got %d", recentRequestsWindow, got)
+	}
+}
+
+func TestFragmented(t *testing.T) {
+	devices := []FreeDeviceResources{
+		{DeviceId: "full", Requests: 0, Memory: 0},
+		{DeviceId: "empty", Requests: 1, Memory: 1},
+		{DeviceId: "small", Requests: 0.2, Memory: 0.5},
+		{DeviceId: "no-memory", Requests: 0.6, Memory: 0.1},
+		{DeviceId: "exact", Requests: 0.3, Memory: 0.3},
+	}
+	if got := fragmented(devices, 0.3, 0.3); got != 0.8 {
+		t.Errorf("expected 0.8 fragmented compute, got %v", got)
+	}
+}
+
+func TestRecordFleetMetrics(t *testing.T) {
+	registerMetrics()
+	f := &fakeInventoryFetcher{free: map[string][]FreeDeviceResources{
+		"node1": {{DeviceId: "dev1", Requests: 1, Memory: 1}, {DeviceId: "dev2", Requests: 0.25, Memory: 0.5}},
+		"node2": {{DeviceId: "dev3", Requests: 0.5, Memory: 0.25}},
+	}}
+	sp := &ShareDevPlugin{
+		inventory: newDeviceInventory(f.fetch, nil, clocktesting.NewFakeClock(time.Now()), time.Second),
+		requests:  newRecentRequests(),
+	}
+	for _, nodeName := range []string{"node1", "node2"} {
+		if _, err := sp.inventory.get(nodeName, "", "example.com", "mydev"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Reserved since the last fetch.
+	sp.inventory.assume("node1", []string{"dev1"}, quota("p1", 0.5, 0.5))
+	sp.requests.record(quota("p2", 0.5, 0.5))
+
+	sp.recordFleetMetrics()
+
+	for _, tt := range []struct {
+		gauge  string
+		labels []string
+		want   float64
+	}{
+		{"compute", []string{"free"}, 1.25},
+		{"compute", []string{"reserved"}, 1.75},
+		{"memory", []string{"free"}, 1.25},
+		{"memory", []string{"reserved"}, 1.75},
+		// dev2 and dev3 can't host half a device.
+		{"fragmented", nil, 0.75},
+	} {
+		labels := append([]string{"example.com", "mydev"}, tt.labels...)
+		var got float64
+		var err error
+		switch tt.gauge {
+		case "compute":
+			got, err = metricstestutil.GetGaugeMetricValue(deviceCompute.WithLabelValues(labels...))
+		case "memory":
+			got, err = metricstestutil.GetGaugeMetricValue(deviceMemory.WithLabelValues(labels...))
+		case "fragmented":
+			got, err = metricstestutil.GetGaugeMetricValue(fragmentedCompute.WithLabelValues(labels...))
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("expected %s %v to be %v, got %v", tt.gauge, tt.labels, tt.want, got)
+		}
+	}
+}
+
+func TestFilterMetrics(t *testing.T) {
+	registerMetrics()
+	fdm := testutil.NewFakeDeviceManager().AddDevice("example.com", "mydev", "dev1").Reserve("dev1", "other", 0.75, 0.75)
+	sp, _ := newTestPlugin(t, map[string]*testutil.FakeDeviceManager{"10.0.0.1": fdm})
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "metrics-node"},
+		Status:     v1.NodeStatus{Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.1"}}},
+	}
+
+	filter := func(node *v1.Node) {
+		cycleState := framework.NewCycleState()
+		cycleState.Write(ShareDevStateKey, &ShareDevState{
+			PodQ:                       quota("p1", 0.5, 0.5),
+			FreeDeviceResourcesPerNode: map[string][]FreeDeviceResources{},
+			NodeNameToEndpoint:         map[string]string{},
+		})
+		nodeInfo := framework.NewNodeInfo()
+		nodeInfo.SetNode(node)
+		sp.Filter(context.Background(), cycleState, makeClaimPod("p1", "half"), nodeInfo)
+	}
+
+	requests := deviceManagerRequestDuration.WithLabelValues("GetAvailableDevices", "metrics-node", "OK")
+	requestsBefore, err := metricstestutil.GetHistogramMetricCount(requests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	insufficient := rejections(t, rejectInsufficientResources)
+	notFound := rejections(t, rejectEndpointNotFound)
+	filter(node)
+	filter(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "no-address"}})
+
+	if got := rejections(t, rejectInsufficientResources) - insufficient; got != 1 {
+		t.Errorf("expected 1 insufficient resources rejection, got %v", got)
+	}
+	if got := rejections(t, rejectEndpointNotFound) - notFound; got != 1 {
+		t.Errorf("expected 1 endpoint not found rejection, got %v", got)
+	}
+	if count, _ := metricstestutil.GetHistogramMetricCount(requests); count-requestsBefore != 1 {
+		t.Errorf("expected 1 GetAvailableDevices request, got %d", count-requestsBefore)
+	}
+}
+
+func TestProvisionerMetrics(t *testing.T) {
+	registerMetrics()
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	f := newFakeAllocators()
+	p := newDeviceProvisioner(f.create, f.allocatorReady, fakeClock, time.Minute, 5*time.Second)
+	provisions := allocatorProvisions.WithLabelValues("example.com", "metrics", provisionSucceeded)
+	before, err := metricstestutil.GetCounterMetricValue(provisions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ready := allocatorReadyDuration.WithLabelValues("example.com", "metrics")
+	readyBefore, _ := metricstestutil.GetHistogramMetricCount(ready)
+	readySumBefore, _ := metricstestutil.GetHistogramMetricValue(ready)
+
+	pod := quota("p1", 0.5, 0.5)
+	pod.Model = "metrics"
+	p.request(pod)
+	f.wait(t, 1)
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		after, err := metricstestutil.GetCounterMetricValue(provisions)
+		return after-before == 1, err
+	}); err != nil {
+		t.Fatalf("expected 1 provisioned allocator: %v", err)
+	}
+
+	fakeClock.Step(20 * time.Second)
+	p.updatePod(makeAllocatorPod(f.createdNames()[0], "node1", v1.PodRunning))
+	if count, _ := metricstestutil.GetHistogramMetricCount(ready); count-readyBefore != 1 {
+		t.Errorf("expected 1 ready allocator, got %d", count-readyBefore)
+	}
+	if sum, _ := metricstestutil.GetHistogramMetricValue(ready); sum-readySumBefore != 20 {
+		t.Errorf("expected 20s to ready, got %v", sum-readySumBefore)
+	}
+}
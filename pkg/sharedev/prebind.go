@@ -0,0 +1,111 @@
+package sharedev
+
+import (
+	"context"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	"sigs.k8s.io/scheduler-plugins/pkg/util"
+)
+
+// PreBind records the device reserved for the pod as annotations and in the
+// status of its claim, so the pod keeps its identity through binding. The env vars the device client
+// needs are injected at admission time and resolved from these annotations
+// by the kubelet when the containers start.
+func (sp *ShareDevPlugin) PreBind(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) *framework.Status {
+	shareDevState, err := getShareDevState(state)
+	if err != nil {
+		return framework.NewStatus(framework.Error, err.Error())
+	}
+
+	podQ := shareDevState.PodQ
+	if len(shareDevState.ReservedDeviceIds) != len(podQ.Shares) {
+		return framework.NewStatus(framework.Error, "shared devices of the pod are not reserved")
+	}
+	podCopy := annotatePod(pod, endpointHost(shareDevState.endpointOf(nodeName)), podQ, shareDevState.ReservedDeviceIds)
+
+	patch, err := util.CreateMergePatch(pod, podCopy)
+	if err != nil {
+		return framework.NewStatus(framework.Error, err.Error())
+	}
+	_, err = sp.handle.ClientSet().CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "Failed to annotate pod with its shared devices", "pod", klog.KObj(pod), "node", nodeName)
+		return framework.NewStatus(framework.Error, err.Error())
+	}
+	shareDevState.PreBound = true
+
+	err = sp.bindClaim(ctx, pod, nodeName, podQ, shareDevState.ReservedDeviceIds)
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "Failed to update SharedDeviceClaim status", "pod", klog.KObj(pod), "sharedDeviceClaim", klog.KRef(pod.Namespace, podQ.ClaimName))
+		return framework.NewStatus(framework.Error, err.Error())
+	}
+
+	return framework.NewStatus(framework.Success)
+}
+
+// unbindPod undoes PreBind after the binding failed: it removes the
+// annotations recording the devices from the pod and the pod from the status
+// of its claim, as the shares were released.
+func (sp *ShareDevPlugin) unbindPod(ctx context.Context, pod *v1.Pod, podQ PodRequestedQuota, deviceIds []string) error {
+	// pod is the pod as it was before PreBind.
+	patch, err := util.CreateMergePatch(annotatePod(pod, "", podQ, deviceIds), pod)
+	if err != nil {
+		return err
+	}
+	_, err = sp.handle.ClientSet().CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return sp.unbindClaim(ctx, pod, podQ.ClaimName)
+}
+
+// annotatePod returns a copy of the pod recording the device assignment of
+// its shares, deviceIds being by share index.
+func annotatePod(pod *v1.Pod, hostIP string, podQ PodRequestedQuota, deviceIds []string) *v1.Pod {
+	podCopy := pod.DeepCopy()
+	if podCopy.Labels == nil {
+		podCopy.Labels = map[string]string{}
+	}
+	if podCopy.Annotations == nil {
+		podCopy.Annotations = map[string]string{}
+	}
+
+	// this label is used by Device Managers to query healthy pods
+	// and run garbage collection to free up devices
+	podCopy.Labels[sharedevLabel] = clientLabelValue
+	podDeviceIds, containerDeviceIds := splitDeviceIds(podQ, deviceIds)
+	podCopy.Annotations[v1alpha1.SharedDeviceIDAnnotation] = strings.Join(podDeviceIds, ",")
+	for container, deviceId := range containerDeviceIds {
+		podCopy.Annotations[v1alpha1.SharedDeviceContainerIDAnnotationPrefix+container] = deviceId
+	}
+	podCopy.Annotations[v1alpha1.SharedDeviceHostIPAnnotation] = hostIP
+	podCopy.Annotations[v1alpha1.SharedDeviceModelAnnotation] = modelName(podQ.Vendor, podQ.Model)
+
+	return podCopy
+}
+
+// splitDeviceIds returns the devices of the pod's shares and of the container
+// shares, by container name.
+func splitDeviceIds(podQ PodRequestedQuota, deviceIds []string) ([]string, map[string]string) {
+	var podDeviceIds []string
+	var containerDeviceIds map[string]string
+	for i, share := range podQ.Shares {
+		if share.Container == "" {
+			podDeviceIds = append(podDeviceIds, deviceIds[i])
+			continue
+		}
+		if containerDeviceIds == nil {
+			containerDeviceIds = map[string]string{}
+		}
+		containerDeviceIds[share.Container] = deviceIds[i]
+	}
+	return podDeviceIds, containerDeviceIds
+}
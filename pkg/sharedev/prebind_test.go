@@ -0,0 +1,241 @@
+package sharedev
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/defaultbinder"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/queuesort"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+	st "k8s.io/kubernetes/pkg/scheduler/testing"
+
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	fakeclientset "sigs.k8s.io/scheduler-plugins/pkg/generated/clientset/versioned/fake"
+	schedinformer "sigs.k8s.io/scheduler-plugins/pkg/generated/informers/externalversions"
+	testutil "sigs.k8s.io/scheduler-plugins/test/util"
+)
+
+func TestPreBind(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pod := withContainers(makeClaimPod("p1", "multi"), "main", "sidecar")
+	pod.UID = "uid1"
+	claim := withSidecar(withCount(makeClaim("multi", "500m", "500m", nil), 2), "sidecar")
+
+	cs := clientsetfake.NewSimpleClientset(pod)
+	registeredPlugins := []st.RegisterPluginFunc{
+		st.RegisterQueueSortPlugin(queuesort.Name, queuesort.New),
+		st.RegisterBindPlugin(defaultbinder.Name, defaultbinder.New),
+	}
+	fh, err := st.NewFramework(registeredPlugins, "", ctx.Done(), frameworkruntime.WithClientSet(cs))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The claim is already used by a running pod and by one that is gone.
+	running := withContainers(makeClaimPod("p0", "multi"), "main", "sidecar")
+	running.UID = "uid0"
+	claim.Status.Pods = []v1alpha1.SharedDeviceClaimPodStatus{
+		{Name: "gone", UID: "uid-gone", NodeName: "node3", DeviceIDs: []string{"dev9"}},
+		{Name: "p0", UID: "uid0", NodeName: "node2", DeviceIDs: []string{"dev3", "dev4"}, ContainerDeviceIDs: map[string]string{"sidecar": "dev3"}, Vendor: "example.com", Model: "mydev"},
+	}
+	claimClient := fakeclientset.NewSimpleClientset(claim)
+	claimInformer := schedinformer.NewSharedInformerFactory(claimClient, 0).Scheduling().V1alpha1().SharedDeviceClaims()
+	claimInformer.Informer().GetStore().Add(claim)
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	pods.Add(pod)
+	pods.Add(running)
+
+	sp := &ShareDevPlugin{handle: fh, claimClient: claimClient, claimLister: claimInformer.Lister(), podLister: corelisters.NewPodLister(pods)}
+
+	cycleState := framework.NewCycleState()
+	podQ, err := claimToQuota(pod, claim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cycleState.Write(ShareDevStateKey, &ShareDevState{
+		PodQ:               *podQ,
+		NodeNameToEndpoint: map[string]string{"node1": "10.0.0.1:50051"},
+		ReservedDeviceIds:  []string{"dev1", "dev2", "dev1"},
+	})
+
+	if status := sp.PreBind(ctx, cycleState, pod, "node1"); !status.IsSuccess() {
+		t.Fatalf("unexpected status: %v", status)
+	}
+
+	got, err := cs.CoreV1().Pods("ns").Get(ctx, "p1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.UID != pod.UID {
+		t.Errorf("expected pod identity to be kept, got UID %q", got.UID)
+	}
+	if got.Spec.NodeName != "" {
+		t.Errorf("expected binding to be left to the binder, got node %q", got.Spec.NodeName)
+	}
+	if got.Labels["sharedev"] != "client" {
+		t.Errorf("expected sharedev=client label, got %v", got.Labels)
+	}
+	wantAnnotations := map[string]string{
+		v1alpha1.SharedDeviceIDAnnotation:                            "dev1,dev2",
+		v1alpha1.SharedDeviceContainerIDAnnotationPrefix + "sidecar": "dev1",
+		v1alpha1.SharedDeviceHostIPAnnotation:                        "10.0.0.1",
+		v1alpha1.SharedDeviceModelAnnotation:                         "example.com/mydev",
+	}
+	for k, v := range wantAnnotations {
+		if got.Annotations[k] != v {
+			t.Errorf("expected annotation %s=%s, got %v", k, v, got.Annotations)
+		}
+	}
+
+	gotClaim, err := claimClient.SchedulingV1alpha1().SharedDeviceClaims("ns").Get(ctx, "multi", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The running pod stays first.
+	wantStatus := v1alpha1.SharedDeviceClaimStatus{
+		Phase:              v1alpha1.SharedDeviceClaimBound,
+		DeviceID:           "dev3",
+		DeviceIDs:          []string{"dev3", "dev4"},
+		ContainerDeviceIDs: map[string]string{"sidecar": "dev3"},
+		NodeName:           "node2",
+		Vendor:             "example.com",
+		Model:              "mydev",
+		Pods: []v1alpha1.SharedDeviceClaimPodStatus{
+			claim.Status.Pods[1],
+			{Name: "p1", UID: "uid1", NodeName: "node1", DeviceIDs: []string{"dev1", "dev2"}, ContainerDeviceIDs: map[string]string{"sidecar": "dev1"}, Vendor: "example.com", Model: "mydev"},
+		},
+	}
+	if diff := cmp.Diff(wantStatus, gotClaim.Status); diff != "" {
+		t.Errorf("unexpected claim status (-want,+got):\n%s", diff)
+	}
+}
+
+func TestPreBindClaimUpdateFails(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pod := makeClaimPod("p1", "half")
+	claim := makeClaim("half", "500m", "500m", nil)
+	cs := clientsetfake.NewSimpleClientset(pod)
+	registeredPlugins := []st.RegisterPluginFunc{
+		st.RegisterQueueSortPlugin(queuesort.Name, queuesort.New),
+		st.RegisterBindPlugin(defaultbinder.Name, defaultbinder.New),
+	}
+	fh, err := st.NewFramework(registeredPlugins, "", ctx.Done(), frameworkruntime.WithClientSet(cs))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The claim is in the lister but was deleted from the API server.
+	claimClient := fakeclientset.NewSimpleClientset()
+	claimInformer := schedinformer.NewSharedInformerFactory(claimClient, 0).Scheduling().V1alpha1().SharedDeviceClaims()
+	claimInformer.Informer().GetStore().Add(claim)
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	sp := &ShareDevPlugin{handle: fh, claimClient: claimClient, claimLister: claimInformer.Lister(), podLister: corelisters.NewPodLister(pods)}
+
+	podQ, err := claimToQuota(pod, claim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cycleState := framework.NewCycleState()
+	cycleState.Write(ShareDevStateKey, &ShareDevState{
+		PodQ:               *podQ,
+		NodeNameToEndpoint: map[string]string{"node1": "10.0.0.1:50051"},
+		ReservedDeviceIds:  []string{"dev1"},
+	})
+
+	if status := sp.PreBind(ctx, cycleState, pod, "node1"); status.Code() != framework.Error {
+		t.Errorf("expected an error, got %v", status)
+	}
+}
+
+func TestUnreserveAfterPreBind(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pod := makeClaimPod("p1", "half")
+	pod.UID = "uid1"
+	running := makeClaimPod("p0", "half")
+	running.UID = "uid0"
+	claim := makeClaim("half", "500m", "500m", nil)
+	claim.Status.Pods = []v1alpha1.SharedDeviceClaimPodStatus{
+		{Name: "p0", UID: "uid0", NodeName: "node2", DeviceIDs: []string{"dev3"}, Vendor: "example.com", Model: "mydev"},
+	}
+	podQ, err := claimToQuota(pod, claim)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fdm := testutil.NewFakeDeviceManager().
+		AddDevice("example.com", "mydev", "dev1").
+		Reserve("dev1", podQ.Shares[0].ClientId, 0.5, 0.5)
+	sp, _ := newTestPlugin(t, map[string]*testutil.FakeDeviceManager{"10.0.0.1": fdm})
+
+	cs := clientsetfake.NewSimpleClientset(pod)
+	registeredPlugins := []st.RegisterPluginFunc{
+		st.RegisterQueueSortPlugin(queuesort.Name, queuesort.New),
+		st.RegisterBindPlugin(defaultbinder.Name, defaultbinder.New),
+	}
+	fh, err := st.NewFramework(registeredPlugins, "", ctx.Done(), frameworkruntime.WithClientSet(cs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimClient := fakeclientset.NewSimpleClientset(claim)
+	claimInformer := schedinformer.NewSharedInformerFactory(claimClient, 0).Scheduling().V1alpha1().SharedDeviceClaims()
+	claimInformer.Informer().GetStore().Add(claim)
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	pods.Add(pod)
+	pods.Add(running)
+	sp.handle, sp.claimClient, sp.claimLister, sp.podLister = fh, claimClient, claimInformer.Lister(), corelisters.NewPodLister(pods)
+
+	cycleState := framework.NewCycleState()
+	cycleState.Write(ShareDevStateKey, &ShareDevState{
+		PodQ:               *podQ,
+		NodeNameToEndpoint: map[string]string{"node1": "10.0.0.1:50051"},
+		ReservedDeviceIds:  []string{"dev1"},
+	})
+	if status := sp.PreBind(ctx, cycleState, pod, "node1"); !status.IsSuccess() {
+		t.Fatalf("unexpected status: %v", status)
+	}
+	// The binder fails.
+	sp.Unreserve(ctx, cycleState, pod, "node1")
+
+	got, err := cs.CoreV1().Pods("ns").Get(ctx, "p1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(pod.Labels, got.Labels); diff != "" {
+		t.Errorf("unexpected labels (-want,+got):\n%s", diff)
+	}
+	if len(got.Annotations) != 0 {
+		t.Errorf("expected the shared device annotations to be removed, got %v", got.Annotations)
+	}
+
+	gotClaim, err := claimClient.SchedulingV1alpha1().SharedDeviceClaims("ns").Get(ctx, "half", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantStatus := v1alpha1.SharedDeviceClaimStatus{
+		Phase:     v1alpha1.SharedDeviceClaimBound,
+		DeviceID:  "dev3",
+		DeviceIDs: []string{"dev3"},
+		NodeName:  "node2",
+		Vendor:    "example.com",
+		Model:     "mydev",
+		Pods:      claim.Status.Pods,
+	}
+	if diff := cmp.Diff(wantStatus, gotClaim.Status); diff != "" {
+		t.Errorf("unexpected claim status (-want,+got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]bool{}, reservations(fdm, "dev1")); diff != "" {
+		t.Errorf("unexpected reservations (-want,+got):\n%s", diff)
+	}
+}
@@ -0,0 +1,343 @@
+package sharedev
+
+import (
+	"context"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1"
+	corev1helpers "k8s.io/component-helpers/scheduling/corev1"
+	"k8s.io/klog/v2"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/preemption"
+	schedutil "k8s.io/kubernetes/pkg/scheduler/util"
+
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	"sigs.k8s.io/scheduler-plugins/pkg/util"
+)
+
+var _ preemption.Interface = &preemptor{}
+
+// preempt evicts lower-priority clients of the devices of one node to make
+// room for the pod, like DefaultPreemption does for the regular resources.
+func (sp *ShareDevPlugin) preempt(ctx context.Context, state *framework.CycleState, pod *v1.Pod, m framework.NodeToStatusMap) (*framework.PostFilterResult, *framework.Status) {
+	pe := preemption.Evaluator{
+		PluginName: sp.Name(),
+		Handler:    sp.handle,
+		PodLister:  sp.podLister,
+		PdbLister:  sp.pdbLister,
+		State:      state,
+		Interface: &preemptor{
+			sp:    sp,
+			state: state,
+		},
+	}
+	return pe.Preempt(ctx, pod, m)
+}
+
+// AddPod takes the shares of a pod added back to the node in the preemption
+// dry run, or of a pod nominated to the node, from the free devices Filter
+// sees.
+func (sp *ShareDevPlugin) AddPod(ctx context.Context, cycleState *framework.CycleState, podToSchedule *v1.Pod, podInfoToAdd *framework.PodInfo, nodeInfo *framework.NodeInfo) *framework.Status {
+	return sp.updateReleased(cycleState, podInfoToAdd.Pod, nodeInfo, -1)
+}
+
+// RemovePod gives the shares of a pod removed from the node in the preemption
+// dry run back to the free devices Filter sees.
+func (sp *ShareDevPlugin) RemovePod(ctx context.Context, cycleState *framework.CycleState, podToSchedule *v1.Pod, podInfoToRemove *framework.PodInfo, nodeInfo *framework.NodeInfo) *framework.Status {
+	return sp.updateReleased(cycleState, podInfoToRemove.Pod, nodeInfo, 1)
+}
+
+func (sp *ShareDevPlugin) updateReleased(cycleState *framework.CycleState, pod *v1.Pod, nodeInfo *framework.NodeInfo, sign float64) *framework.Status {
+	shareDevState, err := getShareDevState(cycleState)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+
+	nodeName := nodeInfo.Node().Name
+	if pod.Spec.NodeName == "" {
+		// Nominated, its devices are only picked once it is scheduled.
+		sp.updateNominated(shareDevState, pod, nodeName, sign)
+		return nil
+	}
+	for _, share := range sp.podShares(pod, shareDevState.models()) {
+		if shareDevState.Released == nil {
+			shareDevState.Released = map[string][]FreeDeviceResources{}
+		}
+		share.Requests *= sign
+		share.Memory *= sign
+		shareDevState.Released[nodeName] = append(shareDevState.Released[nodeName], share)
+	}
+	return nil
+}
+
+// updateNominated adds the quotas of a pod nominated to the node, or removes
+// them for a positive sign.
+func (sp *ShareDevPlugin) updateNominated(shareDevState *ShareDevState, pod *v1.Pod, nodeName string, sign float64) {
+	podModels, err := sp.parsePodModels(pod)
+	if err != nil {
+		return
+	}
+	podId := podModels[0].PodId
+	nominated := shareDevState.Nominated[nodeName]
+	for i, models := range nominated {
+		if models[0].PodId == podId {
+			nominated = append(nominated[:i:i], nominated[i+1:]...)
+			break
+		}
+	}
+	if sign < 0 {
+		nominated = append(nominated, podModels)
+	}
+	if shareDevState.Nominated == nil {
+		shareDevState.Nominated = map[string][][]PodRequestedQuota{}
+	}
+	shareDevState.Nominated[nodeName] = nominated
+}
+
+// podShares returns the shares a bound client pod holds on the devices of its
+// node, from its claim and the devices and model PreBind recorded on it, if
+// the devices are of one of models.
+func (sp *ShareDevPlugin) podShares(pod *v1.Pod, models []PodRequestedQuota) []FreeDeviceResources {
+	if pod.Labels[sharedevLabel] != clientLabelValue {
+		return nil
+	}
+	claim, err := sp.claimLister.SharedDeviceClaims(pod.Namespace).Get(pod.Labels[v1alpha1.SharedDeviceClaimLabel])
+	if err != nil {
+		return nil
+	}
+	podQ, deviceIds, err := BoundShares(pod, claim)
+	if err != nil || !hasModel(models, podQ.Vendor, podQ.Model) {
+		return nil
+	}
+
+	var shares []FreeDeviceResources
+	for i, share := range podQ.Shares {
+		if deviceIds[i] == "" {
+			continue
+		}
+		shares = append(shares, FreeDeviceResources{DeviceId: deviceIds[i], Requests: share.Requests, Memory: share.Memory})
+	}
+	return shares
+}
+
+// boundModel returns the quota of the model a bound pod runs on, the claim's
+// own model for pods bound before the model was recorded. It returns nil if
+// the claim doesn't accept the model anymore.
+func boundModel(pod *v1.Pod, models []PodRequestedQuota) *PodRequestedQuota {
+	name, ok := pod.Annotations[v1alpha1.SharedDeviceModelAnnotation]
+	if !ok {
+		return &models[0]
+	}
+	for i := range models {
+		if modelName(models[i].Vendor, models[i].Model) == name {
+			return &models[i]
+		}
+	}
+	return nil
+}
+
+// hasModel tells whether one of models is of vendor and model.
+func hasModel(models []PodRequestedQuota, vendor, model string) bool {
+	for _, q := range models {
+		if q.Vendor == vendor && q.Model == model {
+			return true
+		}
+	}
+	return false
+}
+
+// withReleased returns free plus the shares released on its devices.
+func withReleased(free, released []FreeDeviceResources) []FreeDeviceResources {
+	if len(released) == 0 {
+		return free
+	}
+	n := make([]FreeDeviceResources, len(free))
+	copy(n, free)
+	for _, r := range released {
+		for i := range n {
+			if n[i].DeviceId == r.DeviceId {
+				n[i].Requests += r.Requests
+				n[i].Memory += r.Memory
+				break
+			}
+		}
+	}
+	return n
+}
+
+// withNominated returns free less the shares the nominated pods take on the
+// devices of the model of podQ, placed the way Reserve would place them.
+// Nominated pods that don't fit take nothing.
+func withNominated(free []FreeDeviceResources, nominated [][]PodRequestedQuota, podQ PodRequestedQuota, scoreDevice deviceScorer) []FreeDeviceResources {
+	if len(nominated) == 0 {
+		return free
+	}
+	n := make([]FreeDeviceResources, len(free))
+	copy(n, free)
+	for _, models := range nominated {
+		for _, q := range models {
+			if q.Vendor != podQ.Vendor || q.Model != podQ.Model {
+				continue
+			}
+			_, deviceIds := assignShares(q, n, scoreDevice)
+			for i, deviceId := range deviceIds {
+				for j := range n {
+					if n[j].DeviceId == deviceId {
+						n[j].Requests -= q.Shares[i].Requests
+						n[j].Memory -= q.Shares[i].Memory
+						break
+					}
+				}
+			}
+			break
+		}
+	}
+	return n
+}
+
+type preemptor struct {
+	sp    *ShareDevPlugin
+	state *framework.CycleState
+}
+
+func (p *preemptor) GetOffsetAndNumCandidates(n int32) (int32, int32) {
+	return 0, n
+}
+
+func (p *preemptor) CandidatesToVictimsMap(candidates []preemption.Candidate) map[string]*extenderv1.Victims {
+	m := make(map[string]*extenderv1.Victims)
+	for _, c := range candidates {
+		m[c.Name()] = c.Victims()
+	}
+	return m
+}
+
+// PodEligibleToPreemptOthers tells whether the pod may preempt. It may not
+// while lower-priority clients it already preempted on its nominated node are
+// terminating: their shares are released once they are gone.
+func (p *preemptor) PodEligibleToPreemptOthers(pod *v1.Pod, nominatedNodeStatus *framework.Status) (bool, string) {
+	if pod.Spec.PreemptionPolicy != nil && *pod.Spec.PreemptionPolicy == v1.PreemptNever {
+		klog.V(5).InfoS("Pod is not eligible for preemption because of its preemptionPolicy", "pod", klog.KObj(pod), "preemptionPolicy", v1.PreemptNever)
+		return false, "not eligible due to preemptionPolicy=Never."
+	}
+
+	nomNodeName := pod.Status.NominatedNodeName
+	if len(nomNodeName) == 0 || nominatedNodeStatus.Code() == framework.UnschedulableAndUnresolvable {
+		return true, ""
+	}
+	nodeInfo, _ := p.sp.handle.SnapshotSharedLister().NodeInfos().Get(nomNodeName)
+	if nodeInfo == nil {
+		return true, ""
+	}
+	podPriority := corev1helpers.PodPriority(pod)
+	for _, pi := range nodeInfo.Pods {
+		if pi.Pod.DeletionTimestamp != nil && pi.Pod.Labels[sharedevLabel] == clientLabelValue && corev1helpers.PodPriority(pi.Pod) < podPriority {
+			return false, "not eligible due to a terminating pod on the nominated node."
+		}
+	}
+	return true, ""
+}
+
+// SelectVictimsOnNode picks the fewest lower-priority clients of the node's
+// devices to evict for the pod to fit. Like DefaultPreemption, it removes all
+// of them, then reprieves as many as possible, the ones whose PDB would be
+// violated first, and each group from the highest priority.
+func (p *preemptor) SelectVictimsOnNode(
+	ctx context.Context,
+	state *framework.CycleState,
+	pod *v1.Pod,
+	nodeInfo *framework.NodeInfo,
+	pdbs []*policy.PodDisruptionBudget) ([]*v1.Pod, int, *framework.Status) {
+	logger := klog.FromContext(ctx)
+	shareDevState, err := getShareDevState(state)
+	if err != nil {
+		return nil, 0, framework.AsStatus(err)
+	}
+	fh := p.sp.handle
+
+	removePod := func(rpi *framework.PodInfo) error {
+		if err := nodeInfo.RemovePod(rpi.Pod); err != nil {
+			return err
+		}
+		status := fh.RunPreFilterExtensionRemovePod(ctx, state, pod, rpi, nodeInfo)
+		if !status.IsSuccess() {
+			return status.AsError()
+		}
+		return nil
+	}
+	addPod := func(api *framework.PodInfo) error {
+		nodeInfo.AddPodInfo(api)
+		status := fh.RunPreFilterExtensionAddPod(ctx, state, pod, api, nodeInfo)
+		if !status.IsSuccess() {
+			return status.AsError()
+		}
+		return nil
+	}
+
+	// Only the clients of the devices the pod asks for make room for it.
+	podPriority := corev1helpers.PodPriority(pod)
+	var potentialVictims []*framework.PodInfo
+	for _, pi := range nodeInfo.Pods {
+		if pi.Pod.DeletionTimestamp != nil || corev1helpers.PodPriority(pi.Pod) >= podPriority ||
+			len(p.sp.podShares(pi.Pod, shareDevState.models())) == 0 {
+			continue
+		}
+		potentialVictims = append(potentialVictims, pi)
+	}
+	for _, pi := range potentialVictims {
+		if err := removePod(pi); err != nil {
+			return nil, 0, framework.AsStatus(err)
+		}
+	}
+
+	// No potential victims are found, and so we don't need to evaluate the node again since its state didn't change.
+	if len(potentialVictims) == 0 {
+		return nil, 0, framework.NewStatus(framework.UnschedulableAndUnresolvable, "no lower-priority clients of the shared devices on the node")
+	}
+
+	// The pod doesn't fit even once all the lower-priority clients are gone.
+	if s := fh.RunFilterPluginsWithNominatedPods(ctx, state, pod, nodeInfo); !s.IsSuccess() {
+		return nil, 0, s
+	}
+
+	var victims []*v1.Pod
+	numViolatingVictim := 0
+	sort.Slice(potentialVictims, func(i, j int) bool {
+		return schedutil.MoreImportantPod(potentialVictims[i].Pod, potentialVictims[j].Pod)
+	})
+	// Try to reprieve as many pods as possible. We first try to reprieve the PDB
+	// violating victims and then other non-violating ones. In both cases, we start
+	// from the highest priority victims.
+	violatingVictims, nonViolatingVictims := util.FilterPodsWithPDBViolation(potentialVictims, pdbs)
+	reprievePod := func(pi *framework.PodInfo) (bool, error) {
+		if err := addPod(pi); err != nil {
+			return false, err
+		}
+		s := fh.RunFilterPluginsWithNominatedPods(ctx, state, pod, nodeInfo)
+		fits := s.IsSuccess()
+		if !fits {
+			if err := removePod(pi); err != nil {
+				return false, err
+			}
+			victims = append(victims, pi.Pod)
+			logger.V(5).Info("Found a potential preemption victim on node", "pod", klog.KObj(pi.Pod), "node", klog.KObj(nodeInfo.Node()))
+		}
+		return fits, nil
+	}
+	for _, pi := range violatingVictims {
+		if fits, err := reprievePod(pi); err != nil {
+			return nil, 0, framework.AsStatus(err)
+		} else if !fits {
+			numViolatingVictim++
+		}
+	}
+	// Now we try to reprieve non-violating victims.
+	for _, pi := range nonViolatingVictims {
+		if _, err := reprievePod(pi); err != nil {
+			return nil, 0, framework.AsStatus(err)
+		}
+	}
+	return victims, numViolatingVictim, framework.NewStatus(framework.Success)
+}
@@ -0,0 +1,392 @@
+package sharedev
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/defaultbinder"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/queuesort"
+	"k8s.io/kubernetes/pkg/scheduler/framework/preemption"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+	st "k8s.io/kubernetes/pkg/scheduler/testing"
+
+	"sigs.k8s.io/scheduler-plugins/apis/config"
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	testutil "sigs.k8s.io/scheduler-plugins/test/util"
+)
+
+const (
+	lowPriority  int32 = 10
+	midPriority  int32 = 100
+	highPriority int32 = 1000
+)
+
+// makeVictimPod returns a client of deviceIds on node1 started at second
+// started, or a pending pod if deviceIds is empty.
+func makeVictimPod(name, claimName string, priority int32, deviceIds string, started int64) *v1.Pod {
+	pod := makeClaimPod(name, claimName)
+	pod.Spec.Priority = &priority
+	if deviceIds != "" {
+		pod.Labels[sharedevLabel] = clientLabelValue
+		pod.Annotations = map[string]string{v1alpha1.SharedDeviceIDAnnotation: deviceIds}
+		pod.Spec.NodeName = "node1"
+		startTime := metav1.Unix(1700000000+started, 0)
+		pod.Status.StartTime = &startTime
+	}
+	return pod
+}
+
+// newPreemptionFramework makes sp the PreFilter and Filter plugin of a
+// framework of a cluster with pods and node1, at 10.0.0.1.
+func newPreemptionFramework(t *testing.T, sp *ShareDevPlugin, pods []*v1.Pod, pdbs []*policy.PodDisruptionBudget) framework.Framework {
+	var objs []runtime.Object
+	var bound []*v1.Pod
+	podIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, p := range pods {
+		objs = append(objs, p)
+		podIndexer.Add(p)
+		if p.Spec.NodeName != "" {
+			bound = append(bound, p)
+		}
+	}
+	cs := clientsetfake.NewSimpleClientset(objs...)
+	informerFactory := informers.NewSharedInformerFactory(cs, 0)
+	pdbInformer := informerFactory.Policy().V1().PodDisruptionBudgets()
+	for _, pdb := range pdbs {
+		pdbInformer.Informer().GetStore().Add(pdb)
+	}
+
+	nodes := []*v1.Node{makeEndpointNode("node1", nil, v1.NodeAddress{Type: v1.NodeInternalIP, Address: "10.0.0.1"})}
+	registeredPlugins := []st.RegisterPluginFunc{
+		st.RegisterQueueSortPlugin(queuesort.Name, queuesort.New),
+		st.RegisterBindPlugin(defaultbinder.Name, defaultbinder.New),
+		st.RegisterPluginAsExtensions(Name, func(runtime.Object, framework.Handle) (framework.Plugin, error) {
+			return sp, nil
+		}, "PreFilter", "Filter"),
+	}
+	fwk, err := st.NewFramework(
+		registeredPlugins,
+		"default-scheduler",
+		make(chan struct{}),
+		frameworkruntime.WithClientSet(cs),
+		frameworkruntime.WithEventRecorder(&events.FakeRecorder{}),
+		frameworkruntime.WithPodNominator(testutil.NewPodNominator(nil)),
+		frameworkruntime.WithSnapshotSharedLister(testutil.NewFakeSharedLister(bound, nodes)),
+		frameworkruntime.WithInformerFactory(informerFactory),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp.handle = fwk
+	sp.podLister = corelisters.NewPodLister(podIndexer)
+	sp.pdbLister = pdbInformer.Lister()
+	return fwk
+}
+
+func TestSelectVictimsOnNode(t *testing.T) {
+	claims := []*v1alpha1.SharedDeviceClaim{
+		makeClaim("half", "500m", "500m", nil),
+		makeClaim("most", "750m", "750m", nil),
+		withCount(makeClaim("whole", "1", "1", nil), 3),
+	}
+	tests := []struct {
+		name     string
+		pod      *v1.Pod
+		reserved map[string][]*v1.Pod // deviceId -> clients
+		// nominated are the pods nominated to node1.
+		nominated []*v1.Pod
+		pdbs      []*policy.PodDisruptionBudget
+		// wantVictims are the victims by name, nil if the node is no candidate.
+		wantVictims   []string
+		wantViolating int64
+	}{
+		{
+			name: "fewest victims",
+			pod:  makeVictimPod("p", "most", highPriority, "", 0),
+			reserved: map[string][]*v1.Pod{
+				"dev1": {makeVictimPod("p1", "half", lowPriority, "dev1", 1), makeVictimPod("p2", "half", midPriority, "dev1", 2)},
+				"dev2": {makeVictimPod("p3", "half", lowPriority, "dev2", 3), makeVictimPod("p4", "half", highPriority, "dev2", 4)},
+			},
+			wantVictims: []string{"p1", "p2"},
+		},
+		{
+			name: "the oldest pod is reprieved first",
+			pod:  makeVictimPod("p", "half", highPriority, "", 0),
+			reserved: map[string][]*v1.Pod{
+				"dev1": {makeVictimPod("p1", "half", lowPriority, "dev1", 1), makeVictimPod("p2", "half", lowPriority, "dev1", 2)},
+				"dev2": {makeVictimPod("p3", "half", highPriority, "dev2", 3), makeVictimPod("p4", "half", highPriority, "dev2", 4)},
+			},
+			wantVictims: []string{"p2"},
+		},
+		{
+			name: "higher-priority nominated pods take their shares first",
+			pod:  makeVictimPod("p", "half", midPriority, "", 0),
+			reserved: map[string][]*v1.Pod{
+				"dev1": {makeVictimPod("p1", "half", lowPriority, "dev1", 1), makeVictimPod("p2", "half", lowPriority, "dev1", 2)},
+				"dev2": {makeVictimPod("p3", "half", highPriority, "dev2", 3), makeVictimPod("p4", "half", highPriority, "dev2", 4)},
+			},
+			nominated: []*v1.Pod{
+				makeVictimPod("n1", "half", highPriority, "", 0),
+				// Lower priority, the pod goes first.
+				makeVictimPod("n2", "half", lowPriority, "", 0),
+			},
+			wantVictims: []string{"p1", "p2"},
+		},
+		{
+			name: "pods whose PDB would be violated are reprieved first",
+			pod:  makeVictimPod("p", "half", highPriority, "", 0),
+			reserved: map[string][]*v1.Pod{
+				"dev1": {makeVictimPod("p1", "half", lowPriority, "dev1", 1), makeVictimPod("p2", "half", lowPriority, "dev1", 2)},
+				"dev2": {makeVictimPod("p3", "half", highPriority, "dev2", 3), makeVictimPod("p4", "half", highPriority, "dev2", 4)},
+			},
+			pdbs: []*policy.PodDisruptionBudget{{
+				ObjectMeta: metav1.ObjectMeta{Name: "pdb", Namespace: "ns"},
+				Spec:       policy.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "p2"}}},
+			}},
+			wantVictims: []string{"p1"},
+		},
+		{
+			name: "PDBs are violated when nothing else makes room",
+			pod:  makeVictimPod("p", "most", highPriority, "", 0),
+			reserved: map[string][]*v1.Pod{
+				"dev1": {makeVictimPod("p1", "half", lowPriority, "dev1", 1), makeVictimPod("p2", "half", lowPriority, "dev1", 2)},
+				"dev2": {makeVictimPod("p3", "half", highPriority, "dev2", 3), makeVictimPod("p4", "half", highPriority, "dev2", 4)},
+			},
+			pdbs: []*policy.PodDisruptionBudget{{
+				ObjectMeta: metav1.ObjectMeta{Name: "pdb", Namespace: "ns"},
+				Spec:       policy.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "p2"}}},
+			}},
+			wantVictims:   []string{"p1", "p2"},
+			wantViolating: 1,
+		},
+		{
+			name: "no lower-priority clients",
+			pod:  makeVictimPod("p", "half", midPriority, "", 0),
+			reserved: map[string][]*v1.Pod{
+				"dev1": {makeVictimPod("p1", "half", midPriority, "dev1", 1), makeVictimPod("p2", "half", highPriority, "dev1", 2)},
+				"dev2": {makeVictimPod("p3", "most", highPriority, "dev2", 3)},
+			},
+		},
+		{
+			name: "the node is too small",
+			pod:  makeVictimPod("p", "whole", highPriority, "", 0),
+			reserved: map[string][]*v1.Pod{
+				"dev1": {makeVictimPod("p1", "half", lowPriority, "dev1", 1)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fdm := testutil.NewFakeDeviceManager().
+				AddDevice("example.com", "mydev", "dev1").
+				AddDevice("example.com", "mydev", "dev2")
+			// Not a client of the devices.
+			pods := []*v1.Pod{st.MakePod().Name("bystander").Namespace("ns").UID("bystander-uid").Node("node1").Priority(lowPriority).Obj()}
+			for deviceId, clients := range tt.reserved {
+				for _, p := range clients {
+					p.Labels["app"] = p.Name
+					share := claimShare(t, claims, p.Labels[v1alpha1.SharedDeviceClaimLabel])
+					fdm.Reserve(deviceId, podClientId(p), share, share)
+					pods = append(pods, p)
+				}
+			}
+			sp, _ := newTestPlugin(t, map[string]*testutil.FakeDeviceManager{"10.0.0.1": fdm}, claims...)
+			sp.noFitPolicy = config.PreemptNoFit
+			fwk := newPreemptionFramework(t, sp, append(append(pods, tt.pod), tt.nominated...), tt.pdbs)
+			for _, p := range tt.nominated {
+				pi, err := framework.NewPodInfo(p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				fwk.AddNominatedPod(pi, &framework.NominatingInfo{NominatingMode: framework.ModeOverride, NominatedNodeName: "node1"})
+			}
+
+			ctx := context.Background()
+			state := framework.NewCycleState()
+			if _, s := fwk.RunPreFilterPlugins(ctx, state, tt.pod); !s.IsSuccess() {
+				t.Fatalf("unexpected PreFilter status: %v", s)
+			}
+			pe := preemption.Evaluator{
+				PluginName: Name,
+				Handler:    fwk,
+				PodLister:  sp.podLister,
+				PdbLister:  sp.pdbLister,
+				State:      state,
+				Interface:  &preemptor{sp: sp, state: state},
+			}
+			nodeInfos, _ := fwk.SnapshotSharedLister().NodeInfos().List()
+			got, _, err := pe.DryRunPreemption(ctx, tt.pod, nodeInfos, tt.pdbs, 0, int32(len(nodeInfos)))
+			if err != nil {
+				t.Fatalf("unexpected error during DryRunPreemption(): %v", err)
+			}
+
+			if tt.wantVictims == nil {
+				if len(got) != 0 {
+					t.Errorf("expected no candidates, got victims %v", got[0].Victims().Pods)
+				}
+				return
+			}
+			if len(got) != 1 {
+				t.Fatalf("expected node1 to be the only candidate, got %d candidates", len(got))
+			}
+			var victims []string
+			for _, p := range got[0].Victims().Pods {
+				victims = append(victims, p.Name)
+			}
+			sort.Strings(victims)
+			if diff := cmp.Diff(tt.wantVictims, victims); diff != "" {
+				t.Errorf("unexpected victims (-want,+got):\n%s", diff)
+			}
+			if got := got[0].Victims().NumPDBViolations; got != tt.wantViolating {
+				t.Errorf("expected %d PDB violations, got %d", tt.wantViolating, got)
+			}
+		})
+	}
+}
+
+// claimShare returns the compute share of the claim called name.
+func claimShare(t *testing.T, claims []*v1alpha1.SharedDeviceClaim, name string) float64 {
+	for _, c := range claims {
+		if c.Name == name {
+			return c.Spec.Compute.AsApproximateFloat64()
+		}
+	}
+	t.Fatalf("no claim %q", name)
+	return 0
+}
+
+func TestPostFilterPreempt(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      config.NoFitPolicy
+		priority    int32
+		wantCode    framework.Code
+		wantNode    string
+		wantDeleted bool
+		wantCreated int
+	}{
+		{
+			name:        "preempt",
+			policy:      config.PreemptNoFit,
+			priority:    highPriority,
+			wantCode:    framework.Success,
+			wantNode:    "node1",
+			wantDeleted: true,
+		},
+		{
+			name:     "nothing to preempt",
+			policy:   config.PreemptNoFit,
+			priority: lowPriority,
+			wantCode: framework.Unschedulable,
+		},
+		{
+			name:        "provision when nothing can be preempted",
+			policy:      config.PreemptOrProvisionNoFit,
+			priority:    lowPriority,
+			wantCode:    framework.Unschedulable,
+			wantCreated: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fdm := testutil.NewFakeDeviceManager().
+				AddDevice("example.com", "mydev", "dev1").
+				Reserve("dev1", "ns/p1/p1-uid", 0.75, 0.75)
+			sp, allocators := newTestPlugin(t, map[string]*testutil.FakeDeviceManager{"10.0.0.1": fdm},
+				makeClaim("half", "500m", "500m", nil), makeClaim("most", "750m", "750m", nil))
+			sp.noFitPolicy = tt.policy
+			victim := makeVictimPod("p1", "most", midPriority, "dev1", 1)
+			pod := makeVictimPod("p", "half", tt.priority, "", 0)
+			fwk := newPreemptionFramework(t, sp, []*v1.Pod{victim, pod}, nil)
+
+			ctx := context.Background()
+			state := framework.NewCycleState()
+			if _, s := fwk.RunPreFilterPlugins(ctx, state, pod); !s.IsSuccess() {
+				t.Fatalf("unexpected PreFilter status: %v", s)
+			}
+			nodeInfo, _ := fwk.SnapshotSharedLister().NodeInfos().Get("node1")
+			filterStatus := sp.Filter(ctx, state, pod, nodeInfo)
+			if filterStatus.Code() != framework.Unschedulable {
+				t.Fatalf("expected the pod not to fit, got %v", filterStatus)
+			}
+
+			result, gotStatus := sp.PostFilter(ctx, state, pod, framework.NodeToStatusMap{"node1": filterStatus})
+			if gotStatus.Code() != tt.wantCode {
+				t.Errorf("expected code %v, got %v", tt.wantCode, gotStatus)
+			}
+			if tt.wantNode != "" && (result == nil || result.NominatingInfo == nil || result.NominatedNodeName != tt.wantNode) {
+				t.Errorf("expected the pod to be nominated to %s, got %v", tt.wantNode, result)
+			}
+			_, err := fwk.ClientSet().CoreV1().Pods("ns").Get(ctx, "p1", metav1.GetOptions{})
+			if deleted := err != nil; deleted != tt.wantDeleted {
+				t.Errorf("expected the victim to be deleted: %v, got %v", tt.wantDeleted, err)
+			}
+			if tt.wantCreated > 0 {
+				allocators.wait(t, tt.wantCreated)
+			}
+			if got := len(allocators.createdNames()); got != tt.wantCreated {
+				t.Errorf("expected %d allocators to be created, got %d", tt.wantCreated, got)
+			}
+		})
+	}
+}
+
+func TestPodShares(t *testing.T) {
+	mydev := quota("p1", 0.5, 0.5)
+	small := quota("p1", 1, 0.5)
+	small.Model = "small"
+
+	tests := []struct {
+		name   string
+		model  string
+		models []PodRequestedQuota
+		want   []FreeDeviceResources
+	}{
+		{
+			name:   "pods bound before the model was recorded run on the claim's model",
+			models: []PodRequestedQuota{mydev, small},
+			want:   []FreeDeviceResources{{DeviceId: "dev1", Requests: 0.25, Memory: 0.25}},
+		},
+		{
+			name:   "shares on a fallback model are scaled",
+			model:  "example.com/small",
+			models: []PodRequestedQuota{mydev, small},
+			want:   []FreeDeviceResources{{DeviceId: "dev1", Requests: 0.5, Memory: 0.25}},
+		},
+		{
+			name:   "devices of a model the pod doesn't ask for",
+			model:  "example.com/small",
+			models: []PodRequestedQuota{mydev},
+		},
+		{
+			name:   "model the claim doesn't accept anymore",
+			model:  "example.com/gone",
+			models: []PodRequestedQuota{mydev, small},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp, _ := newTestPlugin(t, nil, withFallback(makeClaim("quarter", "250m", "250m", nil), "small", "2"))
+			victim := makeVictimPod("v1", "quarter", lowPriority, "dev1", 0)
+			if tt.model != "" {
+				victim.Annotations[v1alpha1.SharedDeviceModelAnnotation] = tt.model
+			}
+			if diff := cmp.Diff(tt.want, sp.podShares(victim, tt.models)); diff != "" {
+				t.Errorf("unexpected shares (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package sharedev
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+// deviceModel identifies a kind of device.
+type deviceModel struct {
+	vendor string
+	model  string
+}
+
+type provisioning struct {
+	// pending are the pods waiting for a device of the model, by PodId.
+	pending map[string]PodRequestedQuota
+	// allocators are the recent allocators, by Deployment name.
+	allocators map[string]*allocatorStatus
+}
+
+type allocatorStatus struct {
+	requested time.Time
+	// running is when the allocator pod was seen running, zero before.
+	running time.Time
+}
+
+// deviceProvisioner creates allocators in the background for the devices
+// unschedulable pods are waiting for. Pods waiting for the same vendor and
+// model share allocators: only as many devices as their requests add up to
+// are provisioned at a time.
+type deviceProvisioner struct {
+	createAllocator func(deployName, vendor, model string) error
+	// allocatorReady is called with the node a new device was claimed on.
+	allocatorReady func(nodeName string)
+	clock          clock.Clock
+	// timeout is how long an allocator is waited for before another one
+	// may be created in its place.
+	timeout time.Duration
+	// settle is how long a running allocator still counts as provisioning,
+	// so its device has time to show up in the inventory.
+	settle time.Duration
+
+	lock   sync.Mutex
+	models map[deviceModel]*provisioning
+	// allocatorModels is the model of every recent allocator, by Deployment name.
+	allocatorModels map[string]deviceModel
+}
+
+func newDeviceProvisioner(createAllocator func(deployName, vendor, model string) error, allocatorReady func(nodeName string), clock clock.Clock, timeout, settle time.Duration) *deviceProvisioner {
+	return &deviceProvisioner{
+		createAllocator: createAllocator,
+		allocatorReady:  allocatorReady,
+		clock:           clock,
+		timeout:         timeout,
+		settle:          settle,
+		models:          map[deviceModel]*provisioning{},
+		allocatorModels: map[string]deviceModel{},
+	}
+}
+
+// request records that pod is waiting for a new device and starts creating
+// allocators if the ones in flight can't cover all waiting pods. It returns
+// how many allocators are in flight for the pod's device model.
+func (p *deviceProvisioner) request(pod PodRequestedQuota) int {
+	key := deviceModel{vendor: pod.Vendor, model: pod.Model}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	prov, ok := p.models[key]
+	if !ok {
+		prov = &provisioning{
+			pending:    map[string]PodRequestedQuota{},
+			allocators: map[string]*allocatorStatus{},
+		}
+		p.models[key] = prov
+	}
+	prov.pending[pod.PodId] = pod
+
+	now := p.clock.Now()
+	for deployName, status := range prov.allocators {
+		switch {
+		case status.running.IsZero() && now.Sub(status.requested) > p.timeout:
+			klog.InfoS("Allocator is not running in time, giving up on it", "allocator", deployName, "timeout", p.timeout)
+		case !status.running.IsZero() && now.Sub(status.running) > p.settle:
+		default:
+			continue
+		}
+		delete(prov.allocators, deployName)
+		delete(p.allocatorModels, deployName)
+	}
+
+	for needed := devicesNeeded(prov.pending); len(prov.allocators) < needed; {
+		deployName := allocatorName(key.vendor, key.model)
+		prov.allocators[deployName] = &allocatorStatus{requested: now}
+		p.allocatorModels[deployName] = key
+		go p.create(deployName, key)
+	}
+	return len(prov.allocators)
+}
+
+func (p *deviceProvisioner) create(deployName string, key deviceModel) {
+	klog.V(3).InfoS("Creating allocator", "allocator", deployName, "vendor", key.vendor, "model", key.model)
+	if err := p.createAllocator(deployName, key.vendor, key.model); err != nil {
+		klog.ErrorS(err, "Failed to create allocator", "allocator", deployName, "vendor", key.vendor, "model", key.model)
+		allocatorProvisions.WithLabelValues(key.vendor, key.model, provisionFailed).Inc()
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		if prov, ok := p.models[key]; ok {
+			delete(prov.allocators, deployName)
+		}
+		delete(p.allocatorModels, deployName)
+		return
+	}
+	allocatorProvisions.WithLabelValues(key.vendor, key.model, provisionSucceeded).Inc()
+}
+
+// forget drops a pod that doesn't wait for a new device anymore, of any
+// model: it may have waited for one model and landed on another.
+func (p *deviceProvisioner) forget(pod PodRequestedQuota) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, prov := range p.models {
+		delete(prov.pending, pod.PodId)
+	}
+}
+
+// running is called when the pod of allocator deployName is running on nodeName.
+func (p *deviceProvisioner) running(deployName, nodeName string) {
+	p.lock.Lock()
+	key, ok := p.allocatorModels[deployName]
+	if !ok {
+		p.lock.Unlock()
+		return
+	}
+	prov := p.models[key]
+	status := prov.allocators[deployName]
+	if !status.running.IsZero() {
+		p.lock.Unlock()
+		return
+	}
+	status.running = p.clock.Now()
+	allocatorReadyDuration.WithLabelValues(key.vendor, key.model).Observe(status.running.Sub(status.requested).Seconds())
+	// The pod update requeues the waiting pods, they ask again if they still don't fit.
+	prov.pending = map[string]PodRequestedQuota{}
+	p.lock.Unlock()
+
+	klog.V(3).InfoS("Allocator is running", "allocator", deployName, "vendor", key.vendor, "model", key.model, "node", nodeName)
+	p.allocatorReady(nodeName)
+}
+
+// updatePod is the pod informer's add and update handler.
+func (p *deviceProvisioner) updatePod(obj interface{}) {
+	pod, ok := obj.(*v1.Pod)
+	if !ok || pod.Labels[sharedevLabel] != allocatorLabelValue {
+		return
+	}
+	if pod.Status.Phase == v1.PodRunning {
+		p.running(pod.Labels[allocatorNameLabel], pod.Spec.NodeName)
+	}
+}
+
+// cheapestModel returns the quota of the model whose new devices cost the
+// least for the pod: the cost of a device of the model times how much of the
+// devices the pod's shares take. Models without a cost come after the others,
+// in preference order.
+func (sp *ShareDevPlugin) cheapestModel(models []PodRequestedQuota) PodRequestedQuota {
+	best := -1
+	var bestCost float64
+	for i, q := range models {
+		cost, ok := sp.deviceModelCosts[deviceModel{vendor: q.Vendor, model: q.Model}]
+		if !ok {
+			continue
+		}
+		var size float64
+		for _, share := range q.Shares {
+			size += shareSize(share)
+		}
+		if c := float64(cost) * size; best == -1 || c < bestCost {
+			best, bestCost = i, c
+		}
+	}
+	if best == -1 {
+		return models[0]
+	}
+	return models[best]
+}
+
+// devicesNeeded returns how many whole devices the pods add up to, and at
+// least as many as a single pod needs shares on different devices.
+func devicesNeeded(pods map[string]PodRequestedQuota) int {
+	var requests, memory float64
+	distinct := 0
+	for _, pod := range pods {
+		perClient := map[string]int{}
+		for _, share := range pod.Shares {
+			requests += share.Requests
+			memory += share.Memory
+			perClient[share.ClientId]++
+			if perClient[share.ClientId] > distinct {
+				distinct = perClient[share.ClientId]
+			}
+		}
+	}
+	// Don't let rounding errors of the shares ask for an extra device.
+	needed := int(math.Ceil(math.Max(requests, memory) - 1e-9))
+	if distinct > needed {
+		return distinct
+	}
+	return needed
+}
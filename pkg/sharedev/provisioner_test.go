@@ -0,0 +1,198 @@
+package sharedev
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+// fakeAllocators records the allocators created and the nodes reported ready.
+type fakeAllocators struct {
+	mu      sync.Mutex
+	created []string
+	ready   []string
+	fail    bool
+	// done receives every finished creation.
+	done chan struct{}
+}
+
+func newFakeAllocators() *fakeAllocators {
+	return &fakeAllocators{done: make(chan struct{}, 100)}
+}
+
+func (f *fakeAllocators) create(deployName, vendor, model string) error {
+	defer func() { f.done <- struct{}{} }()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail {
+		return fmt.Errorf("forbidden")
+	}
+	f.created = append(f.created, deployName)
+	return nil
+}
+
+func (f *fakeAllocators) allocatorReady(nodeName string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ready = append(f.ready, nodeName)
+}
+
+func (f *fakeAllocators) wait(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-f.done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for allocator creation %d", i+1)
+		}
+	}
+}
+
+func (f *fakeAllocators) createdNames() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	names := append([]string{}, f.created...)
+	sort.Strings(names)
+	return names
+}
+
+func makeAllocatorPod(deployName, nodeName string, phase v1.PodPhase) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   deployName + "-abcde",
+			Labels: map[string]string{sharedevLabel: allocatorLabelValue, allocatorNameLabel: deployName},
+		},
+		Spec:   v1.PodSpec{NodeName: nodeName},
+		Status: v1.PodStatus{Phase: phase},
+	}
+}
+
+func quota(podId string, requests, memory float64) PodRequestedQuota {
+	return PodRequestedQuota{PodId: podId, Vendor: "example.com", Model: "mydev", Shares: []ShareQuota{
+		{ClientId: podId, Requests: requests, Memory: memory},
+	}}
+}
+
+func TestDeviceProvisionerCoalesces(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	f := newFakeAllocators()
+	p := newDeviceProvisioner(f.create, f.allocatorReady, fakeClock, time.Minute, 5*time.Second)
+
+	// Four quarter devices fit on one new device.
+	for i := 0; i < 4; i++ {
+		if got := p.request(quota(fmt.Sprintf("p%d", i), 0.25, 0.25)); got != 1 {
+			t.Errorf("expected 1 allocator in flight, got %d", got)
+		}
+	}
+	// Asking again doesn't count twice.
+	if got := p.request(quota("p0", 0.25, 0.25)); got != 1 {
+		t.Errorf("expected 1 allocator in flight, got %d", got)
+	}
+	// Memory may need more devices than compute.
+	if got := p.request(quota("p4", 0.1, 0.5)); got != 2 {
+		t.Errorf("expected 2 allocators in flight, got %d", got)
+	}
+	// Shares of one pod need different devices, however small.
+	multi := quota("p6", 0.1, 0.1)
+	multi.Shares = append(multi.Shares, multi.Shares[0], multi.Shares[0])
+	if got := p.request(multi); got != 3 {
+		t.Errorf("expected 3 allocators in flight, got %d", got)
+	}
+	// Other models are provisioned separately.
+	other := quota("p5", 0.25, 0.25)
+	other.Model = "otherdev"
+	if got := p.request(other); got != 1 {
+		t.Errorf("expected 1 allocator in flight, got %d", got)
+	}
+
+	f.wait(t, 4)
+	if got := len(f.createdNames()); got != 4 {
+		t.Errorf("expected 4 allocators to be created, got %d", got)
+	}
+}
+
+func TestDeviceProvisionerRunning(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	f := newFakeAllocators()
+	p := newDeviceProvisioner(f.create, f.allocatorReady, fakeClock, time.Minute, 5*time.Second)
+
+	p.request(quota("p1", 0.5, 0.5))
+	f.wait(t, 1)
+	deployName := f.createdNames()[0]
+
+	// Pending allocators and other pods are ignored.
+	p.updatePod(makeAllocatorPod(deployName, "node1", v1.PodPending))
+	p.updatePod(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "client", Labels: map[string]string{sharedevLabel: clientLabelValue}}})
+	if len(f.ready) != 0 {
+		t.Fatalf("expected no allocator to be ready, got %v", f.ready)
+	}
+
+	p.updatePod(makeAllocatorPod(deployName, "node1", v1.PodRunning))
+	p.updatePod(makeAllocatorPod(deployName, "node1", v1.PodRunning))
+	if len(f.ready) != 1 || f.ready[0] != "node1" {
+		t.Fatalf("expected node1 to be ready once, got %v", f.ready)
+	}
+
+	// While the new device shows up, pods retried too early don't start another allocator.
+	if got := p.request(quota("p1", 0.5, 0.5)); got != 1 {
+		t.Errorf("expected 1 allocator in flight, got %d", got)
+	}
+	// Once it settled, a pod that still doesn't fit gets a new device.
+	fakeClock.Step(6 * time.Second)
+	if got := p.request(quota("p1", 0.5, 0.5)); got != 1 {
+		t.Errorf("expected 1 allocator in flight, got %d", got)
+	}
+	f.wait(t, 1)
+	if got := len(f.createdNames()); got != 2 {
+		t.Errorf("expected 2 allocators to be created, got %d", got)
+	}
+
+	// Scheduled pods don't need a device anymore.
+	p.forget(quota("p1", 0.5, 0.5))
+	if got := len(p.models[deviceModel{vendor: "example.com", model: "mydev"}].pending); got != 0 {
+		t.Errorf("expected no pending pods, got %d", got)
+	}
+}
+
+func TestDeviceProvisionerRetries(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	f := newFakeAllocators()
+	p := newDeviceProvisioner(f.create, f.allocatorReady, fakeClock, time.Minute, 5*time.Second)
+
+	// A failed creation frees the slot for the next request.
+	f.mu.Lock()
+	f.fail = true
+	f.mu.Unlock()
+	p.request(quota("p1", 0.5, 0.5))
+	err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		return len(p.allocatorModels) == 0, nil
+	})
+	if err != nil {
+		t.Fatalf("expected the failed allocator to be forgotten: %v", err)
+	}
+	f.wait(t, 1)
+	f.mu.Lock()
+	f.fail = false
+	f.mu.Unlock()
+	if got := p.request(quota("p1", 0.5, 0.5)); got != 1 {
+		t.Errorf("expected 1 allocator in flight, got %d", got)
+	}
+	f.wait(t, 1)
+
+	// An allocator that doesn't start in time is replaced.
+	fakeClock.Step(2 * time.Minute)
+	p.request(quota("p1", 0.5, 0.5))
+	f.wait(t, 1)
+	if got := len(f.createdNames()); got != 2 {
+		t.Errorf("expected 2 allocators to be created, got %d", got)
+	}
+}
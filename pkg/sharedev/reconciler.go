@@ -0,0 +1,187 @@
+package sharedev
+
+import (
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	dmpb "sigs.k8s.io/scheduler-plugins/pkg/sharedev/devicemanagerpb"
+)
+
+// Reasons and action of the Events recorded by the reservation reconciler.
+const (
+	actionReconciling         = "Reconciling"
+	reasonOrphanedReservation = "OrphanedSharedDeviceReservation"
+	reasonMissingReservation  = "SharedDeviceReservationMissing"
+)
+
+type listReservationsFunc func(nodeName, endpoint string) ([]*dmpb.Reservation, error)
+
+type releaseReservationFunc func(nodeName, endpoint, deviceId, clientId string) error
+
+// reservationReconciler compares the reservations on the device managers with
+// the client pods using them. The scheduler doesn't remember its reservations
+// across restarts, so the ones of pods deleted while it was down, or whose
+// scheduling failed between Reserve and binding without Unreserve running,
+// are found here and released. Bound pods whose reservations are gone are
+// reported.
+type reservationReconciler struct {
+	nodeLister corelisters.NodeLister
+	podLister  corelisters.PodLister
+	endpoints  *endpointCache
+	list       listReservationsFunc
+	release    releaseReservationFunc
+	// waiting tells whether the pod of uid holds its reservations at Permit.
+	waiting       func(uid types.UID) bool
+	eventRecorder events.EventRecorder
+
+	// suspects are the orphaned reservations found by the previous pass, as
+	// "node/device/client". A reservation is only released once two passes
+	// in a row find it orphaned, so the ones Reserve made for pods that
+	// aren't labeled as clients yet are left alone.
+	suspects sets.String
+}
+
+// reconcile runs one pass over all nodes. It is not safe for concurrent use.
+func (r *reservationReconciler) reconcile() {
+	nodes, err := r.nodeLister.List(labels.Everything())
+	if err != nil {
+		klog.ErrorS(err, "Failed to list nodes to reconcile shared device reservations")
+		return
+	}
+	clients, err := r.podLister.List(labels.SelectorFromSet(labels.Set{sharedevLabel: clientLabelValue}))
+	if err != nil {
+		klog.ErrorS(err, "Failed to list shared device clients to reconcile reservations")
+		return
+	}
+	live := map[string]*v1.Pod{}
+	for _, pod := range clients {
+		if pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed {
+			live[podClientId(pod)] = pod
+		}
+	}
+
+	suspects := sets.NewString()
+	var orphaned, missing int
+	for _, node := range nodes {
+		endpoint, err := r.endpoints.get(node)
+		if err != nil {
+			klog.V(4).InfoS("Skipping shared device reservations of node without device manager", "node", node.Name, "err", err)
+			continue
+		}
+		reservations, err := r.list(node.Name, endpoint)
+		if status.Code(err) == codes.Unimplemented {
+			klog.V(4).InfoS("Device manager can't list its reservations", "node", node.Name, "endpoint", endpoint)
+			continue
+		}
+		if err != nil {
+			klog.ErrorS(err, "Failed to list shared device reservations", "node", node.Name, "endpoint", endpoint)
+			continue
+		}
+
+		reserved := sets.NewString()
+		for _, reservation := range reservations {
+			reserved.Insert(reservation.DeviceId + "/" + reservation.PodId)
+			podId, ok := splitClientId(reservation.PodId)
+			if !ok {
+				// Reserved under the pod name by an older scheduler; the
+				// device manager's garbage collection takes care of it.
+				continue
+			}
+			if _, ok := live[podId]; ok || r.waiting(podUID(podId)) {
+				continue
+			}
+			orphaned++
+			key := node.Name + "/" + reservation.DeviceId + "/" + reservation.PodId
+			if !r.suspects.Has(key) {
+				suspects.Insert(key)
+				continue
+			}
+			r.releaseOrphan(node, endpoint, reservation)
+		}
+
+		for podId, pod := range live {
+			if pod.Spec.NodeName != node.Name {
+				continue
+			}
+			for _, d := range reservedDevices(podId, pod) {
+				if !reserved.Has(d.deviceId + "/" + d.clientId) {
+					missing++
+					klog.InfoS("Shared device reservation missing on the device manager", "pod", klog.KObj(pod), "node", node.Name, "device", d.deviceId, "client", d.clientId)
+					r.eventRecorder.Eventf(pod, nil, v1.EventTypeWarning, reasonMissingReservation, actionReconciling,
+						"Device %s on node %s has no reservation for %s", d.deviceId, node.Name, d.clientId)
+				}
+			}
+		}
+	}
+	r.suspects = suspects
+	reservationDrift.WithLabelValues(driftOrphaned).Set(float64(orphaned))
+	reservationDrift.WithLabelValues(driftMissing).Set(float64(missing))
+}
+
+func (r *reservationReconciler) releaseOrphan(node *v1.Node, endpoint string, reservation *dmpb.Reservation) {
+	err := r.release(node.Name, endpoint, reservation.DeviceId, reservation.PodId)
+	if err != nil && status.Code(err) != codes.NotFound {
+		klog.ErrorS(err, "Failed to release orphaned shared device reservation", "node", node.Name, "device", reservation.DeviceId, "client", reservation.PodId)
+		orphanReleases.WithLabelValues(releaseFailed).Inc()
+		return
+	}
+	klog.InfoS("Released orphaned shared device reservation", "node", node.Name, "device", reservation.DeviceId, "client", reservation.PodId)
+	orphanReleases.WithLabelValues(releaseSucceeded).Inc()
+	r.eventRecorder.Eventf(node, nil, v1.EventTypeNormal, reasonOrphanedReservation, actionReconciling,
+		"Released the reservation of %s on device %s, its pod is gone", reservation.PodId, reservation.DeviceId)
+}
+
+// splitClientId returns the "<namespace>/<name>/<uid>" of the pod a client id
+// belongs to, dropping the container of a container share.
+func splitClientId(clientId string) (string, bool) {
+	if strings.Count(clientId, "/") != 2 {
+		return "", false
+	}
+	// Neither UIDs nor container names have dots, pod names may.
+	uidStart := strings.LastIndex(clientId, "/") + 1
+	if i := strings.Index(clientId[uidStart:], "."); i >= 0 {
+		return clientId[:uidStart+i], true
+	}
+	return clientId, true
+}
+
+func podUID(podId string) types.UID {
+	return types.UID(podId[strings.LastIndex(podId, "/")+1:])
+}
+
+// clientDevice is a device a client has a share of.
+type clientDevice struct {
+	clientId string
+	deviceId string
+}
+
+// reservedDevices returns the devices PreBind recorded on the pod.
+func reservedDevices(podId string, pod *v1.Pod) []clientDevice {
+	var devices []clientDevice
+	for key, value := range pod.Annotations {
+		if value == "" {
+			continue
+		}
+		if key == v1alpha1.SharedDeviceIDAnnotation {
+			// The shares of the pod are reserved under the same id, on different devices.
+			for _, deviceId := range strings.Split(value, ",") {
+				devices = append(devices, clientDevice{clientId: podId, deviceId: deviceId})
+			}
+			continue
+		}
+		if container := strings.TrimPrefix(key, v1alpha1.SharedDeviceContainerIDAnnotationPrefix); container != key {
+			devices = append(devices, clientDevice{clientId: podId + "." + container, deviceId: value})
+		}
+	}
+	return devices
+}
@@ -0,0 +1,169 @@
+package sharedev
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	metricstestutil "k8s.io/component-base/metrics/testutil"
+
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	testutil "sigs.k8s.io/scheduler-plugins/test/util"
+)
+
+// makeClientPod returns a pod bound to node1 with its shares recorded by PreBind.
+func makeClientPod(name, uid string, annotations map[string]string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "ns",
+			UID:         types.UID(uid),
+			Labels:      map[string]string{sharedevLabel: clientLabelValue},
+			Annotations: annotations,
+		},
+		Spec:   v1.PodSpec{NodeName: "node1"},
+		Status: v1.PodStatus{Phase: v1.PodRunning},
+	}
+}
+
+func TestSplitClientId(t *testing.T) {
+	for clientId, want := range map[string]string{
+		"ns/p1/p1-uid":          "ns/p1/p1-uid",
+		"ns/p1/p1-uid.sidecar":  "ns/p1/p1-uid",
+		"ns/p1.v2/p1-uid":       "ns/p1.v2/p1-uid",
+		"ns/p1.v2/p1-uid.main":  "ns/p1.v2/p1-uid",
+		"p1":                    "",
+		"p1.sidecar":            "",
+		"ns/p1/p1-uid/sidecar":  "",
+		"allocator-7d9f8-x2x4k": "",
+	} {
+		got, ok := splitClientId(clientId)
+		if got != want || ok != (want != "") {
+			t.Errorf("expected %q to belong to %q, got %q, %v", clientId, want, got, ok)
+		}
+	}
+}
+
+func TestReservationReconciler(t *testing.T) {
+	registerMetrics()
+	fdm := testutil.NewFakeDeviceManager().
+		AddDevice("example.com", "mydev", "dev1").
+		AddDevice("example.com", "mydev", "dev2").
+		Reserve("dev1", "ns/p1/p1-uid", 0.25, 0.25).
+		Reserve("dev2", "ns/p1/p1-uid.sidecar", 0.1, 0.1).
+		// Deleted while the scheduler was down.
+		Reserve("dev1", "ns/p2/p2-uid", 0.25, 0.25).
+		// Of the previous pod of the same name.
+		Reserve("dev2", "ns/p3/p3-old-uid", 0.25, 0.25).
+		// Waiting at Permit.
+		Reserve("dev1", "ns/p4/p4-uid", 0.1, 0.1).
+		// Of a pod that completed.
+		Reserve("dev2", "ns/p5/p5-uid", 0.25, 0.25).
+		// Reserved before PreBind labeled the pod.
+		Reserve("dev1", "ns/p6/p6-uid", 0.1, 0.1).
+		// Of an older scheduler.
+		Reserve("dev1", "legacy", 0.1, 0.1)
+	sp, _ := newTestPlugin(t, map[string]*testutil.FakeDeviceManager{"10.0.0.1": fdm})
+
+	nodes := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	nodes.Add(makeEndpointNode("node1", nil, v1.NodeAddress{Type: v1.NodeInternalIP, Address: "10.0.0.1"}))
+	// Without a device manager.
+	nodes.Add(makeEndpointNode("node2", nil))
+
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	pods.Add(makeClientPod("p1", "p1-uid", map[string]string{
+		v1alpha1.SharedDeviceIDAnnotation:                            "dev1",
+		v1alpha1.SharedDeviceContainerIDAnnotationPrefix + "sidecar": "dev2",
+	}))
+	pods.Add(makeClientPod("p3", "p3-new-uid", map[string]string{v1alpha1.SharedDeviceIDAnnotation: "dev2"}))
+	completed := makeClientPod("p5", "p5-uid", map[string]string{v1alpha1.SharedDeviceIDAnnotation: "dev2"})
+	completed.Status.Phase = v1.PodSucceeded
+	pods.Add(completed)
+	unlabeled := makeClientPod("p6", "p6-uid", nil)
+	unlabeled.Labels = nil
+	pods.Add(unlabeled)
+
+	r := &reservationReconciler{
+		nodeLister:    corelisters.NewNodeLister(nodes),
+		podLister:     corelisters.NewPodLister(pods),
+		endpoints:     sp.endpoints,
+		list:          sp.listReservations,
+		release:       sp.unreservePodQuota,
+		waiting:       func(uid types.UID) bool { return uid == "p4-uid" },
+		eventRecorder: sp.eventRecorder,
+		suspects:      sets.NewString(),
+	}
+	drift := func(kind string) float64 {
+		value, err := metricstestutil.GetGaugeMetricValue(reservationDrift.WithLabelValues(kind))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+	released := orphanReleases.WithLabelValues(releaseSucceeded)
+	releasedBefore, err := metricstestutil.GetCounterMetricValue(released)
+	if err != nil {
+		t.Fatal(err)
+	}
+	allReserved := reservations(fdm, "dev1", "dev2")
+
+	// The first pass only reports the orphans.
+	r.reconcile()
+	if diff := cmp.Diff(allReserved, reservations(fdm, "dev1", "dev2")); diff != "" {
+		t.Errorf("unexpected reservations after the first pass (-want,+got):\n%s", diff)
+	}
+	if got := drift(driftOrphaned); got != 4 {
+		t.Errorf("expected 4 orphaned reservations, got %v", got)
+	}
+	if got := drift(driftMissing); got != 1 {
+		t.Errorf("expected 1 missing reservation, got %v", got)
+	}
+	if diff := cmp.Diff([]string{"Warning SharedDeviceReservationMissing"}, recordedEvents(sp)); diff != "" {
+		t.Errorf("unexpected events (-want,+got):\n%s", diff)
+	}
+
+	// PreBind labels p6 before the next pass, which releases the other orphans.
+	unlabeled = unlabeled.DeepCopy()
+	unlabeled.Labels = map[string]string{sharedevLabel: clientLabelValue}
+	pods.Update(unlabeled)
+	r.reconcile()
+	want := map[string]bool{
+		"dev1/ns/p1/p1-uid":         true,
+		"dev2/ns/p1/p1-uid.sidecar": true,
+		"dev1/ns/p4/p4-uid":         true,
+		"dev1/ns/p6/p6-uid":         true,
+		"dev1/legacy":               true,
+	}
+	if diff := cmp.Diff(want, reservations(fdm, "dev1", "dev2")); diff != "" {
+		t.Errorf("unexpected reservations after the second pass (-want,+got):\n%s", diff)
+	}
+	if got := drift(driftOrphaned); got != 3 {
+		t.Errorf("expected 3 orphaned reservations, got %v", got)
+	}
+	if after, _ := metricstestutil.GetCounterMetricValue(released); after-releasedBefore != 3 {
+		t.Errorf("expected 3 released reservations, got %v", after-releasedBefore)
+	}
+	events := recordedEvents(sp)
+	sort.Strings(events)
+	wantEvents := []string{
+		"Normal OrphanedSharedDeviceReservation",
+		"Normal OrphanedSharedDeviceReservation",
+		"Normal OrphanedSharedDeviceReservation",
+		"Warning SharedDeviceReservationMissing",
+	}
+	if diff := cmp.Diff(wantEvents, events); diff != "" {
+		t.Errorf("unexpected events (-want,+got):\n%s", diff)
+	}
+
+	// Nothing is left to release.
+	r.reconcile()
+	if got := drift(driftOrphaned); got != 0 {
+		t.Errorf("expected no orphaned reservations, got %v", got)
+	}
+}
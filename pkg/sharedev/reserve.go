@@ -2,83 +2,121 @@ package sharedev
 
 import (
 	"context"
-	"log"
+	"time"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	v1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
 )
 
-func copyPod(pod *v1.Pod, hostIP, nodeName, deviceId string) *v1.Pod {
-	podCopy := pod.DeepCopy()
-	podCopy.ResourceVersion = ""
-	podCopy.Spec.NodeName = nodeName
-
-	// this label is used by Device Managers to query healthy pods
-	// and run garbage collection to free up devices
-	podCopy.Labels["sharedev"] = "client"
-
-	for i := range podCopy.Spec.Containers {
-		c := &podCopy.Spec.Containers[i]
-		c.Env = append(c.Env,
-			v1.EnvVar{
-				Name:  "CLIENT_ID",
-				Value: pod.Name,
-			},
-			v1.EnvVar{
-				Name:  "DEVICE_ID",
-				Value: deviceId,
-			},
-			v1.EnvVar{
-				Name:  "HOST_IP",
-				Value: hostIP,
-			},
-		)
-	}
-
-	return podCopy
+// unreserveBackoff is the backoff used when rolling back a reservation.
+var unreserveBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 100 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.1,
 }
 
+// Reserve reserves all shares of the pod on the device manager. It is all or
+// nothing: if one share can't be reserved, the ones already taken are released.
 func (sp *ShareDevPlugin) Reserve(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) *framework.Status {
-	log.Println("ShareDevPlugin Reserve is working!!")
+	logger := klog.FromContext(ctx)
 
 	shareDevState, err := getShareDevState(state)
 	if err != nil {
 		return framework.NewStatus(framework.Error, err.Error())
 	}
 
-	nodeIP := shareDevState.NodeNameToIP[nodeName]
-	_, device := getBestFit(shareDevState.PodQ, shareDevState.FreeDeviceResourcesPerNode[nodeName])
+	endpoint := shareDevState.endpointOf(nodeName)
+	// The pod runs on the model Filter found it fits on the node.
+	shareDevState.PodQ = shareDevState.quotaOn(nodeName)
+	// Pick the devices the same way Score rated the node.
+	_, deviceIds := assignShares(shareDevState.PodQ, shareDevState.freeOn(nodeName), sp.scoreDevice)
+	if deviceIds == nil {
+		return framework.NewStatus(framework.Unschedulable, "no device fits the pod")
+	}
 
-	log.Printf("Reserve State: %v", shareDevState)
-	log.Printf("ShareDevPlugin [Reserve] device %s pod: %s in node %s %s", device.DeviceId, pod.Name, nodeName, nodeIP)
-	err = reservePodQuota(nodeIP, device.DeviceId, shareDevState.PodQ)
-	if err != nil {
-		log.Printf("ShareDevPlugin Reserve: error reserving device: %s", err.Error())
-		return framework.NewStatus(framework.Error, err.Error())
+	logger.V(4).Info("Reserving shared devices", "pod", klog.KObj(pod), "node", nodeName, "endpoint", endpoint, "devices", deviceIds)
+	shareDevState.ReservedDeviceIds = make([]string, len(deviceIds))
+	for i, share := range shareDevState.PodQ.Shares {
+		expiresAt, err := sp.reservePodQuota(nodeName, endpoint, deviceIds[i], share)
+		if err != nil {
+			logger.Error(err, "Failed to reserve shared device", "pod", klog.KObj(pod), "node", nodeName, "device", deviceIds[i])
+			sp.eventRecorder.Eventf(pod, nil, v1.EventTypeWarning, reasonReservationFailed, actionScheduling,
+				"Failed to reserve device %s on node %s: %v", deviceIds[i], nodeName, err)
+			sp.unreserveShares(ctx, shareDevState, pod, nodeName)
+			return framework.NewStatus(framework.Error, err.Error())
+		}
+		shareDevState.ReservedDeviceIds[i] = deviceIds[i]
+		sp.leases.add(nodeName, endpoint, deviceIds[i], share.ClientId, shareDevState.PodQ.PodId, expiresAt)
 	}
+	sp.inventory.assume(nodeName, deviceIds, shareDevState.PodQ)
+	sp.provisioner.forget(shareDevState.PodQ)
 
-	podCopy := copyPod(pod, nodeIP, nodeName, device.DeviceId)
+	return framework.NewStatus(framework.Success)
+}
 
-	err = sp.handle.ClientSet().CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+// Unreserve releases the quota Reserve took on the device manager when a later
+// plugin fails. It is idempotent: the reservations are forgotten once released.
+// Coscheduling rejects the members of a PodGroup waiting at Permit when the
+// group can't be scheduled, which releases the shares of all of them here.
+// When the binding fails after PreBind, the devices PreBind recorded on the pod
+// and its claim are removed too.
+func (sp *ShareDevPlugin) Unreserve(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) {
+	logger := klog.FromContext(ctx)
+	shareDevState, err := getShareDevState(state)
 	if err != nil {
-		log.Printf("ShareDevPlugin Reserve: error deleting pod %s: %s", pod.Name, err.Error())
-		return framework.NewStatus(framework.Error, err.Error())
+		logger.Error(err, "Failed to release shared devices", "pod", klog.KObj(pod), "node", nodeName)
+		return
 	}
-	log.Printf("ShareDevPlugin [Reserve-> Delete] pod: %v/%v(%v) in node %v", pod.Namespace, pod.Name, pod.UID, pod.Spec.NodeName)
 
-	_, err = sp.handle.ClientSet().CoreV1().Pods(pod.Namespace).Create(ctx, podCopy, metav1.CreateOptions{})
-	if err != nil {
-		log.Printf("ShareDevPlugin Reserve: error creating pod %s: %s", podCopy.Name, err.Error())
-		return framework.NewStatus(framework.Error, err.Error())
+	if shareDevState.PreBound {
+		if err := sp.unbindPod(ctx, pod, shareDevState.PodQ, shareDevState.ReservedDeviceIds); err != nil {
+			logger.Error(err, "Failed to remove the shared devices recorded on the pod and its claim", "pod", klog.KObj(pod), "sharedDeviceClaim", klog.KRef(pod.Namespace, shareDevState.PodQ.ClaimName))
+		} else {
+			shareDevState.PreBound = false
+		}
 	}
 
-	shareDevState.ReservedDeviceId = device.DeviceId
-	log.Printf("ShareDevPlugin [Reserve] New Pod %v/%v(%v) v.s. Old Pod  %v/%v(%v)", podCopy.Namespace, podCopy.Name, podCopy.UID, pod.Namespace, pod.Name, pod.UID)
+	if sp.unreserveShares(ctx, shareDevState, pod, nodeName) {
+		sp.inventory.forget(shareDevState.PodQ.PodId)
+	}
+}
 
-	return framework.NewStatus(framework.Success)
+// unreserveShares releases the reserved shares of the pod and returns whether
+// they are all released. Shares that couldn't be released stay reserved.
+func (sp *ShareDevPlugin) unreserveShares(ctx context.Context, shareDevState *ShareDevState, pod *v1.Pod, nodeName string) bool {
+	endpoint := shareDevState.endpointOf(nodeName)
+	released := true
+	for i, deviceId := range shareDevState.ReservedDeviceIds {
+		if deviceId == "" {
+			continue
+		}
+		clientId := shareDevState.PodQ.Shares[i].ClientId
+		err := retry.OnError(unreserveBackoff, isRetriableUnreserveError, func() error {
+			return sp.unreservePodQuota(nodeName, endpoint, deviceId, clientId)
+		})
+		if err != nil && status.Code(err) != codes.NotFound {
+			klog.FromContext(ctx).Error(err, "Failed to release shared device", "pod", klog.KObj(pod), "node", nodeName, "device", deviceId)
+			released = false
+			continue
+		}
+		sp.leases.remove(deviceId, clientId)
+		shareDevState.ReservedDeviceIds[i] = ""
+	}
+	return released
 }
 
-func (sp *ShareDevPlugin) Unreserve(ctx context.Context, state *framework.CycleState, p *v1.Pod, nodeName string) {
-	log.Println("ShareDevPlugin Unreserve is working!!")
+// isRetriableUnreserveError tells whether an UnreservePodQuota call is worth retrying.
+func isRetriableUnreserveError(err error) bool {
+	switch status.Code(err) {
+	case codes.NotFound, codes.InvalidArgument, codes.Unimplemented:
+		return false
+	default:
+		return true
+	}
 }
@@ -0,0 +1,228 @@
+package sharedev
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	testutil "sigs.k8s.io/scheduler-plugins/test/util"
+)
+
+// fastUnreserveBackoff shortens unreserveBackoff for the duration of the test.
+func fastUnreserveBackoff(t *testing.T) {
+	old := unreserveBackoff
+	t.Cleanup(func() { unreserveBackoff = old })
+	unreserveBackoff = wait.Backoff{Steps: 5, Duration: time.Millisecond, Factor: 1}
+}
+
+func TestReserve(t *testing.T) {
+	fastUnreserveBackoff(t)
+	// Two halves of different devices and a sidecar slice.
+	podQ := PodRequestedQuota{PodId: "p1", Vendor: "example.com", Model: "mydev", Shares: []ShareQuota{
+		{ClientId: "p1", Requests: 0.5, Limits: 0.5, Memory: 0.5},
+		{ClientId: "p1", Requests: 0.5, Limits: 0.5, Memory: 0.5},
+		{ClientId: "p1.sidecar", Container: "sidecar", Requests: 0.1, Limits: 0.1, Memory: 0.1},
+	}}
+	free := []FreeDeviceResources{
+		{DeviceId: "dev1", Requests: 1, Memory: 1},
+		{DeviceId: "dev2", Requests: 0.5, Memory: 0.5},
+	}
+
+	tests := []struct {
+		name          string
+		free          []FreeDeviceResources
+		setup         func(fdm *testutil.FakeDeviceManager)
+		wantCode      framework.Code
+		wantReserved  map[string]bool
+		wantDeviceIds []string
+		wantEvents    []string
+	}{
+		{
+			name:          "all shares are reserved",
+			free:          free,
+			wantCode:      framework.Success,
+			wantReserved:  map[string]bool{"dev1/p1": true, "dev2/p1": true, "dev1/p1.sidecar": true, "dev2/other": true},
+			wantDeviceIds: []string{"dev2", "dev1", "dev1"},
+		},
+		{
+			name: "device manager without the extensions",
+			free: free,
+			setup: func(fdm *testutil.FakeDeviceManager) {
+				fdm.WithoutExtensions()
+			},
+			wantCode:      framework.Success,
+			wantReserved:  map[string]bool{"dev1/p1": true, "dev2/p1": true, "dev1/p1.sidecar": true, "dev2/other": true},
+			wantDeviceIds: []string{"dev2", "dev1", "dev1"},
+		},
+		{
+			name:         "shares of the pod need different devices",
+			free:         free[:1],
+			wantCode:     framework.Unschedulable,
+			wantReserved: map[string]bool{"dev2/other": true},
+		},
+		{
+			name: "quota taken since Filter releases the others",
+			free: free,
+			setup: func(fdm *testutil.FakeDeviceManager) {
+				fdm.Reserve("dev2", "late", 0.5, 0.5)
+			},
+			wantCode:      framework.Error,
+			wantReserved:  map[string]bool{"dev2/other": true, "dev2/late": true},
+			wantDeviceIds: []string{"", "", ""},
+			wantEvents:    []string{"Warning SharedDeviceReservationFailed"},
+		},
+		{
+			name: "device manager error",
+			free: free,
+			setup: func(fdm *testutil.FakeDeviceManager) {
+				fdm.SetError("ReservePodQuotaLease", status.Error(codes.Unavailable, "device manager unavailable"))
+			},
+			wantCode:      framework.Error,
+			wantReserved:  map[string]bool{"dev2/other": true},
+			wantDeviceIds: []string{"", "", ""},
+			wantEvents:    []string{"Warning SharedDeviceReservationFailed"},
+		},
+		{
+			name: "device manager timeout",
+			free: free,
+			setup: func(fdm *testutil.FakeDeviceManager) {
+				fdm.SetDelay("ReservePodQuotaLease", 5*testRPCTimeout)
+			},
+			wantCode:      framework.Error,
+			wantReserved:  map[string]bool{"dev2/other": true},
+			wantDeviceIds: []string{"", "", ""},
+			wantEvents:    []string{"Warning SharedDeviceReservationFailed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fdm := testutil.NewFakeDeviceManager().
+				AddDevice("example.com", "mydev", "dev1").
+				AddDevice("example.com", "mydev", "dev2").
+				Reserve("dev2", "other", 0.5, 0.5)
+			if tt.setup != nil {
+				tt.setup(fdm)
+			}
+			sp, _ := newTestPlugin(t, map[string]*testutil.FakeDeviceManager{"10.0.0.1": fdm})
+
+			s := &ShareDevState{
+				PodQ:                       podQ,
+				NodeNameToEndpoint:         map[string]string{"node1": "10.0.0.1:50051"},
+				FreeDeviceResourcesPerNode: map[string][]FreeDeviceResources{"node1": tt.free},
+			}
+			cycleState := framework.NewCycleState()
+			cycleState.Write(ShareDevStateKey, s)
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "ns"}}
+
+			if code := sp.Reserve(context.Background(), cycleState, pod, "node1").Code(); code != tt.wantCode {
+				t.Errorf("expected code %v, got %v", tt.wantCode, code)
+			}
+			if diff := cmp.Diff(tt.wantReserved, reservations(fdm, "dev1", "dev2")); diff != "" {
+				t.Errorf("unexpected reservations (-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantDeviceIds, s.ReservedDeviceIds); diff != "" {
+				t.Errorf("unexpected reserved devices (-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantEvents, recordedEvents(sp)); diff != "" {
+				t.Errorf("unexpected events (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnreserve(t *testing.T) {
+	fastUnreserveBackoff(t)
+
+	tests := []struct {
+		name         string
+		state        *ShareDevState
+		setup        func(fdm *testutil.FakeDeviceManager)
+		wantReserved map[string]bool
+		wantCalls    int
+	}{
+		{
+			name:         "nothing reserved",
+			state:        &ShareDevState{},
+			wantReserved: map[string]bool{},
+		},
+		{
+			name:  "reserved quota is released",
+			state: &ShareDevState{ReservedDeviceIds: []string{"dev1", "dev2"}},
+			setup: func(fdm *testutil.FakeDeviceManager) {
+				fdm.Reserve("dev1", "p1", 0.5, 0.5).Reserve("dev2", "p1", 0.5, 0.5)
+			},
+			wantReserved: map[string]bool{},
+			wantCalls:    2,
+		},
+		{
+			name:  "release is retried on transient errors",
+			state: &ShareDevState{ReservedDeviceIds: []string{"dev1", ""}},
+			setup: func(fdm *testutil.FakeDeviceManager) {
+				fdm.Reserve("dev1", "p1", 0.5, 0.5)
+				fdm.FailNext("UnreservePodQuota", 2, status.Error(codes.Unavailable, "device manager unavailable"))
+			},
+			wantReserved: map[string]bool{},
+			wantCalls:    3,
+		},
+		{
+			name:         "already released reservation is not retried",
+			state:        &ShareDevState{ReservedDeviceIds: []string{"dev1", ""}},
+			wantReserved: map[string]bool{},
+			wantCalls:    1,
+		},
+		{
+			name:  "release keeps timing out",
+			state: &ShareDevState{ReservedDeviceIds: []string{"dev1", ""}},
+			setup: func(fdm *testutil.FakeDeviceManager) {
+				fdm.Reserve("dev1", "p1", 0.5, 0.5)
+				fdm.SetDelay("UnreservePodQuota", 5*testRPCTimeout)
+			},
+			wantReserved: map[string]bool{"dev1/p1": true},
+			// Both Unreserve calls use up the backoff.
+			wantCalls: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			fdm := testutil.NewFakeDeviceManager().
+				AddDevice("example.com", "mydev", "dev1").
+				AddDevice("example.com", "mydev", "dev2")
+			if tt.setup != nil {
+				tt.setup(fdm)
+			}
+			sp, _ := newTestPlugin(t, map[string]*testutil.FakeDeviceManager{"10.0.0.1": fdm})
+
+			s := tt.state
+			s.PodQ = PodRequestedQuota{PodId: "p1", Shares: []ShareQuota{{ClientId: "p1"}, {ClientId: "p1"}}}
+			s.NodeNameToEndpoint = map[string]string{"node1": "10.0.0.1:50051"}
+			cycleState := framework.NewCycleState()
+			cycleState.Write(ShareDevStateKey, s)
+
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "ns"}}
+
+			sp.Unreserve(ctx, cycleState, pod, "node1")
+			// A second call must be a no-op once everything is released.
+			sp.Unreserve(ctx, cycleState, pod, "node1")
+
+			if got := fdm.Calls("UnreservePodQuota"); got != tt.wantCalls {
+				t.Errorf("expected %d UnreservePodQuota calls, got %d", tt.wantCalls, got)
+			}
+			if diff := cmp.Diff(tt.wantReserved, reservations(fdm, "dev1", "dev2")); diff != "" {
+				t.Errorf("unexpected reservations (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -1,95 +1,188 @@
 package sharedev
 
 import (
-	// "context"
-	// "log"
-	// "math"
-
-	// v1 "k8s.io/api/core/v1"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"sigs.k8s.io/scheduler-plugins/apis/config"
 )
 
-// func (sp *ShareDevPlugin) NormalizeScore(ctx context.Context, state *framework.CycleState, pod *v1.Pod, scores framework.NodeScoreList) *framework.Status {
-// 	log.Println("before normalization: ", "scores", scores)
-
-// 	// Get Min and Max Scores to normalize between framework.MaxNodeScore and framework.MinNodeScore
-// 	minCost, maxCost := getMinMaxScores(scores)
-
-// 	// If all nodes were given the minimum score, return
-// 	if minCost == 0 && maxCost == 0 {
-// 		return nil
-// 	}
-
-// 	var normCost float64
-// 	for i := range scores {
-// 		if maxCost != minCost { // If max != min
-// 			// node_normalized_cost = MAX_SCORE * ( ( nodeScore - minCost) / (maxCost - minCost)
-// 			// nodeScore = MAX_SCORE - node_normalized_cost
-// 			normCost = float64(framework.MaxNodeScore) * float64(scores[i].Score-minCost) / float64(maxCost-minCost)
-// 			scores[i].Score = framework.MaxNodeScore - int64(normCost)
-// 		} else { // If maxCost = minCost, avoid division by 0
-// 			normCost = float64(scores[i].Score - minCost)
-// 			scores[i].Score = framework.MaxNodeScore - int64(normCost)
-// 		}
-// 	}
-// 	log.Println("after normalization: ", "scores", scores)
-// 	return nil
-// }
-
-// // MinMax : get min and max scores from NodeScoreList
-// func getMinMaxScores(scores framework.NodeScoreList) (int64, int64) {
-// 	var max int64 = math.MinInt64 // Set to min value
-// 	var min int64 = math.MaxInt64 // Set to max value
-
-// 	for _, nodeScore := range scores {
-// 		if nodeScore.Score > max {
-// 			max = nodeScore.Score
-// 		}
-// 		if nodeScore.Score < min {
-// 			min = nodeScore.Score
-// 		}
-// 	}
-// 	// return min and max scores
-// 	return min, max
-// }
-
-// func (sp *ShareDevPlugin) ScoreExtensions() framework.ScoreExtensions {
-// 	return sp
-// }
-
-// func (sp *ShareDevPlugin) Score(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) (int64, *framework.Status) {
-// 	log.Println("ShareDevPlugin Score is working!!")
-
-// 	shareDevState, err := getShareDevState(state)
-// 	if err != nil {
-// 		return framework.MinNodeScore, framework.NewStatus(framework.Error, err.Error())
-// 	}
-
-// 	highestScore, device := getBestFit(shareDevState.PodQ, shareDevState.FreeDeviceResourcesPerNode[nodeName])
-
-// 	log.Printf("ShareDevPlugin Score: %d for node: %s, deviceId: %s", highestScore, nodeName, device.DeviceId)
-
-// 	return highestScore, framework.NewStatus(framework.Success)
-// }
-
-func getBestFit(pod PodRequestedQuota, freeResources []FreeDeviceResources) (int64, FreeDeviceResources) {
-	var device FreeDeviceResources
-	highestScore := framework.MinNodeScore
-
-	for _, free := range freeResources {
-		if podFits(pod, free) {
-			score := int64((free.Requests - pod.Requests + free.Memory - pod.Memory) * 100)
-
-			if score >= highestScore {
-				highestScore = score
-				device = free
-			}
+// Resource names usable in the scoring strategy of ShareDevPluginArgs.
+const (
+	computeResource = "compute"
+	memoryResource  = "memory"
+)
+
+// deviceScorer scores a device for a share, assuming the share fits on it.
+// Devices are never more than 1 compute and 1 memory.
+type deviceScorer func(share ShareQuota, free FreeDeviceResources) int64
+
+func newDeviceScorer(strategy config.ScoringStrategy) (deviceScorer, error) {
+	var computeWeight, memoryWeight float64
+	for _, r := range strategy.Resources {
+		switch r.Name {
+		case computeResource:
+			computeWeight = float64(r.Weight)
+		case memoryResource:
+			memoryWeight = float64(r.Weight)
+		default:
+			return nil, fmt.Errorf("unsupported scoring resource %q", r.Name)
+		}
+	}
+	weightSum := computeWeight + memoryWeight
+	if weightSum <= 0 {
+		return nil, fmt.Errorf("scoring resources must have a positive weight")
+	}
+
+	// allocated returns the share of compute and memory of the device in use
+	// once the share is on it.
+	allocated := func(share ShareQuota, free FreeDeviceResources) (float64, float64) {
+		compute := math.Min(math.Max(1-(free.Requests-share.Requests), 0), 1)
+		memory := math.Min(math.Max(1-(free.Memory-share.Memory), 0), 1)
+		return compute, memory
+	}
+
+	switch strategy.Type {
+	case config.MostAllocated:
+		// Packs devices, keeping whole devices free for large requests.
+		return func(share ShareQuota, free FreeDeviceResources) int64 {
+			compute, memory := allocated(share, free)
+			return int64((computeWeight*compute + memoryWeight*memory) / weightSum * float64(framework.MaxNodeScore))
+		}, nil
+	case config.LeastAllocated:
+		// Spreads pods, leaving every pod as much headroom as possible.
+		return func(share ShareQuota, free FreeDeviceResources) int64 {
+			compute, memory := allocated(share, free)
+			return int64((computeWeight*(1-compute) + memoryWeight*(1-memory)) / weightSum * float64(framework.MaxNodeScore))
+		}, nil
+	case config.BalancedAllocation:
+		// Favors devices whose compute and memory are used evenly, so neither
+		// runs out while the other is left unused. The score is one minus the
+		// weighted standard deviation of the two shares.
+		return func(share ShareQuota, free FreeDeviceResources) int64 {
+			compute, memory := allocated(share, free)
+			mean := (computeWeight*compute + memoryWeight*memory) / weightSum
+			variance := (computeWeight*(compute-mean)*(compute-mean) + memoryWeight*(memory-mean)*(memory-mean)) / weightSum
+			return int64((1 - math.Sqrt(variance)) * float64(framework.MaxNodeScore))
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported scoring strategy %q", strategy.Type)
+	}
+}
+
+func (sp *ShareDevPlugin) Score(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) (int64, *framework.Status) {
+	shareDevState, err := getShareDevState(state)
+	if err != nil {
+		return framework.MinNodeScore, framework.NewStatus(framework.Error, err.Error())
+	}
+
+	score, deviceIds := assignShares(shareDevState.quotaOn(nodeName), shareDevState.freeOn(nodeName), sp.scoreDevice)
+	// Nodes of a model earlier in the claim's preference order always score
+	// higher, the devices only rank nodes of the same model.
+	if n := int64(len(shareDevState.models())); n > 1 {
+		score = (score + (n-1-int64(shareDevState.modelOn(nodeName)))*framework.MaxNodeScore) / n
+	}
+	klog.FromContext(ctx).V(5).Info("Scored node", "pod", klog.KObj(pod), "node", nodeName, "score", score, "devices", deviceIds)
+
+	return score, framework.NewStatus(framework.Success)
+}
+
+func (sp *ShareDevPlugin) ScoreExtensions() framework.ScoreExtensions {
+	return sp
+}
+
+// NormalizeScore stretches the scores over the whole score range, so the
+// differences between devices still count when weighed against other plugins.
+func (sp *ShareDevPlugin) NormalizeScore(ctx context.Context, state *framework.CycleState, pod *v1.Pod, scores framework.NodeScoreList) *framework.Status {
+	minScore, maxScore := getMinMaxScores(scores)
+	if minScore == maxScore {
+		return nil
+	}
+
+	for i := range scores {
+		scores[i].Score = framework.MinNodeScore + (scores[i].Score-minScore)*(framework.MaxNodeScore-framework.MinNodeScore)/(maxScore-minScore)
+	}
+	return nil
+}
+
+// getMinMaxScores returns the min and max scores of a NodeScoreList.
+func getMinMaxScores(scores framework.NodeScoreList) (int64, int64) {
+	var max int64 = math.MinInt64
+	var min int64 = math.MaxInt64
+
+	for _, nodeScore := range scores {
+		if nodeScore.Score > max {
+			max = nodeScore.Score
+		}
+		if nodeScore.Score < min {
+			min = nodeScore.Score
 		}
 	}
+	return min, max
+}
 
-	if highestScore > framework.MaxNodeScore {
-		highestScore = framework.MaxNodeScore
+// assignShares picks a device for every share of the pod, by share index, and
+// returns them with their mean score. It returns nil if the shares don't all
+// fit at once. Shares are placed greedily, largest first, each on the highest
+// scoring device with room left; shares of the same client go to different
+// devices.
+func assignShares(pod PodRequestedQuota, freeResources []FreeDeviceResources, scoreDevice deviceScorer) (int64, []string) {
+	if len(pod.Shares) == 0 {
+		return framework.MinNodeScore, nil
 	}
 
-	return highestScore, device
+	left := make([]FreeDeviceResources, len(freeResources))
+	copy(left, freeResources)
+
+	order := make([]int, len(pod.Shares))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return shareSize(pod.Shares[order[i]]) > shareSize(pod.Shares[order[j]])
+	})
+
+	deviceIds := make([]string, len(pod.Shares))
+	// used are the devices taken by every client.
+	used := map[string]sets.String{}
+	var total int64
+	for _, i := range order {
+		share := pod.Shares[i]
+		best := -1
+		var bestScore int64
+		for j, free := range left {
+			if used[share.ClientId].Has(free.DeviceId) || !shareFits(share, free) {
+				continue
+			}
+			if score := scoreDevice(share, free); best == -1 || score > bestScore {
+				best, bestScore = j, score
+			}
+		}
+		if best == -1 {
+			return framework.MinNodeScore, nil
+		}
+
+		left[best].Requests -= share.Requests
+		left[best].Memory -= share.Memory
+		if used[share.ClientId] == nil {
+			used[share.ClientId] = sets.NewString()
+		}
+		used[share.ClientId].Insert(left[best].DeviceId)
+		deviceIds[i] = left[best].DeviceId
+		total += bestScore
+	}
+
+	return total / int64(len(pod.Shares)), deviceIds
+}
+
+// shareSize is how much of a device a share takes.
+func shareSize(share ShareQuota) float64 {
+	return math.Max(share.Requests, share.Memory)
 }
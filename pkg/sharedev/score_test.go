@@ -0,0 +1,240 @@
+package sharedev
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	schedconfig "k8s.io/kubernetes/pkg/scheduler/apis/config"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"sigs.k8s.io/scheduler-plugins/apis/config"
+)
+
+func TestAssignShares(t *testing.T) {
+	bothEqual := []schedconfig.ResourceSpec{{Name: "compute", Weight: 1}, {Name: "memory", Weight: 1}}
+	quarter := ShareQuota{ClientId: "p1", Requests: 0.25, Memory: 0.25}
+	half := ShareQuota{ClientId: "p1", Requests: 0.5, Memory: 0.5}
+	sidecar := ShareQuota{ClientId: "p1.sidecar", Container: "sidecar", Requests: 0.25, Memory: 0.25}
+	devices := []FreeDeviceResources{
+		// Too small for the pod.
+		{DeviceId: "full", Requests: 0.1, Memory: 0.1},
+		// Half used, compute and memory alike.
+		{DeviceId: "half", Requests: 0.5, Memory: 0.5},
+		// Unused.
+		{DeviceId: "empty", Requests: 1, Memory: 1},
+		// Compute mostly used, memory unused.
+		{DeviceId: "skewed", Requests: 0.25, Memory: 1},
+	}
+
+	tests := []struct {
+		name        string
+		strategy    config.ScoringStrategy
+		shares      []ShareQuota
+		devices     []FreeDeviceResources
+		wantDevices []string
+		wantScore   int64
+	}{
+		{
+			name:        "MostAllocated packs the fullest device",
+			strategy:    config.ScoringStrategy{Type: config.MostAllocated, Resources: bothEqual},
+			shares:      []ShareQuota{quarter},
+			devices:     devices,
+			wantDevices: []string{"half"},
+			wantScore:   75,
+		},
+		{
+			name:        "LeastAllocated spreads to the emptiest device",
+			strategy:    config.ScoringStrategy{Type: config.LeastAllocated, Resources: bothEqual},
+			shares:      []ShareQuota{quarter},
+			devices:     devices,
+			wantDevices: []string{"empty"},
+			wantScore:   75,
+		},
+		{
+			name:        "BalancedAllocation keeps compute and memory even",
+			strategy:    config.ScoringStrategy{Type: config.BalancedAllocation, Resources: bothEqual},
+			shares:      []ShareQuota{quarter},
+			devices:     devices[:len(devices)-1],
+			wantDevices: []string{"half"},
+			wantScore:   100,
+		},
+		{
+			name: "MostAllocated weighted towards compute",
+			strategy: config.ScoringStrategy{Type: config.MostAllocated, Resources: []schedconfig.ResourceSpec{
+				{Name: "compute", Weight: 3}, {Name: "memory", Weight: 1},
+			}},
+			shares:      []ShareQuota{quarter},
+			devices:     devices,
+			wantDevices: []string{"skewed"},
+			wantScore:   81,
+		},
+		{
+			name:        "MostAllocated on compute only",
+			strategy:    config.ScoringStrategy{Type: config.MostAllocated, Resources: []schedconfig.ResourceSpec{{Name: "compute", Weight: 1}}},
+			shares:      []ShareQuota{quarter},
+			devices:     devices,
+			wantDevices: []string{"skewed"},
+			wantScore:   100,
+		},
+		{
+			name:        "no device fits",
+			strategy:    config.ScoringStrategy{Type: config.MostAllocated, Resources: bothEqual},
+			shares:      []ShareQuota{quarter},
+			devices:     devices[:1],
+			wantDevices: nil,
+			wantScore:   framework.MinNodeScore,
+		},
+		{
+			name:        "shares of the pod go to different devices",
+			strategy:    config.ScoringStrategy{Type: config.MostAllocated, Resources: bothEqual},
+			shares:      []ShareQuota{half, half},
+			devices:     devices,
+			wantDevices: []string{"half", "empty"},
+			wantScore:   75,
+		},
+		{
+			name:        "a container share may join a share of the pod",
+			strategy:    config.ScoringStrategy{Type: config.MostAllocated, Resources: bothEqual},
+			shares:      []ShareQuota{sidecar, half},
+			devices:     devices[2:3],
+			wantDevices: []string{"empty", "empty"},
+			wantScore:   62,
+		},
+		{
+			name:        "shares that only fit one at a time",
+			strategy:    config.ScoringStrategy{Type: config.MostAllocated, Resources: bothEqual},
+			shares:      []ShareQuota{half, half},
+			devices:     devices[2:3],
+			wantDevices: nil,
+			wantScore:   framework.MinNodeScore,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scoreDevice, err := newDeviceScorer(tt.strategy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pod := PodRequestedQuota{PodId: "p1", Shares: tt.shares}
+			score, deviceIds := assignShares(pod, tt.devices, scoreDevice)
+			if diff := cmp.Diff(tt.wantDevices, deviceIds); diff != "" {
+				t.Errorf("unexpected devices (-want,+got):\n%s", diff)
+			}
+			if score != tt.wantScore {
+				t.Errorf("expected score %d, got %d", tt.wantScore, score)
+			}
+		})
+	}
+}
+
+func TestNewDeviceScorer(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy config.ScoringStrategy
+	}{
+		{
+			name:     "unsupported strategy",
+			strategy: config.ScoringStrategy{Type: config.LeastNUMANodes, Resources: []schedconfig.ResourceSpec{{Name: "compute", Weight: 1}}},
+		},
+		{
+			name:     "unsupported resource",
+			strategy: config.ScoringStrategy{Type: config.MostAllocated, Resources: []schedconfig.ResourceSpec{{Name: "cpu", Weight: 1}}},
+		},
+		{
+			name:     "no weight",
+			strategy: config.ScoringStrategy{Type: config.MostAllocated},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newDeviceScorer(tt.strategy); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	scoreDevice, err := newDeviceScorer(config.ScoringStrategy{
+		Type:      config.MostAllocated,
+		Resources: []schedconfig.ResourceSpec{{Name: "compute", Weight: 1}, {Name: "memory", Weight: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp := &ShareDevPlugin{scoreDevice: scoreDevice}
+
+	cycleState := framework.NewCycleState()
+	cycleState.Write(ShareDevStateKey, &ShareDevState{
+		PodQ: PodRequestedQuota{PodId: "p1", Shares: []ShareQuota{{ClientId: "p1", Requests: 0.5, Memory: 0.5}}},
+		FreeDeviceResourcesPerNode: map[string][]FreeDeviceResources{
+			"node1": {{DeviceId: "dev1", Requests: 1, Memory: 1}},
+			"node2": {{DeviceId: "dev2", Requests: 0.5, Memory: 0.5}},
+			"node3": {{DeviceId: "dev3", Requests: 0.75, Memory: 0.75}},
+		},
+	})
+
+	var scores framework.NodeScoreList
+	for _, nodeName := range []string{"node1", "node2", "node3"} {
+		score, status := sp.Score(context.Background(), cycleState, &v1.Pod{}, nodeName)
+		if !status.IsSuccess() {
+			t.Fatalf("unexpected status: %v", status)
+		}
+		scores = append(scores, framework.NodeScore{Name: nodeName, Score: score})
+	}
+	want := framework.NodeScoreList{{Name: "node1", Score: 50}, {Name: "node2", Score: 100}, {Name: "node3", Score: 75}}
+	if diff := cmp.Diff(want, scores); diff != "" {
+		t.Errorf("unexpected scores (-want,+got):\n%s", diff)
+	}
+
+	if status := sp.NormalizeScore(context.Background(), cycleState, &v1.Pod{}, scores); !status.IsSuccess() {
+		t.Fatalf("unexpected status: %v", status)
+	}
+	want = framework.NodeScoreList{{Name: "node1", Score: 0}, {Name: "node2", Score: 100}, {Name: "node3", Score: 50}}
+	if diff := cmp.Diff(want, scores); diff != "" {
+		t.Errorf("unexpected normalized scores (-want,+got):\n%s", diff)
+	}
+}
+
+func TestScoreFallbackModels(t *testing.T) {
+	scoreDevice, err := newDeviceScorer(config.ScoringStrategy{
+		Type:      config.MostAllocated,
+		Resources: []schedconfig.ResourceSpec{{Name: "compute", Weight: 1}, {Name: "memory", Weight: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp := &ShareDevPlugin{scoreDevice: scoreDevice}
+
+	podQ := PodRequestedQuota{PodId: "p1", Model: "mydev", Shares: []ShareQuota{{ClientId: "p1", Requests: 0.5, Memory: 0.5}}}
+	fallback := PodRequestedQuota{PodId: "p1", Model: "small", Shares: []ShareQuota{{ClientId: "p1", Requests: 1, Memory: 0.5}}}
+	cycleState := framework.NewCycleState()
+	cycleState.Write(ShareDevStateKey, &ShareDevState{
+		PodQ:       podQ,
+		Models:     []PodRequestedQuota{podQ, fallback},
+		NodeModels: map[string]int{"node2": 1},
+		FreeDeviceResourcesPerNode: map[string][]FreeDeviceResources{
+			// An empty device of the claim's model still beats a fallback device the pod fills.
+			"node1": {{DeviceId: "dev1", Requests: 1, Memory: 1}},
+			"node2": {{DeviceId: "dev2", Requests: 1, Memory: 0.5}},
+			"node3": {{DeviceId: "dev3", Requests: 0.5, Memory: 0.5}},
+		},
+	})
+
+	var scores framework.NodeScoreList
+	for _, nodeName := range []string{"node1", "node2", "node3"} {
+		score, status := sp.Score(context.Background(), cycleState, &v1.Pod{}, nodeName)
+		if !status.IsSuccess() {
+			t.Fatalf("unexpected status: %v", status)
+		}
+		scores = append(scores, framework.NodeScore{Name: nodeName, Score: score})
+	}
+	want := framework.NodeScoreList{{Name: "node1", Score: 75}, {Name: "node2", Score: 50}, {Name: "node3", Score: 100}}
+	if diff := cmp.Diff(want, scores); diff != "" {
+		t.Errorf("unexpected scores (-want,+got):\n%s", diff)
+	}
+}
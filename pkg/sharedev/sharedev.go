@@ -1,38 +1,299 @@
 package sharedev
 
 import (
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	policylisters "k8s.io/client-go/listers/policy/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/utils/clock"
+
+	"sigs.k8s.io/scheduler-plugins/apis/config"
+	"sigs.k8s.io/scheduler-plugins/apis/config/validation"
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling"
+	"sigs.k8s.io/scheduler-plugins/pkg/generated/clientset/versioned"
+	schedinformer "sigs.k8s.io/scheduler-plugins/pkg/generated/informers/externalversions"
+	listers "sigs.k8s.io/scheduler-plugins/pkg/generated/listers/scheduling/v1alpha1"
 )
 
 // Name is the name of the plugin used in the plugin registry and configurations.
 const (
-	Name              = "ShareDevPlugin"
-	deviceManagerPort = "50051"
+	Name = "ShareDevPlugin"
+)
+
+// Reasons and action of the Events recorded on pods and nodes.
+const (
+	actionScheduling         = "Scheduling"
+	reasonInvalidRequest     = "InvalidSharedDeviceRequest"
+	reasonNoFittingDevice    = "NoFittingSharedDevice"
+	reasonProvisioning       = "ProvisioningSharedDevice"
+	reasonReservationFailed  = "SharedDeviceReservationFailed"
+	reasonPodGroupDoesNotFit = "PodGroupSharedDevicesUnavailable"
+	reasonDeviceUnhealthy    = "SharedDeviceUnhealthy"
 )
 
 type ShareDevPlugin struct {
-	handle framework.Handle
+	handle      framework.Handle
+	claimClient versioned.Interface
+	claimLister listers.SharedDeviceClaimLister
+	// podGroupLister, podLister and nodeLister serve the PodGroup check of PreFilter.
+	podGroupLister listers.PodGroupLister
+	podLister      corelisters.PodLister
+	nodeLister     corelisters.NodeLister
+	// waiting tells whether the pod of uid holds its reservations at Permit.
+	waiting   func(uid types.UID) bool
+	pdbLister policylisters.PodDisruptionBudgetLister
+
+	endpoints      *endpointCache
+	deviceManagers deviceManagerClients
+	dmFailures     *deviceManagerFailures
+	inventory      *deviceInventory
+	scoreDevice    deviceScorer
+	provisioner    *deviceProvisioner
+	requests       *recentRequests
+	leases         *leaseKeeper
+	eventRecorder  events.EventRecorder
+
+	allocatorNamespace         string
+	allocatorImage             string
+	getAvailableDevicesTimeout time.Duration
+	reservePodQuotaTimeout     time.Duration
+	// leaseSeconds is the lease the quota is reserved under, 0 for none.
+	leaseSeconds int64
+	noFitPolicy  config.NoFitPolicy
+	// deviceModelCosts is the cost of a whole device of each model, for
+	// provisioning the cheapest model a claim accepts.
+	deviceModelCosts map[deviceModel]int64
 }
 
 var _ framework.PreFilterPlugin = &ShareDevPlugin{}
+var _ framework.PreFilterExtensions = &ShareDevPlugin{}
 var _ framework.FilterPlugin = &ShareDevPlugin{}
 var _ framework.PostFilterPlugin = &ShareDevPlugin{}
-
-// var _ framework.ScorePlugin = &ShareDevPlugin{}
+var _ framework.ScorePlugin = &ShareDevPlugin{}
 var _ framework.ReservePlugin = &ShareDevPlugin{}
+var _ framework.PreBindPlugin = &ShareDevPlugin{}
+var _ framework.EnqueueExtensions = &ShareDevPlugin{}
 
 // Name returns name of the plugin.
 func (sp *ShareDevPlugin) Name() string {
 	return Name
 }
 
-func podFits(pod PodRequestedQuota, freeResources FreeDeviceResources) bool {
-	return pod.Requests <= freeResources.Requests && pod.Memory <= freeResources.Memory
+// EventsToRegister returns the events that may make a pod rejected by the plugin schedulable.
+func (sp *ShareDevPlugin) EventsToRegister() []framework.ClusterEvent {
+	// To register a custom event, follow the naming convention at:
+	// https://git.k8s.io/kubernetes/pkg/scheduler/eventhandlers.go#L403-L410
+	claimGVK := fmt.Sprintf("shareddeviceclaims.v1alpha1.%v", scheduling.GroupName)
+	pgGVK := fmt.Sprintf("podgroups.v1alpha1.%v", scheduling.GroupName)
+	return []framework.ClusterEvent{
+		// An allocator pod started running with a new device, or a pod
+		// using a shared device left.
+		{Resource: framework.Pod, ActionType: framework.Update | framework.Delete},
+		{Resource: framework.Node, ActionType: framework.Add},
+		{Resource: framework.GVK(claimGVK), ActionType: framework.Add | framework.Update},
+		// A PodGroup may need fewer members.
+		{Resource: framework.GVK(pgGVK), ActionType: framework.Add | framework.Update},
+	}
+}
+
+func shareFits(share ShareQuota, freeResources FreeDeviceResources) bool {
+	return share.Requests <= freeResources.Requests && share.Memory <= freeResources.Memory
+}
+
+func getArgs(obj runtime.Object) (*config.ShareDevPluginArgs, error) {
+	args, ok := obj.(*config.ShareDevPluginArgs)
+	if !ok {
+		return nil, fmt.Errorf("want args to be of type ShareDevPluginArgs, got %T", obj)
+	}
+	return args, nil
+}
+
+func New(obj runtime.Object, handle framework.Handle) (framework.Plugin, error) {
+	args, err := getArgs(obj)
+	if err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateShareDevPluginArgs(nil, args); err != nil {
+		return nil, err
+	}
+
+	scoreDevice, err := newDeviceScorer(args.ScoringStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := versioned.NewForConfig(handle.KubeConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	schedSharedInformerFactory := schedinformer.NewSharedInformerFactory(client, 0)
+	claimInformer := schedSharedInformerFactory.Scheduling().V1alpha1().SharedDeviceClaims()
+	claimLister := claimInformer.Lister()
+	pgInformer := schedSharedInformerFactory.Scheduling().V1alpha1().PodGroups()
+
+	schedSharedInformerFactory.Start(nil)
+	if !cache.WaitForCacheSync(nil, claimInformer.Informer().HasSynced, pgInformer.Informer().HasSynced) {
+		return nil, fmt.Errorf("timed out waiting for caches to sync %v", Name)
+	}
+
+	podInformer := handle.SharedInformerFactory().Core().V1().Pods()
+	resolver, err := newEndpointResolver(args.DeviceManagerEndpoint, args.DeviceManagerPort, podInformer.Lister())
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := newTransportCredentials(args.DeviceManagerTLS)
+	if err != nil {
+		return nil, err
+	}
+
+	sp := &ShareDevPlugin{
+		handle:                     handle,
+		claimClient:                client,
+		claimLister:                claimLister,
+		podGroupLister:             pgInformer.Lister(),
+		podLister:                  podInformer.Lister(),
+		scoreDevice:                scoreDevice,
+		eventRecorder:              handle.EventRecorder(),
+		endpoints:                  newEndpointCache(resolver),
+		deviceManagers:             newDeviceManagerPool(grpc.WithTransportCredentials(creds)),
+		allocatorNamespace:         args.AllocatorNamespace,
+		allocatorImage:             args.AllocatorImage,
+		getAvailableDevicesTimeout: time.Duration(args.GetAvailableDevicesTimeoutSeconds) * time.Second,
+		reservePodQuotaTimeout:     time.Duration(args.ReservePodQuotaTimeoutSeconds) * time.Second,
+		leaseSeconds:               args.ReservationLeaseSeconds,
+		noFitPolicy:                args.NoFitPolicy,
+		deviceModelCosts:           map[deviceModel]int64{},
+	}
+	for _, c := range args.DeviceModelCosts {
+		sp.deviceModelCosts[deviceModel{vendor: c.Vendor, model: c.Model}] = c.Cost
+	}
+	if sp.noFitPolicy != config.ProvisionNoFit {
+		sp.pdbLister = handle.SharedInformerFactory().Policy().V1().PodDisruptionBudgets().Lister()
+	}
+
+	resyncPeriod := time.Duration(args.DeviceInventoryResyncPeriodSeconds) * time.Second
+	sp.dmFailures = newDeviceManagerFailures(clock.RealClock{}, time.Duration(args.DeviceManagerUnreachableSeconds)*time.Second, resyncPeriod)
+	sp.inventory = newDeviceInventory(sp.getFreeResources, sp.deviceUnhealthy, clock.RealClock{}, resyncPeriod)
+	sp.requests = newRecentRequests()
+	registerMetrics()
+	go wait.Forever(func() {
+		sp.inventory.resync()
+		sp.recordFleetMetrics()
+	}, resyncPeriod)
+
+	allocationTimeout := time.Duration(args.AllocationTimeoutSeconds) * time.Second
+	sp.provisioner = newDeviceProvisioner(sp.createAllocator, sp.inventory.invalidate, clock.RealClock{}, allocationTimeout, resyncPeriod)
+	podInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: sp.provisioner.updatePod,
+		UpdateFunc: func(_, newObj interface{}) {
+			sp.provisioner.updatePod(newObj)
+		},
+		DeleteFunc: sp.deletePod,
+	})
+	podInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: sp.endpoints.updatePod,
+		UpdateFunc: func(_, newObj interface{}) {
+			sp.endpoints.updatePod(newObj)
+		},
+		DeleteFunc: sp.endpoints.updatePod,
+	})
+
+	nodeInformer := handle.SharedInformerFactory().Core().V1().Nodes()
+	nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: sp.endpoints.updateNode,
+		DeleteFunc: sp.deleteNode,
+	})
+
+	sp.nodeLister = nodeInformer.Lister()
+	sp.waiting = func(uid types.UID) bool {
+		return handle.GetWaitingPod(uid) != nil
+	}
+	reconciler := &reservationReconciler{
+		nodeLister:    nodeInformer.Lister(),
+		podLister:     podInformer.Lister(),
+		endpoints:     sp.endpoints,
+		list:          sp.listReservations,
+		release:       sp.unreservePodQuota,
+		waiting:       sp.waiting,
+		eventRecorder: sp.eventRecorder,
+		suspects:      sets.NewString(),
+	}
+	sp.leases = newLeaseKeeper(sp.renewPodQuota, clock.RealClock{}, podInformer.Lister(), nodeInformer.Lister(), sp.endpoints, sp.waiting)
+	go func() {
+		// The shared informers are only started once all plugins are built.
+		if !cache.WaitForCacheSync(nil, podInformer.Informer().HasSynced, nodeInformer.Informer().HasSynced) {
+			return
+		}
+		if sp.leaseSeconds > 0 {
+			// Renew well before the leases expire, so a failed renewal is retried in time.
+			go wait.Forever(sp.leases.renewAll, time.Duration(sp.leaseSeconds)*time.Second/3)
+		}
+		wait.Forever(reconciler.reconcile, time.Duration(args.ReservationReconcilePeriodSeconds)*time.Second)
+	}()
+
+	return sp, nil
+}
+
+// deleteNode is the node informer's delete handler.
+func (sp *ShareDevPlugin) deleteNode(obj interface{}) {
+	var node *v1.Node
+	switch t := obj.(type) {
+	case *v1.Node:
+		node = t
+	case cache.DeletedFinalStateUnknown:
+		var ok bool
+		if node, ok = t.Obj.(*v1.Node); !ok {
+			klog.ErrorS(nil, "Cannot convert to *v1.Node", "obj", t.Obj)
+			return
+		}
+	default:
+		klog.ErrorS(nil, "Cannot convert to *v1.Node", "obj", t)
+		return
+	}
+	sp.endpoints.invalidate(node.Name)
+	sp.deviceManagers.remove(node.Name)
+	sp.dmFailures.remove(node.Name)
+	sp.inventory.removeNode(node.Name)
+}
+
+// deviceUnhealthy records an Event on the node of a device that became unhealthy.
+func (sp *ShareDevPlugin) deviceUnhealthy(nodeName string, model deviceModel, device FreeDeviceResources) {
+	klog.InfoS("Shared device is unhealthy", "node", nodeName, "device", device.DeviceId, "vendor", model.vendor, "model", model.model, "reason", device.HealthMessage)
+	node := &v1.ObjectReference{Kind: "Node", Name: nodeName, UID: types.UID(nodeName)}
+	sp.eventRecorder.Eventf(node, nil, v1.EventTypeWarning, reasonDeviceUnhealthy, actionScheduling,
+		"Shared device %s (%s) is unhealthy: %s", device.DeviceId, modelName(model.vendor, model.model), device.HealthMessage)
 }
 
-func New(_ runtime.Object, handle framework.Handle) (framework.Plugin, error) {
-	return &ShareDevPlugin{
-		handle: handle,
-	}, nil
+// deletePod is the pod informer's delete handler. The free devices of the node
+// of a client are fetched again, so the shares of preempted clients are seen
+// as free as soon as the device manager releases them.
+func (sp *ShareDevPlugin) deletePod(obj interface{}) {
+	var pod *v1.Pod
+	switch t := obj.(type) {
+	case *v1.Pod:
+		pod = t
+	case cache.DeletedFinalStateUnknown:
+		var ok bool
+		if pod, ok = t.Obj.(*v1.Pod); !ok {
+			return
+		}
+	default:
+		return
+	}
+	if pod.Labels[sharedevLabel] == clientLabelValue && pod.Spec.NodeName != "" {
+		sp.inventory.invalidate(pod.Spec.NodeName)
+	}
 }
@@ -0,0 +1,110 @@
+package sharedev
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"k8s.io/apimachinery/pkg/types"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/events"
+	schedconfig "k8s.io/kubernetes/pkg/scheduler/apis/config"
+	"k8s.io/utils/clock"
+
+	"sigs.k8s.io/scheduler-plugins/apis/config"
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	fakeclientset "sigs.k8s.io/scheduler-plugins/pkg/generated/clientset/versioned/fake"
+	schedinformer "sigs.k8s.io/scheduler-plugins/pkg/generated/informers/externalversions"
+	pb "sigs.k8s.io/scheduler-plugins/pkg/sharedev/devicemanagerpb"
+	testutil "sigs.k8s.io/scheduler-plugins/test/util"
+)
+
+// testRPCTimeout is the device manager call timeout of the test plugin.
+const testRPCTimeout = 200 * time.Millisecond
+
+// unreachableDeviceManagers stands for device managers that can't be dialed.
+type unreachableDeviceManagers struct{}
+
+func (unreachableDeviceManagers) get(nodeName, endpoint string) (pb.DeviceManagerClient, error) {
+	return nil, fmt.Errorf("did not connect to %s", endpoint)
+}
+
+func (unreachableDeviceManagers) healthy(nodeName string) bool { return false }
+
+func (unreachableDeviceManagers) remove(nodeName string) {}
+
+// newTestPlugin returns a plugin talking to the fake device managers, by node
+// IP, over in-memory connections, and knowing about the claims.
+func newTestPlugin(t *testing.T, fakes map[string]*testutil.FakeDeviceManager, claims ...*v1alpha1.SharedDeviceClaim) (*ShareDevPlugin, *fakeAllocators) {
+	deviceManagers := newDeviceManagerPool(
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		testutil.ServeFakeDeviceManagers(t, fakes))
+	t.Cleanup(deviceManagers.close)
+
+	scoreDevice, err := newDeviceScorer(config.ScoringStrategy{
+		Type:      config.MostAllocated,
+		Resources: []schedconfig.ResourceSpec{{Name: "compute", Weight: 1}, {Name: "memory", Weight: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cs := fakeclientset.NewSimpleClientset()
+	schedInformerFactory := schedinformer.NewSharedInformerFactory(cs, 0)
+	claimInformer := schedInformerFactory.Scheduling().V1alpha1().SharedDeviceClaims()
+	for _, c := range claims {
+		claimInformer.Informer().GetStore().Add(c)
+	}
+
+	allocators := newFakeAllocators()
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	sp := &ShareDevPlugin{
+		claimClient:                cs,
+		claimLister:                claimInformer.Lister(),
+		podGroupLister:             schedInformerFactory.Scheduling().V1alpha1().PodGroups().Lister(),
+		podLister:                  corelisters.NewPodLister(pods),
+		nodeLister:                 corelisters.NewNodeLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})),
+		waiting:                    func(types.UID) bool { return false },
+		endpoints:                  newEndpointCache(&nodeAddressResolver{port: 50051}),
+		deviceManagers:             deviceManagers,
+		scoreDevice:                scoreDevice,
+		requests:                   newRecentRequests(),
+		eventRecorder:              events.NewFakeRecorder(100),
+		getAvailableDevicesTimeout: testRPCTimeout,
+		reservePodQuotaTimeout:     testRPCTimeout,
+		noFitPolicy:                config.ProvisionNoFit,
+	}
+	sp.dmFailures = newDeviceManagerFailures(clock.RealClock{}, time.Minute, time.Second)
+	sp.inventory = newDeviceInventory(sp.getFreeResources, sp.deviceUnhealthy, clock.RealClock{}, time.Second)
+	sp.provisioner = newDeviceProvisioner(allocators.create, allocators.allocatorReady, clock.RealClock{}, time.Minute, time.Second)
+	sp.leases = newLeaseKeeper(sp.renewPodQuota, clock.RealClock{}, sp.podLister, sp.nodeLister, sp.endpoints, sp.waiting)
+	return sp, allocators
+}
+
+// reservations returns the quota reserved on the devices of fdm as "deviceId/podId".
+func reservations(fdm *testutil.FakeDeviceManager, deviceIds ...string) map[string]bool {
+	reserved := map[string]bool{}
+	for _, deviceId := range deviceIds {
+		for podId := range fdm.Reservations(deviceId) {
+			reserved[deviceId+"/"+podId] = true
+		}
+	}
+	return reserved
+}
+
+// recordedEvents returns the "type reason" of the events recorded since the last call.
+func recordedEvents(sp *ShareDevPlugin) []string {
+	var recorded []string
+	for {
+		select {
+		case e := <-sp.eventRecorder.(*events.FakeRecorder).Events:
+			recorded = append(recorded, strings.Join(strings.SplitN(e, " ", 3)[:2], " "))
+		default:
+			return recorded
+		}
+	}
+}
@@ -2,6 +2,7 @@ package sharedev
 
 import (
 	"fmt"
+	"sync"
 
 	"k8s.io/kubernetes/pkg/scheduler/framework"
 )
@@ -10,34 +11,93 @@ const (
 	ShareDevStateKey = "ShareDevStateKey"
 )
 
+// ShareQuota is the share of one device.
+type ShareQuota struct {
+	// ClientId is the id the share is reserved under on the device manager:
+	// "<namespace>/<name>/<uid>" of the pod, followed by ".<container>" for
+	// the share of a single container.
+	ClientId string
+	// Container is the only container using the share, empty if the whole pod uses it.
+	Container string
+	Requests  float64
+	Limits    float64
+	Memory    float64
+}
+
+// PodRequestedQuota is what a pod asks for through its claim: the shares of
+// the devices of one vendor and model it needs all at once.
 type PodRequestedQuota struct {
-	PodId    string
-	Vendor   string
-	Model    string
-	Requests float64
-	Limits   float64
-	Memory   float64
+	// PodId is the "<namespace>/<name>/<uid>" of the pod.
+	PodId     string
+	ClaimName string
+	Vendor    string
+	Model     string
+	Shares    []ShareQuota
 }
 
 type FreeDeviceResources struct {
 	DeviceId string
 	Requests float64
 	Memory   float64
+	// Unhealthy devices are reported by the device manager but must not
+	// receive new shares. HealthMessage tells why.
+	Unhealthy     bool
+	HealthMessage string
 }
 
+// ShareDevState is the state of the pod in a scheduling cycle. Filter runs on
+// the nodes in parallel, so the per-node maps it fills are only read and
+// written through the methods holding lock.
 type ShareDevState struct {
-	PodQ                       PodRequestedQuota
+	// PodQ is the quota of the pod on its claim's own model, and on the model
+	// of its node once Reserve picked the devices.
+	PodQ PodRequestedQuota
+	// Models are the quotas of the pod on every model its claim accepts, in
+	// preference order, PodQ first.
+	Models []PodRequestedQuota
+	// NodeModels is the index in Models of the model the pod fits on each
+	// node, when not the first one.
+	NodeModels                 map[string]int
 	FreeDeviceResourcesPerNode map[string][]FreeDeviceResources
-	NodeNameToIP               map[string]string
-	ReservedDeviceId           string
+	NodeNameToEndpoint         map[string]string
+	// ReservedDeviceIds are the devices the shares of PodQ were reserved on,
+	// by share index; empty where nothing is reserved.
+	ReservedDeviceIds []string
+	// PreBound tells whether PreBind annotated the pod with its devices,
+	// which Unreserve undoes when the binding fails.
+	PreBound bool
+	// Released are the shares the pods removed from each node in the
+	// preemption dry run free, negative for the pods added back.
+	Released map[string][]FreeDeviceResources
+	// Nominated are the quotas, on every model their claims accept, of the
+	// higher-priority pods nominated to each node that haven't reserved their
+	// devices yet. They take their shares before the pod.
+	Nominated map[string][][]PodRequestedQuota
+
+	// lock guards NodeModels, FreeDeviceResourcesPerNode and NodeNameToEndpoint.
+	lock sync.RWMutex
 }
 
 func (s *ShareDevState) Clone() framework.StateData {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
 	n := ShareDevState{
 		FreeDeviceResourcesPerNode: make(map[string][]FreeDeviceResources),
-		NodeNameToIP:               make(map[string]string),
+		NodeNameToEndpoint:         make(map[string]string),
 		PodQ:                       s.PodQ,
-		ReservedDeviceId:           s.ReservedDeviceId,
+		Models:                     s.Models,
+		PreBound:                   s.PreBound,
+	}
+	if s.NodeModels != nil {
+		n.NodeModels = make(map[string]int, len(s.NodeModels))
+		for k, v := range s.NodeModels {
+			n.NodeModels[k] = v
+		}
+	}
+	if s.ReservedDeviceIds != nil {
+		n.ReservedDeviceIds = make([]string, len(s.ReservedDeviceIds))
+		copy(n.ReservedDeviceIds, s.ReservedDeviceIds)
 	}
 
 	for k, v := range s.FreeDeviceResourcesPerNode {
@@ -47,13 +107,93 @@ func (s *ShareDevState) Clone() framework.StateData {
 		n.FreeDeviceResourcesPerNode[k] = arr
 	}
 
-	for k, v := range s.NodeNameToIP {
-		n.NodeNameToIP[k] = v
+	for k, v := range s.NodeNameToEndpoint {
+		n.NodeNameToEndpoint[k] = v
+	}
+
+	if s.Released != nil {
+		n.Released = make(map[string][]FreeDeviceResources, len(s.Released))
+		for k, v := range s.Released {
+			arr := make([]FreeDeviceResources, len(v))
+			copy(arr, v)
+			n.Released[k] = arr
+		}
+	}
+
+	if s.Nominated != nil {
+		n.Nominated = make(map[string][][]PodRequestedQuota, len(s.Nominated))
+		for k, v := range s.Nominated {
+			arr := make([][]PodRequestedQuota, len(v))
+			copy(arr, v)
+			n.Nominated[k] = arr
+		}
 	}
 
 	return &n
 }
 
+// models returns the quotas of the pod on every model its claim accepts.
+func (s *ShareDevState) models() []PodRequestedQuota {
+	if len(s.Models) == 0 {
+		return []PodRequestedQuota{s.PodQ}
+	}
+	return s.Models
+}
+
+// quotaOn returns the quota of the pod on the model it fits on the node.
+func (s *ShareDevState) quotaOn(nodeName string) PodRequestedQuota {
+	if i := s.modelOn(nodeName); i > 0 && i < len(s.Models) {
+		return s.Models[i]
+	}
+	return s.PodQ
+}
+
+// modelOn returns the index in Models of the model the pod fits on the node.
+func (s *ShareDevState) modelOn(nodeName string) int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.NodeModels[nodeName]
+}
+
+// setNode records the free devices Filter found on the node, and the endpoint
+// of their device manager.
+func (s *ShareDevState) setNode(nodeName, endpoint string, free []FreeDeviceResources) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.FreeDeviceResourcesPerNode[nodeName] = free
+	s.NodeNameToEndpoint[nodeName] = endpoint
+}
+
+// setModelOn records the index in Models of the model the pod fits on the node.
+func (s *ShareDevState) setModelOn(nodeName string, i int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if i > 0 {
+		s.NodeModels[nodeName] = i
+	} else {
+		delete(s.NodeModels, nodeName)
+	}
+}
+
+// freeOn returns the free devices Filter found on the node.
+func (s *ShareDevState) freeOn(nodeName string) []FreeDeviceResources {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.FreeDeviceResourcesPerNode[nodeName]
+}
+
+// endpointOf returns the endpoint of the device manager of the node.
+func (s *ShareDevState) endpointOf(nodeName string) string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.NodeNameToEndpoint[nodeName]
+}
+
 func getShareDevState(state *framework.CycleState) (*ShareDevState, error) {
 	s, err := state.Read(ShareDevStateKey)
 	if err != nil {
@@ -0,0 +1,102 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	st "k8s.io/kubernetes/pkg/scheduler/testing"
+)
+
+func TestFilterPodsWithPDBViolation(t *testing.T) {
+	pdb := func(name, namespace, app string, allowed int32, disrupted ...string) *policy.PodDisruptionBudget {
+		p := &policy.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       policy.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}}},
+			Status:     policy.PodDisruptionBudgetStatus{DisruptionsAllowed: allowed, DisruptedPods: map[string]metav1.Time{}},
+		}
+		for _, name := range disrupted {
+			p.Status.DisruptedPods[name] = metav1.Now()
+		}
+		return p
+	}
+	tests := []struct {
+		name             string
+		pods             []*v1.Pod
+		pdbs             []*policy.PodDisruptionBudget
+		wantViolating    []string
+		wantNonViolating []string
+	}{
+		{
+			name:             "no PDBs",
+			pods:             []*v1.Pod{st.MakePod().Name("p1").Namespace("ns").Label("app", "a").Obj()},
+			wantNonViolating: []string{"p1"},
+		},
+		{
+			name: "the budget is used up in order",
+			pods: []*v1.Pod{
+				st.MakePod().Name("p1").Namespace("ns").Label("app", "a").Obj(),
+				st.MakePod().Name("p2").Namespace("ns").Label("app", "a").Obj(),
+				st.MakePod().Name("p3").Namespace("ns").Label("app", "a").Obj(),
+			},
+			pdbs:             []*policy.PodDisruptionBudget{pdb("pdb", "ns", "a", 1)},
+			wantViolating:    []string{"p2", "p3"},
+			wantNonViolating: []string{"p1"},
+		},
+		{
+			name: "PDBs of other namespaces, other pods or already disrupting the pod",
+			pods: []*v1.Pod{
+				st.MakePod().Name("p1").Namespace("ns").Label("app", "a").Obj(),
+				st.MakePod().Name("p2").Namespace("ns").Obj(),
+			},
+			pdbs: []*policy.PodDisruptionBudget{
+				pdb("other-ns", "other", "a", 0),
+				pdb("other-app", "ns", "b", 0),
+				pdb("disrupted", "ns", "a", 0, "p1"),
+			},
+			wantNonViolating: []string{"p1", "p2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var podInfos []*framework.PodInfo
+			for _, p := range tt.pods {
+				podInfos = append(podInfos, &framework.PodInfo{Pod: p})
+			}
+			violating, nonViolating := FilterPodsWithPDBViolation(podInfos, tt.pdbs)
+			if diff := cmp.Diff(tt.wantViolating, podNames(violating)); diff != "" {
+				t.Errorf("unexpected violating pods (-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantNonViolating, podNames(nonViolating)); diff != "" {
+				t.Errorf("unexpected non-violating pods (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func podNames(podInfos []*framework.PodInfo) []string {
+	var names []string
+	for _, pi := range podInfos {
+		names = append(names, pi.Pod.Name)
+	}
+	return names
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+)
+
+// SharedDeviceResourcePrefix is the prefix of the synthetic resources shared
+// device claims are accounted as in ElasticQuotas.
+const SharedDeviceResourcePrefix = "sharedev.io/"
+
+// SharedDeviceComputeResource returns the synthetic resource the compute
+// shares of vendor/model devices are accounted as, e.g.
+// "sharedev.io/example.com-mydev-compute". A whole device is 1.
+func SharedDeviceComputeResource(vendor, model string) v1.ResourceName {
+	return v1.ResourceName(fmt.Sprintf("%s%s-%s-compute", SharedDeviceResourcePrefix, vendor, model))
+}
+
+// SharedDeviceMemoryResource returns the synthetic resource the memory shares
+// of vendor/model devices are accounted as. A whole device is 1.
+func SharedDeviceMemoryResource(vendor, model string) v1.ResourceName {
+	return v1.ResourceName(fmt.Sprintf("%s%s-%s-memory", SharedDeviceResourcePrefix, vendor, model))
+}
+
+// IsSharedDeviceResource tells whether name is a shared device synthetic resource.
+func IsSharedDeviceResource(name v1.ResourceName) bool {
+	return strings.HasPrefix(string(name), SharedDeviceResourcePrefix)
+}
+
+// GetSharedDeviceRequest returns the shares of devices a pod referencing claim
+// uses, as synthetic resources: the pod's share on each of its devices plus
+// the shares of its containers.
+func GetSharedDeviceRequest(claim *v1alpha1.SharedDeviceClaim) v1.ResourceList {
+	spec := claim.Spec
+	compute := resource.Quantity{Format: resource.DecimalSI}
+	memory := resource.Quantity{Format: resource.DecimalSI}
+	count := 1
+	if spec.Count != nil && *spec.Count > 1 {
+		count = int(*spec.Count)
+	}
+	for i := 0; i < count; i++ {
+		compute.Add(spec.Compute)
+		memory.Add(spec.Memory)
+	}
+	for _, c := range spec.Containers {
+		compute.Add(c.Compute)
+		memory.Add(c.Memory)
+	}
+	return v1.ResourceList{
+		SharedDeviceComputeResource(spec.Vendor, spec.Model): compute,
+		SharedDeviceMemoryResource(spec.Vendor, spec.Model):  memory,
+	}
+}
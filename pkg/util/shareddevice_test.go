@@ -0,0 +1,81 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	quota "k8s.io/apiserver/pkg/quota/v1"
+	"k8s.io/utils/pointer"
+
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+)
+
+func TestGetSharedDeviceRequest(t *testing.T) {
+	compute := SharedDeviceComputeResource("example.com", "mydev")
+	memory := SharedDeviceMemoryResource("example.com", "mydev")
+	if compute != "sharedev.io/example.com-mydev-compute" || memory != "sharedev.io/example.com-mydev-memory" {
+		t.Fatalf("unexpected resource names %q and %q", compute, memory)
+	}
+
+	tests := []struct {
+		name string
+		spec v1alpha1.SharedDeviceClaimSpec
+		want v1.ResourceList
+	}{
+		{
+			name: "one share",
+			spec: v1alpha1.SharedDeviceClaimSpec{
+				Compute: resource.MustParse("250m"),
+				Memory:  resource.MustParse("500m"),
+			},
+			want: v1.ResourceList{compute: resource.MustParse("250m"), memory: resource.MustParse("500m")},
+		},
+		{
+			name: "limit is not accounted",
+			spec: v1alpha1.SharedDeviceClaimSpec{
+				Compute: resource.MustParse("250m"),
+				Memory:  resource.MustParse("500m"),
+				Limit:   resource.NewMilliQuantity(750, resource.DecimalSI),
+			},
+			want: v1.ResourceList{compute: resource.MustParse("250m"), memory: resource.MustParse("500m")},
+		},
+		{
+			name: "shares on several devices and of containers",
+			spec: v1alpha1.SharedDeviceClaimSpec{
+				Compute: resource.MustParse("500m"),
+				Memory:  resource.MustParse("250m"),
+				Count:   pointer.Int32(3),
+				Containers: []v1alpha1.SharedDeviceContainerShare{
+					{Name: "sidecar", Compute: resource.MustParse("100m"), Memory: resource.MustParse("50m")},
+				},
+			},
+			want: v1.ResourceList{compute: resource.MustParse("1600m"), memory: resource.MustParse("800m")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.spec.Vendor, tt.spec.Model = "example.com", "mydev"
+			got := GetSharedDeviceRequest(&v1alpha1.SharedDeviceClaim{Spec: tt.spec})
+			if !quota.Equals(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
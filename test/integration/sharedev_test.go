@@ -0,0 +1,383 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kubernetes/pkg/scheduler"
+	schedapi "k8s.io/kubernetes/pkg/scheduler/apis/config"
+	fwkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+	st "k8s.io/kubernetes/pkg/scheduler/testing"
+	imageutils "k8s.io/kubernetes/test/utils/image"
+
+	schedconfig "sigs.k8s.io/scheduler-plugins/apis/config"
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling"
+	"sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	"sigs.k8s.io/scheduler-plugins/pkg/generated/clientset/versioned"
+	"sigs.k8s.io/scheduler-plugins/pkg/sharedev"
+	"sigs.k8s.io/scheduler-plugins/test/util"
+)
+
+const sharedevVendor = "example.com"
+
+// sharedevNode is a node with its own fake device manager.
+type sharedevNode struct {
+	name          string
+	ip            string
+	deviceManager *util.FakeDeviceManager
+}
+
+func TestShareDevPlugin(t *testing.T) {
+	testCtx := &testContext{}
+	testCtx.Ctx, testCtx.CancelFn = context.WithCancel(context.Background())
+
+	cs := kubernetes.NewForConfigOrDie(globalKubeConfig)
+	extClient := versioned.NewForConfigOrDie(globalKubeConfig)
+	testCtx.ClientSet = cs
+	testCtx.KubeConfig = globalKubeConfig
+
+	if err := wait.Poll(100*time.Millisecond, 3*time.Second, func() (done bool, err error) {
+		groupList, _, err := cs.ServerGroupsAndResources()
+		if err != nil {
+			return false, nil
+		}
+		for _, group := range groupList {
+			if group.Name == scheduling.GroupName {
+				t.Log("The CRD is ready to serve")
+				return true, nil
+			}
+		}
+		return false, nil
+	}); err != nil {
+		t.Fatalf("Timed out waiting for CRD to be ready: %v", err)
+	}
+
+	// The plugin dials every node's device manager on the same port, so each
+	// node gets a loopback address of its own.
+	nodes := []*sharedevNode{
+		{name: "fake-node-1", ip: "127.0.0.2", deviceManager: util.NewFakeDeviceManager()},
+		{name: "fake-node-2", ip: "127.0.0.3", deviceManager: util.NewFakeDeviceManager()},
+	}
+	port := serveSharedevNodes(t, nodes)
+
+	ns := fmt.Sprintf("integration-test-%v", string(uuid.NewUUID()))
+	createNamespace(t, testCtx, ns)
+
+	cfg, err := util.NewDefaultSchedulerComponentConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Profiles[0].Plugins.PreFilter.Enabled = append(cfg.Profiles[0].Plugins.PreFilter.Enabled, schedapi.Plugin{Name: sharedev.Name})
+	cfg.Profiles[0].Plugins.Filter.Enabled = append(cfg.Profiles[0].Plugins.Filter.Enabled, schedapi.Plugin{Name: sharedev.Name})
+	cfg.Profiles[0].Plugins.PostFilter = schedapi.PluginSet{
+		Enabled:  []schedapi.Plugin{{Name: sharedev.Name}},
+		Disabled: []schedapi.Plugin{{Name: "*"}},
+	}
+	// Only score devices, so that pods are packed by the plugin's strategy.
+	cfg.Profiles[0].Plugins.Score = schedapi.PluginSet{
+		Enabled:  []schedapi.Plugin{{Name: sharedev.Name}},
+		Disabled: []schedapi.Plugin{{Name: "*"}},
+	}
+	cfg.Profiles[0].Plugins.Reserve.Enabled = append(cfg.Profiles[0].Plugins.Reserve.Enabled, schedapi.Plugin{Name: sharedev.Name})
+	cfg.Profiles[0].Plugins.PreBind.Enabled = append(cfg.Profiles[0].Plugins.PreBind.Enabled, schedapi.Plugin{Name: sharedev.Name})
+	cfg.Profiles[0].PluginConfig = append(cfg.Profiles[0].PluginConfig, schedapi.PluginConfig{
+		Name: sharedev.Name,
+		Args: &schedconfig.ShareDevPluginArgs{
+			DeviceManagerPort:                  port,
+			AllocatorNamespace:                 ns,
+			AllocatorImage:                     "allocator:test",
+			GetAvailableDevicesTimeoutSeconds:  1,
+			ReservePodQuotaTimeoutSeconds:      1,
+			AllocationTimeoutSeconds:           60,
+			DeviceInventoryResyncPeriodSeconds: 1,
+			ReservationReconcilePeriodSeconds:  1,
+			ReservationLeaseSeconds:            60,
+			DeviceManagerUnreachableSeconds:    60,
+			ScoringStrategy: schedconfig.ScoringStrategy{
+				Type:      schedconfig.MostAllocated,
+				Resources: []schedapi.ResourceSpec{{Name: "compute", Weight: 1}, {Name: "memory", Weight: 1}},
+			},
+			DeviceManagerEndpoint: schedconfig.DeviceManagerEndpoint{Type: schedconfig.NodeAddressEndpoint},
+			DeviceManagerTLS:      schedconfig.DeviceManagerTLS{Insecure: true},
+			NoFitPolicy:           schedconfig.ProvisionNoFit,
+		},
+	})
+
+	testCtx = initTestSchedulerWithOptions(
+		t,
+		testCtx,
+		scheduler.WithProfiles(cfg.Profiles...),
+		scheduler.WithFrameworkOutOfTreeRegistry(fwkruntime.Registry{sharedev.Name: sharedev.New}),
+	)
+	syncInformerFactory(testCtx)
+	go testCtx.Scheduler.Run(testCtx.Ctx)
+	t.Log("Init scheduler success")
+	defer cleanupTest(t, testCtx)
+
+	for _, n := range nodes {
+		node := st.MakeNode().Name(n.name).Label("node", n.name).Obj()
+		node.Status.Allocatable = v1.ResourceList{
+			v1.ResourcePods: *resource.NewQuantity(64, resource.DecimalSI),
+		}
+		node.Status.Capacity = v1.ResourceList{
+			v1.ResourcePods: *resource.NewQuantity(64, resource.DecimalSI),
+		}
+		node.Status.Addresses = []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: n.ip}}
+		if _, err := cs.CoreV1().Nodes().Create(testCtx.Ctx, node, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("Failed to create Node %q: %v", n.name, err)
+		}
+	}
+
+	// Every scenario uses a device model of its own, so they don't see each other's devices.
+	for _, model := range []string{"pack", "release", "provision", "burst"} {
+		for _, claim := range []*v1alpha1.SharedDeviceClaim{
+			makeSharedDeviceClaim(ns, model+"-quarter", model, "250m"),
+			makeSharedDeviceClaim(ns, model+"-whole", model, "1"),
+		} {
+			if _, err := extClient.SchedulingV1alpha1().SharedDeviceClaims(ns).Create(testCtx.Ctx, claim, metav1.CreateOptions{}); err != nil {
+				t.Fatalf("Failed to create SharedDeviceClaim %q: %v", claim.Name, err)
+			}
+		}
+	}
+
+	t.Run("fractional pods are packed onto one device", func(t *testing.T) {
+		for _, n := range nodes {
+			n.deviceManager.AddDevice(sharedevVendor, "pack", n.name+"-pack")
+		}
+
+		var pods []*v1.Pod
+		for i := 0; i < 4; i++ {
+			pod := makeSharedDevicePod(ns, fmt.Sprintf("pack-%d", i), "pack-quarter")
+			// One at a time, so that each pod sees where the previous ones went.
+			createSharedDevicePods(t, testCtx, pod)
+			waitSharedDevicePodsScheduled(t, testCtx, pod)
+			pods = append(pods, pod)
+		}
+		defer cleanupPods(t, testCtx, pods)
+
+		deviceIds := map[string]bool{}
+		for _, pod := range pods {
+			deviceIds[sharedDeviceID(t, testCtx, pod)] = true
+		}
+		if len(deviceIds) != 1 {
+			t.Errorf("expected all pods on one device, got %v", deviceIds)
+		}
+		for _, n := range nodes {
+			deviceId := n.name + "-pack"
+			if got := len(n.deviceManager.Reservations(deviceId)); deviceIds[deviceId] && got != 4 {
+				t.Errorf("expected 4 reservations on %s, got %d", deviceId, got)
+			}
+		}
+	})
+
+	t.Run("quota is reused once a deleted pod's is released", func(t *testing.T) {
+		for _, n := range nodes {
+			n.deviceManager.AddDevice(sharedevVendor, "release", n.name+"-release")
+		}
+
+		first := []*v1.Pod{makeSharedDevicePod(ns, "release-0", "release-whole"), makeSharedDevicePod(ns, "release-1", "release-whole")}
+		createSharedDevicePods(t, testCtx, first...)
+		waitSharedDevicePodsScheduled(t, testCtx, first...)
+		freed := sharedDeviceID(t, testCtx, first[0])
+
+		// The device manager releases the quota of deleted pods on its own.
+		cleanupPods(t, testCtx, first[:1])
+		for _, n := range nodes {
+			n.deviceManager.Release(first[0].Name)
+		}
+		// Let the plugin's inventory resync.
+		time.Sleep(3 * time.Second)
+
+		next := makeSharedDevicePod(ns, "release-2", "release-whole")
+		createSharedDevicePods(t, testCtx, next)
+		waitSharedDevicePodsScheduled(t, testCtx, next)
+		defer cleanupPods(t, testCtx, []*v1.Pod{first[1], next})
+
+		if got := sharedDeviceID(t, testCtx, next); got != freed {
+			t.Errorf("expected the pod on the released device %s, got %s", freed, got)
+		}
+	})
+
+	t.Run("allocators are provisioned when devices are exhausted", func(t *testing.T) {
+		n := nodes[0]
+		n.deviceManager.AddDevice(sharedevVendor, "provision", n.name+"-provision")
+
+		full := makeSharedDevicePod(ns, "provision-0", "provision-whole")
+		createSharedDevicePods(t, testCtx, full)
+		waitSharedDevicePodsScheduled(t, testCtx, full)
+
+		waiting := makeSharedDevicePod(ns, "provision-1", "provision-quarter")
+		createSharedDevicePods(t, testCtx, waiting)
+		defer cleanupPods(t, testCtx, []*v1.Pod{full, waiting})
+
+		var deployName string
+		if err := wait.Poll(100*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+			deployments, err := cs.AppsV1().Deployments(ns).List(testCtx.Ctx, metav1.ListOptions{})
+			if err != nil || len(deployments.Items) == 0 {
+				return false, nil
+			}
+			if len(deployments.Items) > 1 {
+				return false, fmt.Errorf("expected one allocator, got %d", len(deployments.Items))
+			}
+			deployName = deployments.Items[0].Name
+			return true, nil
+		}); err != nil {
+			t.Fatalf("Waiting for the allocator Deployment: %v", err)
+		}
+		if podScheduled(cs, waiting.Namespace, waiting.Name) {
+			t.Fatalf("expected pod %s to wait for the new device", waiting.Name)
+		}
+
+		// Play the Deployment controller and the allocator: its pod claims a
+		// new device on the node and registers it under the pod's name.
+		allocator := st.MakePod().Namespace(ns).Name(deployName+"-abcde").Node(n.name).
+			Label(v1alpha1.SharedDeviceRoleLabel, v1alpha1.SharedDeviceAllocatorRole).
+			Label(v1alpha1.SharedDeviceAllocatorNameLabel, deployName).
+			Container(imageutils.GetPauseImageName()).ZeroTerminationGracePeriod().Obj()
+		n.deviceManager.AddDevice(sharedevVendor, "provision", allocator.Name)
+		// Let the plugin's inventory resync.
+		time.Sleep(3 * time.Second)
+		allocator, err := cs.CoreV1().Pods(ns).Create(testCtx.Ctx, allocator, metav1.CreateOptions{})
+		if err != nil {
+			t.Fatalf("Failed to create allocator pod: %v", err)
+		}
+		defer cleanupPods(t, testCtx, []*v1.Pod{allocator})
+		allocator.Status.Phase = v1.PodRunning
+		if _, err := cs.CoreV1().Pods(ns).UpdateStatus(testCtx.Ctx, allocator, metav1.UpdateOptions{}); err != nil {
+			t.Fatalf("Failed to update allocator pod status: %v", err)
+		}
+
+		waitSharedDevicePodsScheduled(t, testCtx, waiting)
+		if got := sharedDeviceID(t, testCtx, waiting); got != allocator.Name {
+			t.Errorf("expected the pod on the new device %s, got %s", allocator.Name, got)
+		}
+	})
+
+	t.Run("concurrent pods never overcommit a device", func(t *testing.T) {
+		devices := map[string]*util.FakeDeviceManager{}
+		for _, n := range nodes {
+			for _, suffix := range []string{"a", "b"} {
+				deviceId := n.name + "-burst-" + suffix
+				n.deviceManager.AddDevice(sharedevVendor, "burst", deviceId)
+				devices[deviceId] = n.deviceManager
+			}
+		}
+
+		// Four devices fit sixteen quarters, exactly.
+		var pods []*v1.Pod
+		for i := 0; i < 16; i++ {
+			pods = append(pods, makeSharedDevicePod(ns, fmt.Sprintf("burst-%d", i), "burst-quarter"))
+		}
+		var wg sync.WaitGroup
+		for _, pod := range pods {
+			wg.Add(1)
+			go func(pod *v1.Pod) {
+				defer wg.Done()
+				createSharedDevicePods(t, testCtx, pod)
+			}(pod)
+		}
+		wg.Wait()
+		defer cleanupPods(t, testCtx, pods)
+		waitSharedDevicePodsScheduled(t, testCtx, pods...)
+
+		perDevice := map[string]int{}
+		for _, pod := range pods {
+			perDevice[sharedDeviceID(t, testCtx, pod)]++
+		}
+		for deviceId, deviceManager := range devices {
+			if got := len(deviceManager.Reservations(deviceId)); got != 4 || perDevice[deviceId] != 4 {
+				t.Errorf("expected 4 pods and reservations on %s, got %d pods and %d reservations", deviceId, perDevice[deviceId], got)
+			}
+		}
+	})
+}
+
+// serveSharedevNodes serves the device manager of every node on its IP and a
+// port they share, and returns the port.
+func serveSharedevNodes(t *testing.T, nodes []*sharedevNode) int32 {
+	port := "0"
+	for _, n := range nodes {
+		lis, err := net.Listen("tcp", net.JoinHostPort(n.ip, port))
+		if err != nil {
+			t.Fatalf("Failed to listen for the device manager of %s: %v", n.name, err)
+		}
+		n.deviceManager.Serve(t, lis)
+		_, port, _ = net.SplitHostPort(lis.Addr().String())
+	}
+	p, _ := strconv.Atoi(port)
+	return int32(p)
+}
+
+func makeSharedDeviceClaim(namespace, name, model, share string) *v1alpha1.SharedDeviceClaim {
+	return &v1alpha1.SharedDeviceClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: v1alpha1.SharedDeviceClaimSpec{
+			Vendor:  sharedevVendor,
+			Model:   model,
+			Compute: resource.MustParse(share),
+			Memory:  resource.MustParse(share),
+		},
+	}
+}
+
+func makeSharedDevicePod(namespace, name, claimName string) *v1.Pod {
+	return st.MakePod().Namespace(namespace).Name(name).
+		Label(v1alpha1.SharedDeviceClaimLabel, claimName).
+		Container(imageutils.GetPauseImageName()).ZeroTerminationGracePeriod().Obj()
+}
+
+func createSharedDevicePods(t *testing.T, testCtx *testContext, pods ...*v1.Pod) {
+	for _, pod := range pods {
+		if _, err := testCtx.ClientSet.CoreV1().Pods(pod.Namespace).Create(testCtx.Ctx, pod, metav1.CreateOptions{}); err != nil {
+			t.Errorf("Failed to create Pod %q: %v", pod.Name, err)
+		}
+	}
+}
+
+func waitSharedDevicePodsScheduled(t *testing.T, testCtx *testContext, pods ...*v1.Pod) {
+	t.Helper()
+	for _, pod := range pods {
+		if err := wait.Poll(100*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+			return podScheduled(testCtx.ClientSet, pod.Namespace, pod.Name), nil
+		}); err != nil {
+			t.Fatalf("Waiting for pod %q to be scheduled: %v", pod.Name, err)
+		}
+	}
+}
+
+// sharedDeviceID returns the device the scheduler bound the pod to.
+func sharedDeviceID(t *testing.T, testCtx *testContext, pod *v1.Pod) string {
+	t.Helper()
+	got, err := testCtx.ClientSet.CoreV1().Pods(pod.Namespace).Get(testCtx.Ctx, pod.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get Pod %q: %v", pod.Name, err)
+	}
+	return got.Annotations[v1alpha1.SharedDeviceIDAnnotation]
+}
@@ -126,6 +126,27 @@ func (p *podWrapper) Node(name string) *podWrapper {
 	return p
 }
 
+func (p *podWrapper) Label(key, value string) *podWrapper {
+	if p.Pod.Labels == nil {
+		p.Pod.Labels = map[string]string{}
+	}
+	p.Pod.Labels[key] = value
+	return p
+}
+
+func (p *podWrapper) Annotation(key, value string) *podWrapper {
+	if p.Pod.Annotations == nil {
+		p.Pod.Annotations = map[string]string{}
+	}
+	p.Pod.Annotations[key] = value
+	return p
+}
+
+func (p *podWrapper) Created(t time.Time) *podWrapper {
+	p.Pod.CreationTimestamp = metav1.NewTime(t)
+	return p
+}
+
 func (p *podWrapper) Obj() *v1.Pod {
 	return p.Pod
 }
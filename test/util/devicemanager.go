@@ -0,0 +1,425 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/zbsss/device-manager/generated"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"k8s.io/utils/clock"
+
+	dmpb "sigs.k8s.io/scheduler-plugins/pkg/sharedev/devicemanagerpb"
+)
+
+// quotaEpsilon absorbs the rounding of the float quotas summed up per device.
+const quotaEpsilon = 1e-9
+
+// FakeReservation is the quota a pod reserved on a fake device.
+type FakeReservation struct {
+	Requests float64
+	Limit    float64
+	Memory   float64
+	// ExpiresAt is when the lease of the reservation expires, zero without a lease.
+	ExpiresAt time.Time
+}
+
+type fakeDevice struct {
+	vendor       string
+	model        string
+	reservations map[string]FakeReservation
+	// unhealthy is why the device is unhealthy, empty if it is healthy.
+	unhealthy string
+}
+
+// FakeDeviceManager is an in-memory dmpb.DeviceManagerServer. It models whole
+// devices, each with 1 compute and 1 memory, and the quota pods reserved on
+// them, dropped once their lease expires on its clock. Any RPC can be made to
+// fail or to answer late.
+type FakeDeviceManager struct {
+	pb.UnimplementedDeviceManagerServer
+	dmpb.UnimplementedDeviceManagerExtensionsServer
+
+	clock   clock.Clock
+	mu      sync.Mutex
+	devices map[string]*fakeDevice
+	// withoutExtensions makes the extensions RPCs unimplemented.
+	withoutExtensions bool
+	errs              map[string]error
+	failNext          map[string][]error
+	delays            map[string]time.Duration
+	calls             map[string]int
+}
+
+var _ dmpb.DeviceManagerServer = &FakeDeviceManager{}
+
+func NewFakeDeviceManager() *FakeDeviceManager {
+	return &FakeDeviceManager{
+		clock:    clock.RealClock{},
+		devices:  map[string]*fakeDevice{},
+		errs:     map[string]error{},
+		failNext: map[string][]error{},
+		delays:   map[string]time.Duration{},
+		calls:    map[string]int{},
+	}
+}
+
+// WithClock makes the leases expire on c.
+func (f *FakeDeviceManager) WithClock(c clock.Clock) *FakeDeviceManager {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.clock = c
+	return f
+}
+
+// WithoutExtensions makes the fake a device manager that only serves the
+// DeviceManager service, not its extensions.
+func (f *FakeDeviceManager) WithoutExtensions() *FakeDeviceManager {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.withoutExtensions = true
+	return f
+}
+
+// AddDevice adds an unused device.
+func (f *FakeDeviceManager) AddDevice(vendor, model, deviceId string) *FakeDeviceManager {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.devices[deviceId] = &fakeDevice{vendor: vendor, model: model, reservations: map[string]FakeReservation{}}
+	return f
+}
+
+// Reserve reserves quota on a device as if a pod had been scheduled there.
+func (f *FakeDeviceManager) Reserve(deviceId, podId string, requests, memory float64) *FakeDeviceManager {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.devices[deviceId].reservations[podId] = FakeReservation{Requests: requests, Limit: requests, Memory: memory}
+	return f
+}
+
+// SetUnhealthy reports the device as unhealthy for reason, until SetHealthy.
+func (f *FakeDeviceManager) SetUnhealthy(deviceId, reason string) *FakeDeviceManager {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.devices[deviceId].unhealthy = reason
+	return f
+}
+
+// SetHealthy reports the device as healthy again.
+func (f *FakeDeviceManager) SetHealthy(deviceId string) *FakeDeviceManager {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.devices[deviceId].unhealthy = ""
+	return f
+}
+
+// Release drops the quota of a pod and of its containers, like the device
+// manager's garbage collection does once the pod is deleted.
+func (f *FakeDeviceManager) Release(podId string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, d := range f.devices {
+		for clientId := range d.reservations {
+			if clientId == podId || strings.HasPrefix(clientId, podId+".") {
+				delete(d.reservations, clientId)
+			}
+		}
+	}
+}
+
+// Reservations returns the quota reserved on a device, by pod.
+func (f *FakeDeviceManager) Reservations(deviceId string) map[string]FakeReservation {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.expireLocked()
+	reservations := map[string]FakeReservation{}
+	if d, ok := f.devices[deviceId]; ok {
+		for podId, r := range d.reservations {
+			reservations[podId] = r
+		}
+	}
+	return reservations
+}
+
+// SetError makes every call of method, e.g. "ReservePodQuota", fail with err
+// until it is set back to nil.
+func (f *FakeDeviceManager) SetError(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errs[method] = err
+}
+
+// FailNext makes the next n calls of method fail with err.
+func (f *FakeDeviceManager) FailNext(method string, n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := 0; i < n; i++ {
+		f.failNext[method] = append(f.failNext[method], err)
+	}
+}
+
+// SetDelay makes every call of method wait for d before being answered.
+func (f *FakeDeviceManager) SetDelay(method string, d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.delays[method] = d
+}
+
+// Calls returns how many times method was called.
+func (f *FakeDeviceManager) Calls(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+// intercept counts the calls and injects the configured errors and delays.
+func (f *FakeDeviceManager) intercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	method := path.Base(info.FullMethod)
+	f.mu.Lock()
+	f.calls[method]++
+	err, delay := f.errs[method], f.delays[method]
+	if next := f.failNext[method]; len(next) > 0 {
+		err, f.failNext[method] = next[0], next[1:]
+	}
+	if f.withoutExtensions && path.Dir(info.FullMethod) == "/"+dmpb.DeviceManagerExtensions_ServiceDesc.ServiceName {
+		err = status.Errorf(codes.Unimplemented, "unknown service %s", dmpb.DeviceManagerExtensions_ServiceDesc.ServiceName)
+	}
+	f.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (f *FakeDeviceManager) RegisterDevice(_ context.Context, req *pb.RegisterDeviceRequest) (*pb.RegisterDeviceReply, error) {
+	f.AddDevice(req.Vendor, req.Model, req.DeviceId)
+	return &pb.RegisterDeviceReply{}, nil
+}
+
+func (f *FakeDeviceManager) GetAvailableDevices(ctx context.Context, req *pb.GetAvailableDevicesRequest) (*pb.GetAvailableDevicesReply, error) {
+	health, err := f.GetAvailableDevicesHealth(ctx, &dmpb.GetAvailableDevicesHealthRequest{Vendor: req.Vendor, Model: req.Model})
+	if err != nil {
+		return nil, err
+	}
+	reply := &pb.GetAvailableDevicesReply{}
+	for _, free := range health.Free {
+		reply.Free = append(reply.Free, &pb.FreeDeviceResources{DeviceId: free.DeviceId, Requests: free.Requests, Memory: free.Memory})
+	}
+	return reply, nil
+}
+
+func (f *FakeDeviceManager) GetAvailableDevicesHealth(_ context.Context, req *dmpb.GetAvailableDevicesHealthRequest) (*dmpb.GetAvailableDevicesHealthReply, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.expireLocked()
+
+	reply := &dmpb.GetAvailableDevicesHealthReply{}
+	for deviceId, d := range f.devices {
+		if d.vendor != req.Vendor || d.model != req.Model {
+			continue
+		}
+		requests, memory := d.free("")
+		reply.Free = append(reply.Free, &dmpb.FreeDeviceResourcesHealth{
+			DeviceId:      deviceId,
+			Requests:      requests,
+			Memory:        memory,
+			Unhealthy:     d.unhealthy != "",
+			HealthMessage: d.unhealthy,
+		})
+	}
+	sort.Slice(reply.Free, func(i, j int) bool { return reply.Free[i].DeviceId < reply.Free[j].DeviceId })
+	return reply, nil
+}
+
+func (f *FakeDeviceManager) ReservePodQuota(ctx context.Context, req *pb.ReservePodQuotaRequest) (*pb.ReservePodQuotaReply, error) {
+	_, err := f.ReservePodQuotaLease(ctx, &dmpb.ReservePodQuotaLeaseRequest{
+		DeviceId: req.DeviceId,
+		PodId:    req.PodId,
+		Requests: req.Requests,
+		Limit:    req.Limit,
+		Memory:   req.Memory,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ReservePodQuotaReply{}, nil
+}
+
+func (f *FakeDeviceManager) ReservePodQuotaLease(_ context.Context, req *dmpb.ReservePodQuotaLeaseRequest) (*dmpb.ReservePodQuotaLeaseReply, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.expireLocked()
+
+	d, ok := f.devices[req.DeviceId]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "device %s not found", req.DeviceId)
+	}
+	if req.Requests <= 0 || req.Memory <= 0 || req.Limit < req.Requests || req.LeaseSeconds < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid quota %v", req)
+	}
+	// Reserving again replaces the pod's previous reservation.
+	requests, memory := d.free(req.PodId)
+	if req.Requests > requests+quotaEpsilon || req.Memory > memory+quotaEpsilon {
+		return nil, status.Errorf(codes.ResourceExhausted, "not enough quota left on device %s", req.DeviceId)
+	}
+	r := FakeReservation{Requests: req.Requests, Limit: req.Limit, Memory: req.Memory}
+	reply := &dmpb.ReservePodQuotaLeaseReply{}
+	if req.LeaseSeconds > 0 {
+		r.ExpiresAt = f.clock.Now().Add(time.Duration(req.LeaseSeconds) * time.Second)
+		reply.ExpiresAt = r.ExpiresAt.Unix()
+	}
+	d.reservations[req.PodId] = r
+	return reply, nil
+}
+
+func (f *FakeDeviceManager) RenewPodQuota(_ context.Context, req *dmpb.RenewPodQuotaRequest) (*dmpb.RenewPodQuotaReply, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.expireLocked()
+
+	d, ok := f.devices[req.DeviceId]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "device %s not found", req.DeviceId)
+	}
+	r, ok := d.reservations[req.PodId]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "pod %s has no quota on device %s", req.PodId, req.DeviceId)
+	}
+	if req.LeaseSeconds <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid lease of %d seconds", req.LeaseSeconds)
+	}
+	r.ExpiresAt = f.clock.Now().Add(time.Duration(req.LeaseSeconds) * time.Second)
+	d.reservations[req.PodId] = r
+	return &dmpb.RenewPodQuotaReply{ExpiresAt: r.ExpiresAt.Unix()}, nil
+}
+
+func (f *FakeDeviceManager) UnreservePodQuota(_ context.Context, req *pb.UnreservePodQuotaRequest) (*pb.UnreservePodQuotaQuotaReply, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.expireLocked()
+
+	d, ok := f.devices[req.DeviceId]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "device %s not found", req.DeviceId)
+	}
+	if _, ok := d.reservations[req.PodId]; !ok {
+		return nil, status.Errorf(codes.NotFound, "pod %s has no quota on device %s", req.PodId, req.DeviceId)
+	}
+	delete(d.reservations, req.PodId)
+	return &pb.UnreservePodQuotaQuotaReply{}, nil
+}
+
+func (f *FakeDeviceManager) ListReservations(_ context.Context, _ *dmpb.ListReservationsRequest) (*dmpb.ListReservationsReply, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.expireLocked()
+
+	reply := &dmpb.ListReservationsReply{}
+	for deviceId, d := range f.devices {
+		for podId, r := range d.reservations {
+			reply.Reservations = append(reply.Reservations, &dmpb.Reservation{
+				DeviceId: deviceId,
+				PodId:    podId,
+				Requests: r.Requests,
+				Limit:    r.Limit,
+				Memory:   r.Memory,
+			})
+		}
+	}
+	sort.Slice(reply.Reservations, func(i, j int) bool {
+		a, b := reply.Reservations[i], reply.Reservations[j]
+		return a.DeviceId < b.DeviceId || a.DeviceId == b.DeviceId && a.PodId < b.PodId
+	})
+	return reply, nil
+}
+
+// expireLocked drops the reservations whose lease expired.
+func (f *FakeDeviceManager) expireLocked() {
+	now := f.clock.Now()
+	for _, d := range f.devices {
+		for podId, r := range d.reservations {
+			if !r.ExpiresAt.IsZero() && !now.Before(r.ExpiresAt) {
+				delete(d.reservations, podId)
+			}
+		}
+	}
+}
+
+// free returns the compute and memory left on the device, ignoring the
+// reservation of exceptPod.
+func (d *fakeDevice) free(exceptPod string) (float64, float64) {
+	requests, memory := 1.0, 1.0
+	for podId, r := range d.reservations {
+		if podId == exceptPod {
+			continue
+		}
+		requests -= r.Requests
+		memory -= r.Memory
+	}
+	return requests, memory
+}
+
+// Serve serves f on lis until the test ends, e.g. with grpc.Creds to require TLS.
+func (f *FakeDeviceManager) Serve(t testing.TB, lis net.Listener, opts ...grpc.ServerOption) {
+	server := grpc.NewServer(append(opts, grpc.UnaryInterceptor(f.intercept))...)
+	dmpb.RegisterDeviceManagerServer(server, f)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+}
+
+// ServeFakeDeviceManagers serves the device managers, by node IP, over
+// in-memory connections until the test ends. It returns the dial option that
+// connects gRPC clients to the device manager of the host they dial.
+func ServeFakeDeviceManagers(t testing.TB, fakes map[string]*FakeDeviceManager) grpc.DialOption {
+	listeners := map[string]*bufconn.Listener{}
+	for nodeIP, f := range fakes {
+		lis := bufconn.Listen(1024 * 1024)
+		f.Serve(t, lis)
+		listeners[nodeIP] = lis
+	}
+
+	return grpc.WithContextDialer(func(ctx context.Context, address string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		lis, ok := listeners[host]
+		if !ok {
+			return nil, fmt.Errorf("no device manager at %s", address)
+		}
+		return lis.DialContext(ctx)
+	})
+}